@@ -33,6 +33,21 @@ type EnrollPayload struct {
 	// hardware hash from device
 	// if specified, pass to dsts device add
 	HardwareHash string `json:"hardware_hash"`
+	// tpm attestation of the csr key verified by es. nil if the
+	// enroll was not attested.
+	AttestationResult *AttestationResult `json:"attestation_result,omitempty"`
+}
+
+type AttestationResult struct {
+	// tpm vendor and tcg manufacturer id from the AK or EK certificate
+	Manufacturer    string `json:"manufacturer"`
+	ManufacturerId  string `json:"manufacturer_id"`
+	Model           string `json:"model,omitempty"`
+	FirmwareVersion string `json:"firmware_version"`
+	// hex sha-256 of the AK public key
+	AKHash string `json:"ak_hash"`
+	// hex sha-256 of the EK public key when es bound the AK to the EK
+	EKHash string `json:"ek_hash,omitempty"`
 }
 
 // Return next incoming pending enroll message.
@@ -117,6 +117,15 @@ func processSinglePendingEnroll(en *EnrollPayload) error {
 		zap.String("type", en.Type),
 		zap.String("enroll_id", en.ID),
 		zap.String("tenant_id", en.TenantId))
+	if en.AttestationResult != nil {
+		eswLogger.Info("Device certificate key is tpm attested",
+			zap.String("enroll_id", en.ID),
+			zap.String("manufacturer", en.AttestationResult.Manufacturer),
+			zap.String("firmware_version",
+				en.AttestationResult.FirmwareVersion),
+			zap.String("ak_hash", en.AttestationResult.AKHash),
+			zap.String("ek_hash", en.AttestationResult.EKHash))
+	}
 	//inject the enroll id
 	dc.EnrollId = en.ID
 	dc.TenantId = en.TenantId
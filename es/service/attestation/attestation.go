@@ -0,0 +1,427 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+// Package attestation verifies TPM 2.0 evidence that the key of a csr
+// was created in, and cannot leave, a genuine TPM.
+//
+// The attestation key (AK) is trusted in one of two ways. A device with
+// an AK certificate from a trusted attestation CA sends it with the
+// evidence; the CA activated a credential for the AK against the
+// endorsement key (EK) of the TPM before issuing it. Otherwise the device
+// asks for a challenge for its EK certificate and AK, and sends back the
+// secret TPM2_ActivateCredential recovered from it. Only the TPM holding
+// the EK, trusted through the EK manufacturer roots, can recover it and
+// only for that AK. Challenges are sealed and short lived, so nothing is
+// stored between the two requests. Evidence is bound to the csr through
+// the qualifying data of TPM2_Certify, and a csr can only be enrolled
+// once, so evidence cannot be replayed.
+package attestation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HPInc/krypton-es/es/service/config"
+	"go.uber.org/zap"
+)
+
+var (
+	// Structured logging using Uber Zap.
+	esLogger *zap.Logger
+
+	// trusted attestation CA roots and intermediates. nil if AK
+	// certificates are not trusted.
+	akRoots *x509.CertPool
+	// trusted EK manufacturer roots and intermediates. nil if credential
+	// activation is not configured.
+	ekRoots *x509.CertPool
+
+	oidSubjectAltName  = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidTPMManufacturer = asn1.ObjectIdentifier{2, 23, 133, 2, 1}
+	oidTPMModel        = asn1.ObjectIdentifier{2, 23, 133, 2, 2}
+
+	ErrNotConfigured      = errors.New("attestation is not configured")
+	ErrInvalidEvidence    = errors.New("attestation evidence must be base64 encoded")
+	ErrAKNotTrusted       = errors.New("ak certificate is not issued by a trusted attestation ca")
+	ErrEKNotTrusted       = errors.New("ek certificate is not issued by a trusted tpm manufacturer")
+	ErrAKCertMismatch     = errors.New("ak_public does not match ak certificate")
+	ErrInvalidAK          = errors.New("ak must be a restricted tpm resident signing key")
+	ErrNotCertifyInfo     = errors.New("certify_info is not a tpm certify attestation")
+	ErrSignature          = errors.New("certify_info signature does not verify with ak")
+	ErrQualifyingData     = errors.New("certify_info must be qualified with the sha-256 of the csr")
+	ErrCertifiedKey       = errors.New("certify_info does not certify key_public")
+	ErrKeyNotTPMResident  = errors.New("key_public is not a tpm resident key")
+	ErrCsrKeyMismatch     = errors.New("key_public does not match csr public key")
+	ErrNoTrustedAKRoots   = errors.New("no certificates in ak roots file")
+	ErrNoTrustedEKRoots   = errors.New("no certificates in ek roots file")
+	ErrInvalidAKExtension = errors.New("invalid tpm subject alternative name in ak certificate")
+	ErrInvalidEKExtension = errors.New("invalid tpm subject alternative name in ek certificate")
+	ErrUnsupportedEK      = errors.New("ek must be an rsa 2048 or ecc nist p-256 key")
+	ErrInvalidChallenge   = errors.New("attestation challenge is invalid or expired")
+	ErrChallengeMismatch  = errors.New("attestation challenge was not issued for this ek and ak")
+	ErrActivatedSecret    = errors.New("activated_secret does not match the attestation challenge")
+	ErrChallengeKey       = errors.New("attestation challenge key must be base64 of 32 bytes")
+)
+
+// TPM evidence sent with an enroll. all fields are base64. structures
+// are as returned by the TPM, without TPM2B size prefixes.
+type Evidence struct {
+	// der AK certificate issued by an attestation CA. when empty the AK
+	// is bound to the EK by the challenge fields below.
+	AKCertificate string `json:"ak_certificate,omitempty"`
+	// der EK certificate the challenge was issued for
+	EKCertificate string `json:"ek_certificate,omitempty"`
+	// challenge from Post /attestation/challenge
+	Challenge string `json:"challenge,omitempty"`
+	// secret recovered from the challenge by TPM2_ActivateCredential
+	ActivatedSecret string `json:"activated_secret,omitempty"`
+	// TPMT_PUBLIC of the attestation key
+	AKPublic string `json:"ak_public"`
+	// TPMT_PUBLIC of the csr key
+	KeyPublic string `json:"key_public"`
+	// TPMS_ATTEST from TPM2_Certify of the csr key with the AK.
+	// qualifying data is the sha-256 of the der csr.
+	CertifyInfo string `json:"certify_info"`
+	// TPMT_SIGNATURE of certify_info by the AK
+	CertifySignature string `json:"certify_signature"`
+}
+
+// verified attestation. stored with the enroll and sent to the worker.
+type Result struct {
+	// tpm vendor from the AK or EK certificate, such as IFX or NTC
+	Manufacturer string `json:"manufacturer"`
+	// tcg manufacturer id, such as id:49465800
+	ManufacturerId string `json:"manufacturer_id"`
+	Model          string `json:"model,omitempty"`
+	// tpm firmware version from certify_info
+	FirmwareVersion string `json:"firmware_version"`
+	// hex sha-256 of the AK public key
+	AKHash string `json:"ak_hash"`
+	// hex sha-256 of the EK public key when the AK was bound to it by
+	// credential activation. stable for the life of the tpm.
+	EKHash string `json:"ek_hash,omitempty"`
+}
+
+func Init(logger *zap.Logger, settings *config.Attestation) error {
+	esLogger = logger
+	if settings.AKRootsFile == "" && settings.EKRootsFile == "" {
+		esLogger.Info("TPM attestation is not configured")
+		return nil
+	}
+	var err error
+	if settings.AKRootsFile != "" {
+		akRoots, err = loadRoots(settings.AKRootsFile, ErrNoTrustedAKRoots)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.EKRootsFile != "" {
+		// challenges are sealed for the device to send back with the
+		// evidence
+		if err = initChallengeKey(settings.ChallengeKey); err != nil {
+			esLogger.Error("Invalid attestation challenge key",
+				zap.Error(err))
+			return err
+		}
+		ekRoots, err = loadRoots(settings.EKRootsFile, ErrNoTrustedEKRoots)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadRoots(file string, errNoRoots error) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		esLogger.Error("Failed to read attestation roots file",
+			zap.String("file", file),
+			zap.Error(err))
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		esLogger.Error("Failed to load attestation roots file",
+			zap.String("file", file))
+		return nil, errNoRoots
+	}
+	esLogger.Info("Loaded trusted attestation roots",
+		zap.String("file", file))
+	return pool, nil
+}
+
+func IsConfigured() bool {
+	return akRoots != nil || ekRoots != nil
+}
+
+// verify evidence that the csr key is resident in a tpm with a trusted AK
+func (e *Evidence) Verify(cr *x509.CertificateRequest) (*Result, error) {
+	if !IsConfigured() {
+		return nil, ErrNotConfigured
+	}
+	akRaw, keyRaw, certifyInfo, signatureRaw, err := e.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	ak, err := parseTPMPublic(akRaw)
+	if err != nil {
+		return nil, err
+	}
+	if !isAttestationKey(ak) {
+		return nil, ErrInvalidAK
+	}
+	// the certificate that vouches for the ak identifies the tpm
+	var tpmCert *x509.Certificate
+	errInvalidExtension := ErrInvalidAKExtension
+	if e.AKCertificate != "" {
+		tpmCert, err = e.verifyAKCertificate(ak)
+	} else {
+		tpmCert, err = e.verifyActivation(ak)
+		errInvalidExtension = ErrInvalidEKExtension
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attest, err := parseTPMAttest(certifyInfo)
+	if err != nil {
+		return nil, err
+	}
+	if attest.Type != tpmStAttestCertify {
+		return nil, ErrNotCertifyInfo
+	}
+	signature, err := parseTPMSignature(signatureRaw)
+	if err != nil {
+		return nil, err
+	}
+	if err = signature.verify(ak.PublicKey, certifyInfo); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	csrHash := sha256.Sum256(cr.Raw)
+	if !bytes.Equal(attest.ExtraData, csrHash[:]) {
+		return nil, ErrQualifyingData
+	}
+
+	key, err := parseTPMPublic(keyRaw)
+	if err != nil {
+		return nil, err
+	}
+	name, err := key.name()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(name, attest.CertifiedName) {
+		return nil, ErrCertifiedKey
+	}
+	if !key.hasAttributes(attrTPMResident) {
+		return nil, ErrKeyNotTPMResident
+	}
+	if !isSamePublicKey(key.PublicKey, cr.PublicKey) {
+		return nil, ErrCsrKeyMismatch
+	}
+
+	result, err := getTPMInfo(tpmCert, errInvalidExtension)
+	if err != nil {
+		return nil, err
+	}
+	result.FirmwareVersion = formatFirmwareVersion(attest.FirmwareVersion)
+	akSpki, err := x509.MarshalPKIXPublicKey(ak.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(akSpki)
+	result.AKHash = hex.EncodeToString(sum[:])
+	if e.AKCertificate == "" {
+		sum = sha256.Sum256(tpmCert.RawSubjectPublicKeyInfo)
+		result.EKHash = hex.EncodeToString(sum[:])
+	}
+	return result, nil
+}
+
+// AK trusted through a certificate from an attestation CA
+func (e *Evidence) verifyAKCertificate(ak *tpmPublic) (*x509.Certificate,
+	error) {
+	if akRoots == nil {
+		return nil, fmt.Errorf("%w: no ak roots", ErrAKNotTrusted)
+	}
+	der, err := decodeEvidence("ak_certificate", e.AKCertificate)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := verifyTPMCertificate(der, akRoots, ErrAKNotTrusted)
+	if err != nil {
+		return nil, err
+	}
+	if !isSamePublicKey(ak.PublicKey, cert.PublicKey) {
+		return nil, ErrAKCertMismatch
+	}
+	return cert, nil
+}
+
+// AK bound to a trusted EK by the secret the tpm recovered from a
+// challenge issued for both. returns the EK certificate.
+func (e *Evidence) verifyActivation(ak *tpmPublic) (*x509.Certificate,
+	error) {
+	if ekRoots == nil {
+		return nil, fmt.Errorf("%w: no ek roots", ErrEKNotTrusted)
+	}
+	ekDer, err := decodeEvidence("ek_certificate", e.EKCertificate)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := decodeEvidence("activated_secret", e.ActivatedSecret)
+	if err != nil {
+		return nil, err
+	}
+	state, err := openChallenge(e.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	akName, err := ak.name()
+	if err != nil {
+		return nil, err
+	}
+	ekHash := sha256.Sum256(ekDer)
+	if !bytes.Equal(state.EKHash, ekHash[:]) ||
+		!bytes.Equal(state.AKName, akName) {
+		return nil, ErrChallengeMismatch
+	}
+	if subtle.ConstantTimeCompare(secret, state.Secret) != 1 {
+		return nil, ErrActivatedSecret
+	}
+	// verified when the challenge was issued. verified again in case the
+	// roots changed since.
+	return verifyTPMCertificate(ekDer, ekRoots, ErrEKNotTrusted)
+}
+
+// restricted signing key that cannot leave the tpm
+func isAttestationKey(ak *tpmPublic) bool {
+	return ak.hasAttributes(attrTPMResident|attrRestricted|attrSign) &&
+		!ak.hasAttributes(attrDecrypt)
+}
+
+func isSamePublicKey(a, b crypto.PublicKey) bool {
+	pub, ok := a.(interface {
+		Equal(crypto.PublicKey) bool
+	})
+	return ok && pub.Equal(b)
+}
+
+// fields common to both ways of trusting the AK
+func (e *Evidence) decode() (ak, key, certifyInfo, signature []byte,
+	err error) {
+	fields := []struct {
+		name  string
+		value string
+		out   *[]byte
+	}{
+		{"ak_public", e.AKPublic, &ak},
+		{"key_public", e.KeyPublic, &key},
+		{"certify_info", e.CertifyInfo, &certifyInfo},
+		{"certify_signature", e.CertifySignature, &signature},
+	}
+	for _, f := range fields {
+		if *f.out, err = decodeEvidence(f.name, f.value); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	return ak, key, certifyInfo, signature, nil
+}
+
+func decodeEvidence(name, value string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEvidence, name)
+	}
+	return b, nil
+}
+
+// AK and EK certificates carry tpm details in a directory name subject
+// alternative name that x509 does not handle. it is parsed separately.
+func verifyTPMCertificate(der []byte, roots *x509.CertPool,
+	errNotTrusted error) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotTrusted, err)
+	}
+	unhandled := cert.UnhandledCriticalExtensions[:0]
+	for _, oid := range cert.UnhandledCriticalExtensions {
+		if !oid.Equal(oidSubjectAltName) {
+			unhandled = append(unhandled, oid)
+		}
+	}
+	cert.UnhandledCriticalExtensions = unhandled
+	if _, err = cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotTrusted, err)
+	}
+	return cert, nil
+}
+
+// manufacturer and model from the tcg directory name in the AK or EK
+// certificate subject alternative name
+func getTPMInfo(cert *x509.Certificate, errInvalidExtension error) (*Result,
+	error) {
+	var result Result
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		var names []asn1.RawValue
+		if _, err := asn1.Unmarshal(ext.Value, &names); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidExtension, err)
+		}
+		for _, n := range names {
+			// directoryName [4] is explicit since Name is a choice
+			if n.Class != asn1.ClassContextSpecific || n.Tag != 4 {
+				continue
+			}
+			var rdns pkix.RDNSequence
+			if _, err := asn1.Unmarshal(n.Bytes, &rdns); err != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidExtension, err)
+			}
+			for _, rdn := range rdns {
+				for _, atv := range rdn {
+					value, _ := atv.Value.(string)
+					switch {
+					case atv.Type.Equal(oidTPMManufacturer):
+						result.ManufacturerId = value
+					case atv.Type.Equal(oidTPMModel):
+						result.Model = value
+					}
+				}
+			}
+		}
+	}
+	result.Manufacturer = getManufacturerName(result.ManufacturerId)
+	return &result, nil
+}
+
+// tcg manufacturer ids are ascii vendor ids in hex. id:49465800 is IFX.
+func getManufacturerName(id string) string {
+	b, err := hex.DecodeString(strings.TrimPrefix(id, "id:"))
+	if err != nil {
+		return id
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// the upper 32 bits are TPM_PT_FIRMWARE_VERSION_1: major.minor
+func formatFirmwareVersion(v uint64) string {
+	return fmt.Sprintf("%d.%d", v>>48, (v>>32)&0xffff)
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package attestation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/config"
+	"go.uber.org/zap"
+)
+
+const (
+	testManufacturerId  = "id:49465800"
+	testModel           = "SLB9670"
+	testFirmwareVersion = uint64(7)<<48 | uint64(85)<<32 | 4555
+	testAKAttributes    = attrTPMResident | attrRestricted | attrSign
+)
+
+// tpm with an AK certificate issued by an attestation CA, or with an
+// EK certificate issued by a tpm manufacturer
+type testTPM struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+	ak      *ecdsa.PrivateKey
+	akDer   []byte
+	ek      crypto.Signer
+	ekDer   []byte
+	// activated challenge for the ak
+	challenge string
+	secret    []byte
+}
+
+func TestMain(m *testing.M) {
+	esLogger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestInitNotConfigured(t *testing.T) {
+	akRoots, ekRoots = nil, nil
+	handleError(t, Init(zap.NewNop(), &config.Attestation{}))
+	if IsConfigured() {
+		t.Errorf("Expected attestation to not be configured")
+	}
+	_, err := (&Evidence{}).Verify(&x509.CertificateRequest{})
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected %v, found: %v", ErrNotConfigured, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tpm := newTestTPM(t)
+	setTestAKRoots(t, tpm.root)
+	key := newTestKey(t)
+	keyPublic := newTestPublic(&key.PublicKey, attrTPMResident|attrSign)
+	cr := newTestCSR(t, key)
+
+	result, err := tpm.certify(t, keyPublic, cr).Verify(cr)
+	handleError(t, err)
+	if result.Manufacturer != "IFX" ||
+		result.ManufacturerId != testManufacturerId ||
+		result.Model != testModel {
+		t.Errorf("Unexpected tpm info: %+v", result)
+	}
+	if result.FirmwareVersion != "7.85" {
+		t.Errorf("Expected firmware version 7.85, found: %s",
+			result.FirmwareVersion)
+	}
+	if len(result.AKHash) != sha256.Size*2 {
+		t.Errorf("Expected hex sha-256 ak hash, found: %s", result.AKHash)
+	}
+}
+
+func TestVerifyFails(t *testing.T) {
+	tpm := newTestTPM(t)
+	setTestAKRoots(t, tpm.root)
+	key := newTestKey(t)
+	keyPublic := newTestPublic(&key.PublicKey, attrTPMResident|attrSign)
+	otherKey := newTestKey(t)
+	otherPublic := newTestPublic(&otherKey.PublicKey, attrTPMResident|attrSign)
+	cr := newTestCSR(t, key)
+
+	tests := []struct {
+		name     string
+		evidence func() *Evidence
+		err      error
+	}{
+		{"csr key mismatch", func() *Evidence {
+			return tpm.certify(t, otherPublic, cr)
+		}, ErrCsrKeyMismatch},
+		{"key not tpm resident", func() *Evidence {
+			return tpm.certify(t, newTestPublic(&key.PublicKey, attrSign), cr)
+		}, ErrKeyNotTPMResident},
+		{"certified key mismatch", func() *Evidence {
+			e := tpm.certify(t, otherPublic, cr)
+			e.KeyPublic = base64.StdEncoding.EncodeToString(keyPublic)
+			return e
+		}, ErrCertifiedKey},
+		{"signature", func() *Evidence {
+			e := tpm.certify(t, keyPublic, cr)
+			other := newTestTPM(t).certify(t, keyPublic, cr)
+			e.CertifySignature = other.CertifySignature
+			return e
+		}, ErrSignature},
+		// evidence of another csr of the same key is not replayed
+		{"qualifying data", func() *Evidence {
+			return tpm.certify(t, keyPublic, newTestCSR(t, key))
+		}, ErrQualifyingData},
+		{"ak not restricted", func() *Evidence {
+			e := tpm.certify(t, keyPublic, cr)
+			e.AKPublic = base64.StdEncoding.EncodeToString(
+				newTestPublic(&tpm.ak.PublicKey, attrTPMResident|attrSign))
+			return e
+		}, ErrInvalidAK},
+		// a software key cannot borrow the certificate of a tpm ak
+		{"ak certificate mismatch", func() *Evidence {
+			software := &testTPM{root: tpm.root, rootKey: tpm.rootKey,
+				ak: newTestKey(t), akDer: tpm.akDer}
+			return software.certify(t, keyPublic, cr)
+		}, ErrAKCertMismatch},
+		{"untrusted ak", func() *Evidence {
+			return newTestTPM(t).certify(t, keyPublic, cr)
+		}, ErrAKNotTrusted},
+		{"invalid evidence", func() *Evidence {
+			e := tpm.certify(t, keyPublic, cr)
+			e.CertifyInfo = "not base64"
+			return e
+		}, ErrInvalidEvidence},
+		{"truncated public", func() *Evidence {
+			e := tpm.certify(t, keyPublic, cr)
+			e.KeyPublic = base64.StdEncoding.EncodeToString(keyPublic[:10])
+			return e
+		}, ErrInvalidTPMStructure},
+	}
+	for _, test := range tests {
+		if _, err := test.evidence().Verify(cr); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, found: %v", test.name, test.err, err)
+		}
+	}
+}
+
+func newTestTPM(t *testing.T) *testTPM {
+	root, rootKey := newTestCA(t, "test attestation ca")
+	ak := newTestKey(t)
+	akDer := newTestTPMCertificate(t, root, rootKey, &ak.PublicKey)
+	return &testTPM{root: root, rootKey: rootKey, ak: ak, akDer: akDer}
+}
+
+func newTestCA(t *testing.T, name string) (*x509.Certificate,
+	*ecdsa.PrivateKey) {
+	rootKey := newTestKey(t)
+	rootTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, &rootTemplate,
+		&rootTemplate, &rootKey.PublicKey, rootKey)
+	handleError(t, err)
+	root, err := x509.ParseCertificate(rootDer)
+	handleError(t, err)
+	return root, rootKey
+}
+
+// der AK or EK certificate of pub issued by root
+func newTestTPMCertificate(t *testing.T, root *x509.Certificate,
+	rootKey *ecdsa.PrivateKey, pub crypto.PublicKey) []byte {
+	// tpm details are in a directory name, the only subject alternative
+	// name, which makes the critical extension unhandled by x509
+	name, err := asn1.Marshal(pkix.RDNSequence{
+		{{Type: oidTPMManufacturer, Value: testManufacturerId}},
+		{{Type: oidTPMModel, Value: testModel}},
+	})
+	handleError(t, err)
+	san, err := asn1.Marshal([]asn1.RawValue{{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        4,
+		IsCompound: true,
+		Bytes:      name,
+	}})
+	handleError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidSubjectAltName, Critical: true, Value: san},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, root, pub,
+		rootKey)
+	handleError(t, err)
+	return der
+}
+
+// evidence of TPM2_Certify of keyPublic with the ak of the tpm,
+// qualified with the csr
+func (tpm *testTPM) certify(t *testing.T, keyPublic []byte,
+	cr *x509.CertificateRequest) *Evidence {
+	key, err := parseTPMPublic(keyPublic)
+	handleError(t, err)
+	name, err := key.name()
+	handleError(t, err)
+
+	var info []byte
+	info = binary.BigEndian.AppendUint32(info, tpmGeneratedValue)
+	info = binary.BigEndian.AppendUint16(info, tpmStAttestCertify)
+	info = append2B(info, []byte("signer"))
+	csrHash := sha256.Sum256(cr.Raw)
+	info = append2B(info, csrHash[:])
+	info = append(info, make([]byte, 8+4+4+1)...)
+	info = binary.BigEndian.AppendUint64(info, testFirmwareVersion)
+	info = append2B(info, name)
+	info = append2B(info, name)
+
+	digest := sha256.Sum256(info)
+	r, s, err := ecdsa.Sign(rand.Reader, tpm.ak, digest[:])
+	handleError(t, err)
+	var signature []byte
+	signature = binary.BigEndian.AppendUint16(signature, tpmAlgEcdsa)
+	signature = binary.BigEndian.AppendUint16(signature, tpmAlgSha256)
+	signature = append2B(signature, r.Bytes())
+	signature = append2B(signature, s.Bytes())
+
+	encode := base64.StdEncoding.EncodeToString
+	return &Evidence{
+		AKCertificate:    encode(tpm.akDer),
+		EKCertificate:    encode(tpm.ekDer),
+		Challenge:        tpm.challenge,
+		ActivatedSecret:  encode(tpm.secret),
+		AKPublic:         encode(newTestPublic(&tpm.ak.PublicKey, testAKAttributes)),
+		KeyPublic:        encode(keyPublic),
+		CertifyInfo:      encode(info),
+		CertifySignature: encode(signature),
+	}
+}
+
+// TPMT_PUBLIC of a p-256 ecdsa signing key
+func newTestPublic(key *ecdsa.PublicKey, attributes uint32) []byte {
+	var p []byte
+	p = binary.BigEndian.AppendUint16(p, tpmAlgEcc)
+	p = binary.BigEndian.AppendUint16(p, tpmAlgSha256)
+	p = binary.BigEndian.AppendUint32(p, attributes)
+	p = append2B(p, nil)
+	p = binary.BigEndian.AppendUint16(p, tpmAlgNull)
+	p = binary.BigEndian.AppendUint16(p, tpmAlgEcdsa)
+	p = binary.BigEndian.AppendUint16(p, tpmAlgSha256)
+	p = binary.BigEndian.AppendUint16(p, tpmEccNistP256)
+	p = binary.BigEndian.AppendUint16(p, tpmAlgNull)
+	p = append2B(p, key.X.FillBytes(make([]byte, 32)))
+	return append2B(p, key.Y.FillBytes(make([]byte, 32)))
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	return key
+}
+
+func newTestCSR(t *testing.T, key *ecdsa.PrivateKey) *x509.CertificateRequest {
+	der, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{Subject: pkix.Name{CommonName: "test device"}},
+		key)
+	handleError(t, err)
+	cr, err := x509.ParseCertificateRequest(der)
+	handleError(t, err)
+	return cr
+}
+
+// load root through Init for the duration of the test
+func setTestAKRoots(t *testing.T, root *x509.Certificate) {
+	handleError(t, Init(zap.NewNop(), &config.Attestation{
+		AKRootsFile: writeTestRoots(t, root)}))
+	t.Cleanup(func() { akRoots = nil })
+}
+
+func writeTestRoots(t *testing.T, root *x509.Certificate) string {
+	file := filepath.Join(t.TempDir(), "roots.pem")
+	handleError(t, os.WriteFile(file, pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0600))
+	return file
+}
+
+func handleError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
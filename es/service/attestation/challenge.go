@@ -0,0 +1,168 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package attestation
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// aes-256 key size
+	challengeKeySize = 32
+	// size of the secret wrapped for the tpm. TPM2B_DIGEST of sha-256.
+	challengeSecretSize = sha256.Size
+	// a device activates the credential and enrolls right after asking
+	challengeLifetime = 5 * time.Minute
+	// bound as additional data so nothing else sealed with the key opens
+	// as a challenge
+	challengeLabel = "krypton-es attestation challenge"
+)
+
+// seals challenges. nil when credential activation is not configured.
+var challengeAead cipher.AEAD
+
+// request for a credential activation challenge. fields are base64.
+type ChallengeRequest struct {
+	// der EK certificate
+	EKCertificate string `json:"ek_certificate"`
+	// TPMT_PUBLIC of the attestation key
+	AKPublic string `json:"ak_public"`
+}
+
+// challenge for TPM2_ActivateCredential. fields are base64. structures
+// are without TPM2B size prefixes.
+type Challenge struct {
+	// sent back unchanged with the evidence
+	Challenge string `json:"challenge"`
+	// TPM2B_ID_OBJECT
+	CredentialBlob string `json:"credential_blob"`
+	// TPM2B_ENCRYPTED_SECRET
+	EncryptedSecret string `json:"encrypted_secret"`
+	// evidence must be sent before this time
+	ExpiresTime time.Time `json:"expires_time"`
+}
+
+// what a challenge was issued for. sealed into Challenge.Challenge.
+type challengeState struct {
+	// sha-256 of the der EK certificate
+	EKHash []byte `json:"ek"`
+	AKName []byte `json:"ak"`
+	Secret []byte `json:"secret"`
+	// unix seconds
+	Expires int64 `json:"exp"`
+}
+
+// key is base64 encoded 32 random bytes
+func initChallengeKey(key string) error {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(b) != challengeKeySize {
+		return ErrChallengeKey
+	}
+	block, err := aes.NewCipher(b)
+	if err != nil {
+		return err
+	}
+	challengeAead, err = cipher.NewGCM(block)
+	return err
+}
+
+// wrap a random secret for the AK in the request so only the tpm of the
+// trusted EK can recover it
+func NewChallenge(r *ChallengeRequest) (*Challenge, error) {
+	if ekRoots == nil {
+		return nil, fmt.Errorf("%w: no ek roots", ErrNotConfigured)
+	}
+	ekDer, err := decodeEvidence("ek_certificate", r.EKCertificate)
+	if err != nil {
+		return nil, err
+	}
+	akRaw, err := decodeEvidence("ak_public", r.AKPublic)
+	if err != nil {
+		return nil, err
+	}
+	ek, err := verifyTPMCertificate(ekDer, ekRoots, ErrEKNotTrusted)
+	if err != nil {
+		return nil, err
+	}
+	ak, err := parseTPMPublic(akRaw)
+	if err != nil {
+		return nil, err
+	}
+	if !isAttestationKey(ak) {
+		return nil, ErrInvalidAK
+	}
+	akName, err := ak.name()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, challengeSecretSize)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, err
+	}
+	credentialBlob, encryptedSecret, err := makeCredential(ek.PublicKey,
+		akName, secret)
+	if err != nil {
+		return nil, err
+	}
+	ekHash := sha256.Sum256(ekDer)
+	expires := time.Now().Add(challengeLifetime).Truncate(time.Second)
+	sealed, err := sealChallenge(&challengeState{
+		EKHash:  ekHash[:],
+		AKName:  akName,
+		Secret:  secret,
+		Expires: expires.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Challenge:       sealed,
+		CredentialBlob:  base64.StdEncoding.EncodeToString(credentialBlob),
+		EncryptedSecret: base64.StdEncoding.EncodeToString(encryptedSecret),
+		ExpiresTime:     expires.UTC(),
+	}, nil
+}
+
+func sealChallenge(state *challengeState) (string, error) {
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, challengeAead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := challengeAead.Seal(nonce, nonce, plain, []byte(challengeLabel))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// state of a challenge this service issued that has not expired
+func openChallenge(sealed string) (*challengeState, error) {
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	nonceSize := challengeAead.NonceSize()
+	if err != nil || len(b) < nonceSize {
+		return nil, ErrInvalidChallenge
+	}
+	plain, err := challengeAead.Open(nil, b[:nonceSize], b[nonceSize:],
+		[]byte(challengeLabel))
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	var state challengeState
+	if err = json.Unmarshal(plain, &state); err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	if time.Now().Unix() > state.Expires {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidChallenge)
+	}
+	return &state, nil
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package attestation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/config"
+	"go.uber.org/zap"
+)
+
+var testChallengeKey = base64.StdEncoding.EncodeToString(
+	bytes.Repeat([]byte{7}, challengeKeySize))
+
+func TestMakeCredential(t *testing.T) {
+	rsaEK, err := rsa.GenerateKey(rand.Reader, 2048)
+	handleError(t, err)
+	for _, ek := range []crypto.Signer{rsaEK, newTestKey(t)} {
+		akName := []byte("ak name")
+		secret := []byte("credential secret")
+		blob, encryptedSecret, err := makeCredential(ek.Public(), akName,
+			secret)
+		handleError(t, err)
+		activated, err := activateCredential(ek, akName, blob,
+			encryptedSecret)
+		handleError(t, err)
+		if !bytes.Equal(activated, secret) {
+			t.Errorf("%T: expected secret %q, found: %q", ek, secret,
+				activated)
+		}
+		// the tpm only releases the secret for the ak it was made for
+		if _, err = activateCredential(ek, []byte("other ak"), blob,
+			encryptedSecret); err == nil {
+			t.Errorf("%T: expected activation for another ak to fail", ek)
+		}
+	}
+}
+
+func TestMakeCredentialUnsupportedEK(t *testing.T) {
+	rsaEK, err := rsa.GenerateKey(rand.Reader, 1024)
+	handleError(t, err)
+	p384EK, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	handleError(t, err)
+	for _, ek := range []crypto.PublicKey{&rsaEK.PublicKey, &p384EK.PublicKey} {
+		_, _, err := makeCredential(ek, []byte("ak"), []byte("secret"))
+		if !errors.Is(err, ErrUnsupportedEK) {
+			t.Errorf("%T: expected %v, found: %v", ek, ErrUnsupportedEK, err)
+		}
+	}
+}
+
+func TestInitEKRootsRequireChallengeKey(t *testing.T) {
+	root, _ := newTestCA(t, "test tpm manufacturer")
+	t.Cleanup(func() { ekRoots, challengeAead = nil, nil })
+	err := Init(zap.NewNop(), &config.Attestation{
+		EKRootsFile: writeTestRoots(t, root)})
+	if !errors.Is(err, ErrChallengeKey) {
+		t.Errorf("Expected %v, found: %v", ErrChallengeKey, err)
+	}
+}
+
+func TestVerifyActivation(t *testing.T) {
+	tpm := newTestEKTPM(t)
+	setTestEKRoots(t, tpm.root)
+	key := newTestKey(t)
+	keyPublic := newTestPublic(&key.PublicKey, attrTPMResident|attrSign)
+	cr := newTestCSR(t, key)
+
+	result, err := tpm.activate(t).certify(t, keyPublic, cr).Verify(cr)
+	handleError(t, err)
+	if result.Manufacturer != "IFX" || result.Model != testModel {
+		t.Errorf("Unexpected tpm info: %+v", result)
+	}
+	ekSum := sha256.Sum256(tpm.ekCert(t).RawSubjectPublicKeyInfo)
+	if result.EKHash != hex.EncodeToString(ekSum[:]) {
+		t.Errorf("Expected ek hash of ek certificate, found: %s",
+			result.EKHash)
+	}
+	if len(result.AKHash) != sha256.Size*2 {
+		t.Errorf("Expected hex sha-256 ak hash, found: %s", result.AKHash)
+	}
+}
+
+func TestVerifyActivationFails(t *testing.T) {
+	tpm := newTestEKTPM(t)
+	setTestEKRoots(t, tpm.root)
+	key := newTestKey(t)
+	keyPublic := newTestPublic(&key.PublicKey, attrTPMResident|attrSign)
+	cr := newTestCSR(t, key)
+
+	tests := []struct {
+		name     string
+		evidence func() *Evidence
+		err      error
+	}{
+		{"activated secret", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			e.ActivatedSecret = base64.StdEncoding.EncodeToString(
+				make([]byte, challengeSecretSize))
+			return e
+		}, ErrActivatedSecret},
+		{"tampered challenge", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			sealed, _ := base64.StdEncoding.DecodeString(e.Challenge)
+			sealed[len(sealed)-1] ^= 1
+			e.Challenge = base64.StdEncoding.EncodeToString(sealed)
+			return e
+		}, ErrInvalidChallenge},
+		{"expired challenge", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			state, err := openChallenge(e.Challenge)
+			handleError(t, err)
+			state.Expires = time.Now().Add(-time.Second).Unix()
+			e.Challenge, err = sealChallenge(state)
+			handleError(t, err)
+			return e
+		}, ErrInvalidChallenge},
+		// the challenge of one ak does not vouch for another
+		{"other ak", func() *Evidence {
+			activated := tpm.activate(t)
+			activated.ak = newTestKey(t)
+			return activated.certify(t, keyPublic, cr)
+		}, ErrChallengeMismatch},
+		{"other ek certificate", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			e.EKCertificate = base64.StdEncoding.EncodeToString(
+				newTestTPMCertificate(t, tpm.root, tpm.rootKey,
+					tpm.ek.Public()))
+			return e
+		}, ErrChallengeMismatch},
+		{"missing challenge", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			e.Challenge = ""
+			return e
+		}, ErrInvalidChallenge},
+		{"invalid activated secret", func() *Evidence {
+			e := tpm.activate(t).certify(t, keyPublic, cr)
+			e.ActivatedSecret = "not base64"
+			return e
+		}, ErrInvalidEvidence},
+		// ak roots are not configured
+		{"ak certificate", func() *Evidence {
+			return newTestTPM(t).certify(t, keyPublic, cr)
+		}, ErrAKNotTrusted},
+	}
+	for _, test := range tests {
+		if _, err := test.evidence().Verify(cr); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, found: %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestNewChallengeFails(t *testing.T) {
+	tpm := newTestEKTPM(t)
+	encode := base64.StdEncoding.EncodeToString
+	request := &ChallengeRequest{
+		EKCertificate: encode(tpm.ekDer),
+		AKPublic:      encode(newTestPublic(&tpm.ak.PublicKey, testAKAttributes)),
+	}
+	if _, err := NewChallenge(request); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected %v, found: %v", ErrNotConfigured, err)
+	}
+	setTestEKRoots(t, tpm.root)
+
+	tests := []struct {
+		name    string
+		request ChallengeRequest
+		err     error
+	}{
+		{"untrusted ek", ChallengeRequest{
+			EKCertificate: encode(newTestEKTPM(t).ekDer),
+			AKPublic:      request.AKPublic,
+		}, ErrEKNotTrusted},
+		{"ak not restricted", ChallengeRequest{
+			EKCertificate: request.EKCertificate,
+			AKPublic: encode(newTestPublic(&tpm.ak.PublicKey,
+				attrTPMResident|attrSign)),
+		}, ErrInvalidAK},
+		{"missing ak", ChallengeRequest{
+			EKCertificate: request.EKCertificate,
+		}, ErrInvalidEvidence},
+	}
+	for _, test := range tests {
+		if _, err := NewChallenge(&test.request); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, found: %v", test.name, test.err, err)
+		}
+	}
+}
+
+// tpm with an EK certificate from a tpm manufacturer and an AK without
+// a certificate
+func newTestEKTPM(t *testing.T) *testTPM {
+	root, rootKey := newTestCA(t, "test tpm manufacturer")
+	ek := newTestKey(t)
+	return &testTPM{root: root, rootKey: rootKey, ak: newTestKey(t), ek: ek,
+		ekDer: newTestTPMCertificate(t, root, rootKey, &ek.PublicKey)}
+}
+
+func (tpm *testTPM) ekCert(t *testing.T) *x509.Certificate {
+	cert, err := x509.ParseCertificate(tpm.ekDer)
+	handleError(t, err)
+	return cert
+}
+
+// copy of tpm that has activated a challenge for its ak. evidence it
+// certifies carries the challenge.
+func (tpm *testTPM) activate(t *testing.T) *testTPM {
+	akPublic := newTestPublic(&tpm.ak.PublicKey, testAKAttributes)
+	encode := base64.StdEncoding.EncodeToString
+	challenge, err := NewChallenge(&ChallengeRequest{
+		EKCertificate: encode(tpm.ekDer),
+		AKPublic:      encode(akPublic),
+	})
+	handleError(t, err)
+	ak, err := parseTPMPublic(akPublic)
+	handleError(t, err)
+	akName, err := ak.name()
+	handleError(t, err)
+	blob, err := base64.StdEncoding.DecodeString(challenge.CredentialBlob)
+	handleError(t, err)
+	encryptedSecret, err := base64.StdEncoding.DecodeString(
+		challenge.EncryptedSecret)
+	handleError(t, err)
+	secret, err := activateCredential(tpm.ek, akName, blob, encryptedSecret)
+	handleError(t, err)
+
+	activated := *tpm
+	activated.challenge = challenge.Challenge
+	activated.secret = secret
+	return &activated
+}
+
+// TPM2_ActivateCredential with the EK private key
+func activateCredential(ek crypto.Signer, akName, credentialBlob,
+	encryptedSecret []byte) ([]byte, error) {
+	var seed []byte
+	switch key := ek.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		seed, err = rsa.DecryptOAEP(sha256.New(), nil, key, encryptedSecret,
+			append([]byte(labelIdentity), 0))
+		if err != nil {
+			return nil, err
+		}
+	case *ecdsa.PrivateKey:
+		r := newTPMReader(encryptedSecret)
+		x, err := r.read2B()
+		if err != nil {
+			return nil, err
+		}
+		y, err := r.read2B()
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := ecdh.P256().NewPublicKey(
+			append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, err
+		}
+		ekECDH, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		z, err := ekECDH.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		seed = kdfe(ekNameHash, z, labelIdentity, x,
+			key.X.FillBytes(make([]byte, len(x))), ekNameHash.Size()*8)
+	}
+
+	r := newTPMReader(credentialBlob)
+	integrity, err := r.read2B()
+	if err != nil {
+		return nil, err
+	}
+	encIdentity := credentialBlob[len(credentialBlob)-r.Len():]
+	hmacKey := kdfa(ekNameHash, seed, labelIntegrity, nil, nil,
+		ekNameHash.Size()*8)
+	mac := hmac.New(ekNameHash.New, hmacKey)
+	mac.Write(encIdentity)
+	mac.Write(akName)
+	if !hmac.Equal(mac.Sum(nil), integrity) {
+		return nil, errors.New("credential integrity check failed")
+	}
+	block, err := aes.NewCipher(kdfa(ekNameHash, seed, labelStorage, akName,
+		nil, ekSymmetricSize*8))
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(encIdentity))
+	cipher.NewCFBDecrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(
+		plain, encIdentity)
+	return newTPMReader(plain).read2B()
+}
+
+// load root and the challenge key through Init for the duration of the
+// test
+func setTestEKRoots(t *testing.T, root *x509.Certificate) {
+	handleError(t, Init(zap.NewNop(), &config.Attestation{
+		EKRootsFile:  writeTestRoots(t, root),
+		ChallengeKey: testChallengeKey,
+	}))
+	t.Cleanup(func() { ekRoots, challengeAead = nil, nil })
+}
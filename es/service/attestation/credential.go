@@ -0,0 +1,159 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package attestation
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"fmt"
+)
+
+// TPM2_MakeCredential for an EK created from the default TCG EK
+// templates: rsa 2048 or ecc nist p-256, sha-256 name alg and aes-128
+// cfb. See TPM 2.0 Library Part 1: Architecture, 24 Protected Storage
+// and TCG EK Credential Profile 2.1.5.
+const (
+	labelIdentity  = "IDENTITY"
+	labelStorage   = "STORAGE"
+	labelIntegrity = "INTEGRITY"
+
+	ekNameHash      = crypto.SHA256
+	ekSymmetricSize = 16
+)
+
+// wrap secret so only the tpm of ek can recover it with
+// TPM2_ActivateCredential, and only for the key named akName.
+// returns TPM2B_ID_OBJECT and TPM2B_ENCRYPTED_SECRET without their
+// size prefixes.
+func makeCredential(ek crypto.PublicKey, akName, secret []byte) (
+	credentialBlob, encryptedSecret []byte, err error) {
+	var seed []byte
+	switch key := ek.(type) {
+	case *rsa.PublicKey:
+		seed, encryptedSecret, err = newRsaSeed(key)
+	case *ecdsa.PublicKey:
+		seed, encryptedSecret, err = newEccSeed(key)
+	default:
+		err = fmt.Errorf("%w: %T", ErrUnsupportedEK, ek)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// TPM2B_DIGEST of the secret, encrypted with a key derived from the
+	// seed and the name of the ak. iv is zero.
+	symmetricKey := kdfa(ekNameHash, seed, labelStorage, akName, nil,
+		ekSymmetricSize*8)
+	block, err := aes.NewCipher(symmetricKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain := append2B(nil, secret)
+	encIdentity := make([]byte, len(plain))
+	//#nosec G407 -- tpm credential protection uses a zero iv with a fresh key
+	cipher.NewCFBEncrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(
+		encIdentity, plain)
+
+	hmacKey := kdfa(ekNameHash, seed, labelIntegrity, nil, nil,
+		ekNameHash.Size()*8)
+	mac := hmac.New(ekNameHash.New, hmacKey)
+	mac.Write(encIdentity)
+	mac.Write(akName)
+	credentialBlob = append2B(nil, mac.Sum(nil))
+	credentialBlob = append(credentialBlob, encIdentity...)
+	return credentialBlob, encryptedSecret, nil
+}
+
+// seed encrypted to the ek with rsa-oaep
+func newRsaSeed(ek *rsa.PublicKey) (seed, encryptedSeed []byte, err error) {
+	if ek.N.BitLen() != 2048 {
+		return nil, nil, fmt.Errorf("%w: rsa %d", ErrUnsupportedEK, ek.N.BitLen())
+	}
+	seed = make([]byte, ekSymmetricSize)
+	if _, err = rand.Read(seed); err != nil {
+		return nil, nil, err
+	}
+	encryptedSeed, err = rsa.EncryptOAEP(ekNameHash.New(), rand.Reader, ek,
+		seed, append([]byte(labelIdentity), 0))
+	return seed, encryptedSeed, err
+}
+
+// seed agreed with the ek through an ephemeral ecdh key. the encrypted
+// seed is the ephemeral public point, TPMS_ECC_POINT.
+func newEccSeed(ek *ecdsa.PublicKey) (seed, encryptedSeed []byte, err error) {
+	if ek.Curve != elliptic.P256() {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedEK,
+			ek.Curve.Params().Name)
+	}
+	ekECDH, err := ek.ECDH()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnsupportedEK, err)
+	}
+	ephemeral, err := ekECDH.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	z, err := ephemeral.ECDH(ekECDH)
+	if err != nil {
+		return nil, nil, err
+	}
+	// uncompressed point: 0x04 | x | y
+	point := ephemeral.PublicKey().Bytes()
+	size := (len(point) - 1) / 2
+	x, y := point[1:1+size], point[1+size:]
+	ekX := ek.X.FillBytes(make([]byte, size))
+	seed = kdfe(ekNameHash, z, labelIdentity, x, ekX, ekNameHash.Size()*8)
+	encryptedSeed = append2B(append2B(nil, x), y)
+	return seed, encryptedSeed, nil
+}
+
+// KDFa, TPM 2.0 Library Part 1, 11.4.10.2. hmac in counter mode.
+func kdfa(h crypto.Hash, key []byte, label string, contextU, contextV []byte,
+	bits int) []byte {
+	size := (bits + 7) / 8
+	var out []byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		mac := hmac.New(h.New, key)
+		mac.Write(binary.BigEndian.AppendUint32(nil, counter))
+		mac.Write([]byte(label))
+		mac.Write([]byte{0})
+		mac.Write(contextU)
+		mac.Write(contextV)
+		mac.Write(binary.BigEndian.AppendUint32(nil, uint32(bits)))
+		out = mac.Sum(out)
+	}
+	return out[:size]
+}
+
+// KDFe, TPM 2.0 Library Part 1, 11.4.10.3. hash of the shared secret in
+// counter mode.
+func kdfe(h crypto.Hash, z []byte, label string, partyU, partyV []byte,
+	bits int) []byte {
+	size := (bits + 7) / 8
+	var out []byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		d := h.New()
+		d.Write(binary.BigEndian.AppendUint32(nil, counter))
+		d.Write(z)
+		d.Write([]byte(label))
+		d.Write([]byte{0})
+		d.Write(partyU)
+		d.Write(partyV)
+		out = d.Sum(out)
+	}
+	return out[:size]
+}
+
+// TPM2B: uint16 size followed by data
+func append2B(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
@@ -0,0 +1,381 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package attestation
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha1" //#nosec G505 -- tpm name and signature hash
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// TPM 2.0 structures in their marshaled, big endian form.
+// See TPM 2.0 Library Part 2: Structures.
+const (
+	tpmAlgRsa    = 0x0001
+	tpmAlgSha1   = 0x0004
+	tpmAlgSha256 = 0x000B
+	tpmAlgSha384 = 0x000C
+	tpmAlgSha512 = 0x000D
+	tpmAlgNull   = 0x0010
+	tpmAlgRsassa = 0x0014
+	tpmAlgRsapss = 0x0016
+	tpmAlgEcdsa  = 0x0018
+	tpmAlgEcc    = 0x0023
+
+	tpmEccNistP256 = 0x0003
+	tpmEccNistP384 = 0x0004
+	tpmEccNistP521 = 0x0005
+
+	tpmGeneratedValue  = 0xff544347
+	tpmStAttestCertify = 0x8017
+
+	// TPMA_OBJECT
+	attrFixedTPM            = 1 << 1
+	attrFixedParent         = 1 << 4
+	attrSensitiveDataOrigin = 1 << 5
+	attrRestricted          = 1 << 16
+	attrDecrypt             = 1 << 17
+	attrSign                = 1 << 18
+
+	// key was created in and cannot leave the tpm
+	attrTPMResident = attrFixedTPM | attrFixedParent | attrSensitiveDataOrigin
+
+	defaultRsaExponent = 65537
+)
+
+var (
+	ErrInvalidTPMStructure = errors.New("invalid tpm structure")
+	ErrUnsupportedTPMAlg   = errors.New("unsupported tpm algorithm")
+)
+
+// TPMT_PUBLIC
+type tpmPublic struct {
+	Type       uint16
+	NameAlg    uint16
+	Attributes uint32
+	PublicKey  crypto.PublicKey
+	// marshaled form. the name of the object is a hash of it.
+	raw []byte
+}
+
+// TPMS_ATTEST. only certify info is kept for attested.
+type tpmAttest struct {
+	Type            uint16
+	ExtraData       []byte
+	FirmwareVersion uint64
+	// name of the certified object
+	CertifiedName []byte
+}
+
+// TPMT_SIGNATURE
+type tpmSignature struct {
+	SigAlg  uint16
+	HashAlg uint16
+	// rsa signature
+	Signature []byte
+	// ecdsa signature
+	R, S *big.Int
+}
+
+type tpmReader struct {
+	*bytes.Reader
+}
+
+func newTPMReader(data []byte) *tpmReader {
+	return &tpmReader{bytes.NewReader(data)}
+}
+
+func (r *tpmReader) read(v interface{}) error {
+	if err := binary.Read(r, binary.BigEndian, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTPMStructure, err)
+	}
+	return nil
+}
+
+// TPM2B: uint16 size followed by size bytes
+func (r *tpmReader) read2B() ([]byte, error) {
+	var size uint16
+	if err := r.read(&size); err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTPMStructure, err)
+	}
+	return b, nil
+}
+
+// algorithm with a hash that is only present if algorithm is not null
+func (r *tpmReader) readScheme() (uint16, error) {
+	var alg uint16
+	if err := r.read(&alg); err != nil {
+		return 0, err
+	}
+	if alg != tpmAlgNull {
+		var hash uint16
+		if err := r.read(&hash); err != nil {
+			return 0, err
+		}
+	}
+	return alg, nil
+}
+
+// TPMT_SYM_DEF_OBJECT
+func (r *tpmReader) readSymmetric() error {
+	var alg uint16
+	if err := r.read(&alg); err != nil {
+		return err
+	}
+	if alg == tpmAlgNull {
+		return nil
+	}
+	var keyBits, mode uint16
+	if err := r.read(&keyBits); err != nil {
+		return err
+	}
+	return r.read(&mode)
+}
+
+func (r *tpmReader) end() error {
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidTPMStructure, r.Len())
+	}
+	return nil
+}
+
+func parseTPMPublic(data []byte) (*tpmPublic, error) {
+	r := newTPMReader(data)
+	p := tpmPublic{raw: data}
+	if err := r.read(&p.Type); err != nil {
+		return nil, err
+	}
+	if err := r.read(&p.NameAlg); err != nil {
+		return nil, err
+	}
+	if err := r.read(&p.Attributes); err != nil {
+		return nil, err
+	}
+	// auth policy
+	if _, err := r.read2B(); err != nil {
+		return nil, err
+	}
+	if err := r.readSymmetric(); err != nil {
+		return nil, err
+	}
+	if _, err := r.readScheme(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch p.Type {
+	case tpmAlgRsa:
+		p.PublicKey, err = readRsaPublic(r)
+	case tpmAlgEcc:
+		p.PublicKey, err = readEccPublic(r)
+	default:
+		err = fmt.Errorf("%w: key type 0x%04x", ErrUnsupportedTPMAlg, p.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, r.end()
+}
+
+// TPMS_RSA_PARMS after scheme, then TPM2B_PUBLIC_KEY_RSA
+func readRsaPublic(r *tpmReader) (*rsa.PublicKey, error) {
+	var keyBits uint16
+	var exponent uint32
+	if err := r.read(&keyBits); err != nil {
+		return nil, err
+	}
+	if err := r.read(&exponent); err != nil {
+		return nil, err
+	}
+	if exponent == 0 {
+		exponent = defaultRsaExponent
+	}
+	n, err := r.read2B()
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent)}, nil
+}
+
+// TPMS_ECC_PARMS after scheme, then TPMS_ECC_POINT
+func readEccPublic(r *tpmReader) (*ecdsa.PublicKey, error) {
+	var curveId uint16
+	if err := r.read(&curveId); err != nil {
+		return nil, err
+	}
+	// kdf
+	if _, err := r.readScheme(); err != nil {
+		return nil, err
+	}
+	var curve elliptic.Curve
+	switch curveId {
+	case tpmEccNistP256:
+		curve = elliptic.P256()
+	case tpmEccNistP384:
+		curve = elliptic.P384()
+	case tpmEccNistP521:
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: curve 0x%04x", ErrUnsupportedTPMAlg, curveId)
+	}
+	x, err := r.read2B()
+	if err != nil {
+		return nil, err
+	}
+	y, err := r.read2B()
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+// TPM2B_NAME of the object: name alg followed by the hash of TPMT_PUBLIC
+func (p *tpmPublic) name() ([]byte, error) {
+	hash, err := getTPMHash(p.NameAlg)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(p.raw)
+	return h.Sum(binary.BigEndian.AppendUint16(nil, p.NameAlg)), nil
+}
+
+func (p *tpmPublic) hasAttributes(attributes uint32) bool {
+	return p.Attributes&attributes == attributes
+}
+
+func parseTPMAttest(data []byte) (*tpmAttest, error) {
+	r := newTPMReader(data)
+	var magic uint32
+	if err := r.read(&magic); err != nil {
+		return nil, err
+	}
+	if magic != tpmGeneratedValue {
+		return nil, fmt.Errorf("%w: not generated by a tpm",
+			ErrInvalidTPMStructure)
+	}
+	var a tpmAttest
+	if err := r.read(&a.Type); err != nil {
+		return nil, err
+	}
+	// qualified signer
+	if _, err := r.read2B(); err != nil {
+		return nil, err
+	}
+	var err error
+	if a.ExtraData, err = r.read2B(); err != nil {
+		return nil, err
+	}
+	// TPMS_CLOCK_INFO: clock, reset count, restart count, safe
+	clockInfo := make([]byte, 8+4+4+1)
+	if _, err = io.ReadFull(r, clockInfo); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTPMStructure, err)
+	}
+	if err = r.read(&a.FirmwareVersion); err != nil {
+		return nil, err
+	}
+	if a.Type != tpmStAttestCertify {
+		return &a, nil
+	}
+	// TPMS_CERTIFY_INFO: name, qualified name
+	if a.CertifiedName, err = r.read2B(); err != nil {
+		return nil, err
+	}
+	if _, err = r.read2B(); err != nil {
+		return nil, err
+	}
+	return &a, r.end()
+}
+
+func parseTPMSignature(data []byte) (*tpmSignature, error) {
+	r := newTPMReader(data)
+	var s tpmSignature
+	if err := r.read(&s.SigAlg); err != nil {
+		return nil, err
+	}
+	if err := r.read(&s.HashAlg); err != nil {
+		return nil, err
+	}
+	var err error
+	switch s.SigAlg {
+	case tpmAlgRsassa, tpmAlgRsapss:
+		if s.Signature, err = r.read2B(); err != nil {
+			return nil, err
+		}
+	case tpmAlgEcdsa:
+		var rb, sb []byte
+		if rb, err = r.read2B(); err != nil {
+			return nil, err
+		}
+		if sb, err = r.read2B(); err != nil {
+			return nil, err
+		}
+		s.R, s.S = new(big.Int).SetBytes(rb), new(big.Int).SetBytes(sb)
+	default:
+		return nil, fmt.Errorf("%w: signature 0x%04x", ErrUnsupportedTPMAlg,
+			s.SigAlg)
+	}
+	return &s, r.end()
+}
+
+// verify signature over data with key
+func (s *tpmSignature) verify(key crypto.PublicKey, data []byte) error {
+	hash, err := getTPMHash(s.HashAlg)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch s.SigAlg {
+		case tpmAlgRsassa:
+			return rsa.VerifyPKCS1v15(pub, hash, digest, s.Signature)
+		case tpmAlgRsapss:
+			return rsa.VerifyPSS(pub, hash, digest, s.Signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		if s.SigAlg == tpmAlgEcdsa {
+			if !ecdsa.Verify(pub, digest, s.R, s.S) {
+				return ErrSignature
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature 0x%04x does not match key",
+		ErrUnsupportedTPMAlg, s.SigAlg)
+}
+
+func getTPMHash(alg uint16) (crypto.Hash, error) {
+	switch alg {
+	case tpmAlgSha1:
+		return crypto.SHA1, nil
+	case tpmAlgSha256:
+		return crypto.SHA256, nil
+	case tpmAlgSha384:
+		return crypto.SHA384, nil
+	case tpmAlgSha512:
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("%w: hash 0x%04x", ErrUnsupportedTPMAlg, alg)
+}
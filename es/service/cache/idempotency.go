@@ -0,0 +1,78 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"go.uber.org/zap"
+)
+
+const (
+	cacheFunctionIdempotency = "Idempotency"
+)
+
+// set request for idempotency key
+// kind separates enroll and unenroll keys
+func SetIdempotentRequest(kind, tenantId string, ir *structs.IdempotentRequest) {
+	if !isEnabled {
+		return
+	}
+	defer metrics.ReportLatencyMetric(metrics.MetricCacheLatency,
+		time.Now(), operationCacheSet)
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+
+	cacheEntry, err := json.Marshal(ir)
+	if err != nil {
+		esLogger.Error("Failed to marshal idempotent request for caching!",
+			zap.String("key", ir.Key),
+			zap.Error(err))
+		return
+	}
+	key := fmt.Sprintf(prefixIdempotency, kind, tenantId, ir.Key)
+	if err = cacheClient.Set(ctx, key, cacheEntry, ttlIdempotency).Err(); err != nil {
+		esLogger.Error("Could not set idempotent request",
+			zap.String("key", ir.Key),
+			zap.String("tenant_id", tenantId),
+			zap.Error(err))
+		metrics.ReportCacheError(operationCacheSet, cacheFunctionIdempotency)
+	}
+}
+
+// get request for idempotency key
+func GetIdempotentRequest(kind, tenantId, idempotencyKey string) (
+	*structs.IdempotentRequest, error) {
+	if !isEnabled {
+		return nil, ErrCacheNotFound
+	}
+	defer metrics.ReportLatencyMetric(metrics.MetricCacheLatency,
+		time.Now(), operationCacheGet)
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+
+	key := fmt.Sprintf(prefixIdempotency, kind, tenantId, idempotencyKey)
+	cacheEntry, err := cacheClient.Get(ctx, key).Result()
+	if err != nil {
+		esLogger.Debug("Could not get idempotent request",
+			zap.String("key", idempotencyKey),
+			zap.Error(err))
+		metrics.ReportCacheError(operationCacheGet, cacheFunctionIdempotency)
+		return nil, err
+	}
+	var ir structs.IdempotentRequest
+	if err = json.Unmarshal([]byte(cacheEntry), &ir); err != nil {
+		esLogger.Error("Failed to unmarshal idempotent request from cache!",
+			zap.String("key", idempotencyKey),
+			zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportCacheHit(cacheFunctionIdempotency)
+	return &ir, nil
+}
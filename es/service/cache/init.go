@@ -42,15 +42,19 @@ const (
 	prefixUnenrollStatus = "unenroll_status:%s"
 	prefixCsrHash        = "csrhash:%s"
 	prefixPolicy         = "policy:%s"
+	prefixIdempotency    = "idempotency:%s:%s:%s"
 
 	// ttl
 	ttlStatus  = (time.Minute * 5)
 	ttlCsrHash = (time.Minute * 10)
+	// repeats with an idempotency key are expected within a day
+	ttlIdempotency = (time.Hour * 24)
 
 	// Caching operation names.
-	operationCacheGet    = "get"
-	operationCacheSet    = "set"
-	operationCacheDelete = "delete"
+	operationCacheGet     = "get"
+	operationCacheSet     = "set"
+	operationCacheDelete  = "delete"
+	operationCachePublish = "publish"
 )
 
 // Init - initialize a connection to the Redis based enroll cache.
@@ -106,6 +110,8 @@ func Shutdown() {
 
 	gCtx.Done()
 	isEnabled = false
+	stopStatusSubscriber()
+	stopKeyRevokedSubscriber()
 
 	// Close the client connection to the cache.
 	err := cacheClient.Close()
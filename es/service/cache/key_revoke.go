@@ -0,0 +1,99 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	cacheFunctionKeyRevoke = "KeyRevoke"
+
+	// all replicas subscribe to this channel. message is the key
+	// source and kid of a revoked token signing key.
+	channelKeyRevoked = "key_revoked"
+
+	// key source names do not contain the separator. kids may.
+	keyRevokedSeparator = "|"
+)
+
+var (
+	keyRevokedSubscriber     *redis.PubSub
+	keyRevokedSubscriberLock sync.Mutex
+)
+
+// publish revocation of a token signing key to all replicas
+func PublishKeyRevoked(source, kid string) {
+	if !isEnabled {
+		return
+	}
+	defer metrics.ReportLatencyMetric(metrics.MetricCacheLatency,
+		time.Now(), operationCachePublish)
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+
+	msg := source + keyRevokedSeparator + kid
+	if err := cacheClient.Publish(ctx, channelKeyRevoked, msg).Err(); err != nil {
+		esLogger.Error("Could not publish key revocation",
+			zap.String("message", msg),
+			zap.Error(err))
+		metrics.ReportCacheError(operationCachePublish, cacheFunctionKeyRevoke)
+	}
+}
+
+// call onRevoked for each key revoked on any replica, including this one.
+// only one subscription is made per process. if cache is disabled,
+// onRevoked is never called.
+func SubscribeKeyRevoked(onRevoked func(source, kid string)) {
+	if !isEnabled {
+		return
+	}
+	keyRevokedSubscriberLock.Lock()
+	defer keyRevokedSubscriberLock.Unlock()
+	if keyRevokedSubscriber != nil {
+		return
+	}
+	keyRevokedSubscriber = cacheClient.Subscribe(gCtx, channelKeyRevoked)
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+	if _, err := keyRevokedSubscriber.Receive(ctx); err != nil {
+		esLogger.Error("Could not confirm key revocation subscription",
+			zap.Error(err))
+		metrics.ReportCacheError(operationCacheGet, cacheFunctionKeyRevoke)
+	}
+	go func(ch <-chan *redis.Message) {
+		for msg := range ch {
+			source, kid, ok := strings.Cut(msg.Payload, keyRevokedSeparator)
+			if !ok {
+				esLogger.Error("Invalid key revocation message",
+					zap.String("payload", msg.Payload))
+				continue
+			}
+			onRevoked(source, kid)
+		}
+		esLogger.Info("Key revocation subscriber stopped.")
+	}(keyRevokedSubscriber.Channel())
+	esLogger.Info("Subscribed to key revocations",
+		zap.String("channel", channelKeyRevoked))
+}
+
+func stopKeyRevokedSubscriber() {
+	keyRevokedSubscriberLock.Lock()
+	defer keyRevokedSubscriberLock.Unlock()
+	if keyRevokedSubscriber == nil {
+		return
+	}
+	if err := keyRevokedSubscriber.Close(); err != nil {
+		esLogger.Error("Failed to close key revocation subscriber",
+			zap.Error(err))
+	}
+	keyRevokedSubscriber = nil
+}
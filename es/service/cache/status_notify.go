@@ -0,0 +1,168 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	cacheFunctionStatusNotify = "StatusNotify"
+
+	// all replicas subscribe to this channel. message is the
+	// enroll or unenroll id whose status changed.
+	channelStatusChange = "status_change"
+
+	// a hint may follow the id in a status change message
+	statusHintSeparator = "|"
+
+	// transient hint sent when a worker result for the id is picked up
+	// from the queue and is being recorded. status in db is unchanged.
+	StatusHintProcessing = "processing"
+)
+
+var (
+	// one subscription per process. waiters are fanned out in process.
+	statusSubscriber     *redis.PubSub
+	statusSubscriberLock sync.Mutex
+	statusWatchers       = map[uuid.UUID]map[chan string]struct{}{}
+	statusWatchersLock   sync.Mutex
+)
+
+// publish status change of an enroll or unenroll id to all replicas
+func PublishStatusChange(id uuid.UUID) {
+	publishStatusChange(id.String())
+}
+
+// publish that a worker result for the id is being processed
+func PublishStatusProcessing(id uuid.UUID) {
+	publishStatusChange(id.String() + statusHintSeparator +
+		StatusHintProcessing)
+}
+
+func publishStatusChange(msg string) {
+	if !isEnabled {
+		return
+	}
+	defer metrics.ReportLatencyMetric(metrics.MetricCacheLatency,
+		time.Now(), operationCachePublish)
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+
+	err := cacheClient.Publish(ctx, channelStatusChange, msg).Err()
+	if err != nil {
+		esLogger.Error("Could not publish status change",
+			zap.String("message", msg),
+			zap.Error(err))
+		metrics.ReportCacheError(operationCachePublish, cacheFunctionStatusNotify)
+	}
+}
+
+// watch for a status change of an enroll or unenroll id.
+// the returned channel is signalled when any replica publishes a change.
+// the value is the status hint, empty if status in db has changed.
+// call the returned cancel function when done watching.
+// if cache is disabled, the channel is never signalled.
+func WatchStatusChange(id uuid.UUID) (<-chan string, func()) {
+	ch := make(chan string, 1)
+	if !isEnabled {
+		return ch, func() {}
+	}
+	startStatusSubscriber()
+
+	statusWatchersLock.Lock()
+	if statusWatchers[id] == nil {
+		statusWatchers[id] = map[chan string]struct{}{}
+	}
+	statusWatchers[id][ch] = struct{}{}
+	statusWatchersLock.Unlock()
+
+	return ch, func() {
+		statusWatchersLock.Lock()
+		defer statusWatchersLock.Unlock()
+		delete(statusWatchers[id], ch)
+		if len(statusWatchers[id]) == 0 {
+			delete(statusWatchers, id)
+		}
+	}
+}
+
+// subscribe to status changes on first watch
+func startStatusSubscriber() {
+	statusSubscriberLock.Lock()
+	defer statusSubscriberLock.Unlock()
+	if statusSubscriber != nil {
+		return
+	}
+	statusSubscriber = cacheClient.Subscribe(gCtx, channelStatusChange)
+	// wait for subscription confirmation so early publishes are not lost
+	ctx, cancelFunc := context.WithTimeout(gCtx, cacheTimeout)
+	defer cancelFunc()
+	if _, err := statusSubscriber.Receive(ctx); err != nil {
+		esLogger.Error("Could not confirm status change subscription",
+			zap.Error(err))
+		metrics.ReportCacheError(operationCacheGet, cacheFunctionStatusNotify)
+	}
+	go func(ch <-chan *redis.Message) {
+		for msg := range ch {
+			s, hint, _ := strings.Cut(msg.Payload, statusHintSeparator)
+			id, err := uuid.Parse(s)
+			if err != nil {
+				esLogger.Error("Invalid id in status change message",
+					zap.String("payload", msg.Payload))
+				continue
+			}
+			notifyStatusWatchers(id, hint)
+		}
+		esLogger.Info("Status change subscriber stopped.")
+	}(statusSubscriber.Channel())
+	esLogger.Info("Subscribed to status changes",
+		zap.String("channel", channelStatusChange))
+}
+
+func stopStatusSubscriber() {
+	statusSubscriberLock.Lock()
+	defer statusSubscriberLock.Unlock()
+	if statusSubscriber == nil {
+		return
+	}
+	if err := statusSubscriber.Close(); err != nil {
+		esLogger.Error("Failed to close status change subscriber",
+			zap.Error(err))
+	}
+	statusSubscriber = nil
+}
+
+// wake up local waiters. a waiter that was already signalled
+// is not blocked on. a status change replaces an unread hint.
+func notifyStatusWatchers(id uuid.UUID, hint string) {
+	statusWatchersLock.Lock()
+	defer statusWatchersLock.Unlock()
+	for ch := range statusWatchers[id] {
+		select {
+		case ch <- hint:
+			continue
+		default:
+		}
+		if hint != "" {
+			continue
+		}
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- hint:
+		default:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// watch an id, publish a change and expect a wakeup
+func TestStatusChangeNotifiesWatcher(t *testing.T) {
+	id := uuid.New()
+	ch, cancel := WatchStatusChange(id)
+	defer cancel()
+
+	PublishStatusChange(id)
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected status change notification for %v", id)
+	}
+}
+
+// changes to other ids do not wake up the watcher
+func TestStatusChangeForAnotherIdDoesNotNotify(t *testing.T) {
+	ch, cancel := WatchStatusChange(uuid.New())
+	defer cancel()
+
+	PublishStatusChange(uuid.New())
+	select {
+	case <-ch:
+		t.Errorf("Expected no status change notification")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+// processing hint is delivered to the watcher
+func TestStatusProcessingNotifiesWatcherWithHint(t *testing.T) {
+	id := uuid.New()
+	ch, cancel := WatchStatusChange(id)
+	defer cancel()
+
+	PublishStatusProcessing(id)
+	select {
+	case hint := <-ch:
+		if hint != StatusHintProcessing {
+			t.Errorf("Expected hint %s, got: %s", StatusHintProcessing, hint)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected processing notification for %v", id)
+	}
+}
+
+// a status change is not lost behind an unread hint
+func TestStatusChangeReplacesUnreadHint(t *testing.T) {
+	id := uuid.New()
+	ch, cancel := WatchStatusChange(id)
+	defer cancel()
+
+	notifyStatusWatchers(id, StatusHintProcessing)
+	notifyStatusWatchers(id, "")
+	if hint := <-ch; hint != "" {
+		t.Errorf("Expected status change, got hint: %s", hint)
+	}
+}
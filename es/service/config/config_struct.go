@@ -70,6 +70,17 @@ type Server struct {
 	RetryAfterSeconds int `yaml:"retry_after_seconds"`
 	// Debug rest requests
 	DebugRestRequests bool `yaml:"debug_rest_requests"`
+	// Max seconds a status request can wait for a pending status
+	// to change (?wait=). 0 disables long polling.
+	MaxStatusWaitSeconds int `yaml:"max_status_wait_seconds"`
+	// PEM file with ca certificates returned by EST and SCEP
+	CACertsFile string `yaml:"ca_certs_file"`
+	// mgmt_service of EST and SCEP enrolls that do not specify one
+	DefaultManagementService string `yaml:"default_management_service"`
+	// SCEP registration authority certificate and rsa key. SCEP
+	// messages are encrypted to and signed by this certificate.
+	ScepRACertFile string `yaml:"scep_ra_cert_file"`
+	ScepRAKeyFile  string `yaml:"scep_ra_key_file"`
 }
 
 // Notification configuration settings
@@ -85,6 +96,39 @@ type Notification struct {
 	EnrollErrorWatchDelay int    `yaml:"enroll_error_watch_delay"`
 }
 
+// TPM attestation settings
+type Attestation struct {
+	// PEM file with trusted attestation CA root and intermediate
+	// certificates that issue AK certificates. attestation evidence is
+	// rejected if both roots files are empty.
+	AKRootsFile string `yaml:"ak_roots_file"`
+	// PEM file with trusted EK manufacturer root and intermediate
+	// certificates. AKs are bound to the EK by credential activation.
+	EKRootsFile string `yaml:"ek_roots_file"`
+	// base64 of 32 random bytes. seals credential activation challenges.
+	// required with EKRootsFile.
+	ChallengeKey string `yaml:"challenge_key"`
+}
+
+// Webhook delivery settings
+type Webhook struct {
+	// Whether lifecycle events are queued and delivered to tenant webhooks
+	Enabled bool `yaml:"enabled"`
+	// Seconds between polls for due deliveries
+	PollIntervalSeconds int `yaml:"poll_interval_seconds"`
+	// Max deliveries claimed per poll
+	BatchSize int `yaml:"batch_size"`
+	// Timeout in seconds for a delivery attempt
+	TimeoutSeconds int `yaml:"timeout_seconds"`
+	// Attempts before a delivery is marked failed
+	MaxAttempts int `yaml:"max_attempts"`
+	// Retry delay doubles from initial up to max after each failed attempt
+	InitialBackoffSeconds int `yaml:"initial_backoff_seconds"`
+	MaxBackoffSeconds     int `yaml:"max_backoff_seconds"`
+	// Base64 aes-256 key sealing webhook secrets at rest
+	SecretKey string `yaml:"secret_key"`
+}
+
 type ScheduledJob struct {
 	Enabled bool   `yaml:"enabled"`
 	Start   string `yaml:"start"`
@@ -100,8 +144,12 @@ type Config struct {
 	Notification Notification
 	// Cache server
 	Cache Cache
+	// Webhook delivery settings
+	Webhook Webhook
 	// Database settings
 	Database Database
+	// TPM attestation of csr keys
+	Attestation Attestation
 	// Configuration settings for CA server connection
 	// ES service creates a grpc client connection to CA server
 	CA struct {
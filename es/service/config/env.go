@@ -22,11 +22,16 @@ type value struct {
 func (c *Config) OverrideFromEnvironment() {
 	m := map[string]value{
 		//Server
-		"ES_SERVER":                  {v: &c.Server.Host},
-		"ES_PORT":                    {v: &c.Server.Port},
-		"ES_MAX_RETRY_AFTER_SECONDS": {v: &c.Server.MaxRetryAfterSeconds},
-		"ES_RETRY_AFTER_SECONDS":     {v: &c.Server.RetryAfterSeconds},
-		"ES_DEBUG_REST_REQUESTS":     {v: &c.Server.DebugRestRequests},
+		"ES_SERVER":                     {v: &c.Server.Host},
+		"ES_PORT":                       {v: &c.Server.Port},
+		"ES_MAX_RETRY_AFTER_SECONDS":    {v: &c.Server.MaxRetryAfterSeconds},
+		"ES_RETRY_AFTER_SECONDS":        {v: &c.Server.RetryAfterSeconds},
+		"ES_DEBUG_REST_REQUESTS":        {v: &c.Server.DebugRestRequests},
+		"ES_MAX_STATUS_WAIT_SECONDS":    {v: &c.Server.MaxStatusWaitSeconds},
+		"ES_CA_CERTS_FILE":              {v: &c.Server.CACertsFile},
+		"ES_DEFAULT_MANAGEMENT_SERVICE": {v: &c.Server.DefaultManagementService},
+		"ES_SCEP_RA_CERT_FILE":          {v: &c.Server.ScepRACertFile},
+		"ES_SCEP_RA_KEY_FILE":           {v: &c.Server.ScepRAKeyFile},
 
 		//DSTS
 		"ES_DSTS_HOST":     {v: &c.DSTS.Host},
@@ -59,6 +64,19 @@ func (c *Config) OverrideFromEnvironment() {
 		"ES_CACHE_RETRY_AFTER_HINT_STRATEGY": {v: &c.Cache.RetryAfterHintStrategy},
 		"ES_CACHE_ENROLL_TIME_WINDOW_SIZE":   {v: &c.Cache.EnrollTimeWindowSize},
 		"ES_CACHE_ENROLL_UPDATE_WINDOW_SIZE": {v: &c.Cache.EnrollUpdateWindowSize},
+		//WEBHOOK
+		"ES_WEBHOOK_ENABLED":                 {v: &c.Webhook.Enabled},
+		"ES_WEBHOOK_POLL_INTERVAL_SECONDS":   {v: &c.Webhook.PollIntervalSeconds},
+		"ES_WEBHOOK_BATCH_SIZE":              {v: &c.Webhook.BatchSize},
+		"ES_WEBHOOK_TIMEOUT_SECONDS":         {v: &c.Webhook.TimeoutSeconds},
+		"ES_WEBHOOK_MAX_ATTEMPTS":            {v: &c.Webhook.MaxAttempts},
+		"ES_WEBHOOK_INITIAL_BACKOFF_SECONDS": {v: &c.Webhook.InitialBackoffSeconds},
+		"ES_WEBHOOK_MAX_BACKOFF_SECONDS":     {v: &c.Webhook.MaxBackoffSeconds},
+		"ES_WEBHOOK_SECRET_KEY":              {secret: true, v: &c.Webhook.SecretKey},
+		//ATTESTATION
+		"ES_ATTESTATION_AK_ROOTS_FILE": {v: &c.Attestation.AKRootsFile},
+		"ES_ATTESTATION_EK_ROOTS_FILE": {v: &c.Attestation.EKRootsFile},
+		"ES_ATTESTATION_CHALLENGE_KEY": {secret: true, v: &c.Attestation.ChallengeKey},
 		//Management services
 		"ES_MANAGEMENT_SERVICES": {v: &c.ManagementServices},
 		// modes of operation
@@ -29,15 +29,22 @@ func Ping() error {
 }
 
 // create entry for incoming device enroll
-func CreateEnrollRecord(tenantId, userId, csrHash string) (*structs.DeviceEntry, error) {
+// payload is the json payload handed off for processing. it is kept
+// so a failed enroll can be retried.
+// ir is nil unless the request specified an idempotency key
+func CreateEnrollRecord(tenantId, userId, csrHash, payload string,
+	ir *structs.IdempotentRequest) (*structs.DeviceEntry, error) {
 	start := time.Now()
 	de := structs.DeviceEntry{TenantId: tenantId, UserId: userId}
+	key, requestHash := getIdempotencyValues(ir)
 	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
 	defer cancelFunc()
 	err := gDbPool.QueryRow(ctx,
-		`INSERT INTO enroll(tenant_id, user_id, csr_hash)
-		VALUES($1,$2,$3) RETURNING id, request_id`,
-		tenantId, userId, csrHash).Scan(&de.Id, &de.RequestId)
+		`INSERT INTO enroll(tenant_id, user_id, csr_hash, payload,
+		idempotency_key, request_hash)
+		VALUES($1,$2,$3,$4,$5,$6) RETURNING id, request_id`,
+		tenantId, userId, csrHash, payload, key, requestHash).Scan(
+		&de.Id, &de.RequestId)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return nil, err
@@ -46,6 +53,7 @@ func CreateEnrollRecord(tenantId, userId, csrHash string) (*structs.DeviceEntry,
 		operationDbCreateEnroll)
 	go cache.CreateEnrollStatus(de.Id, tenantId, userId, uuid.Nil, 0)
 	go cache.SetCsrHash(csrHash)
+	go setIdempotentRequest(IdempotencyKindEnroll, tenantId, ir, &de)
 	return &de, nil
 }
 
@@ -71,6 +79,8 @@ func UpdateEnrollRecord(dc *structs.EnrollResult) error {
 	go cache.AddEnrollElapsed(elapsed)
 	// set status in cache
 	cache.SetEnrollStatus(dc.EnrollId, dc.DeviceId, 1)
+	// wake up status requests waiting on this id
+	go cache.PublishStatusChange(dc.EnrollId)
 	return nil
 }
 
@@ -162,6 +172,29 @@ func HasCSRHash(csrHash string) (bool, error) {
 	return count == 1, err
 }
 
+// get id of the latest enroll or failed enroll for csr hash.
+// clients that poll by resubmitting a csr use this to find their enroll.
+func GetEnrollIdByCSRHash(csrHash string) (uuid.UUID, error) {
+	var id uuid.UUID
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx,
+		`SELECT id FROM (
+			SELECT id, created_at FROM enroll WHERE csr_hash=$1
+			UNION ALL
+			SELECT id, created_at FROM enroll_error WHERE csr_hash=$1) e
+		ORDER BY created_at DESC LIMIT 1`,
+		csrHash).Scan(&id)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return uuid.Nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetEnrollIdByCSRHash)
+	return id, nil
+}
+
 // get average enroll time
 func GetAverageEnrollTime() (int, error) {
 	t, err := cache.GetAverageEnrollTime()
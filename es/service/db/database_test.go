@@ -44,7 +44,7 @@ func TestCreateEnrollRecord(t *testing.T) {
 	userId := uuid.New().String()
 	tenantId := uuid.New().String()
 	csrHash := uuid.New().String()
-	_, err := CreateEnrollRecord(userId, tenantId, csrHash)
+	_, err := CreateEnrollRecord(userId, tenantId, csrHash, "", nil)
 	if err != nil {
 		handleError(t, err)
 	}
@@ -129,6 +129,37 @@ func TestGetEnrollDetailsById(t *testing.T) {
 	}
 }
 
+// enroll is found by csr hash before and after it fails
+func TestGetEnrollIdByCSRHash(t *testing.T) {
+	csrHash := uuid.New().String()
+	de, err := CreateEnrollRecord(uuid.New().String(), uuid.New().String(),
+		csrHash, "", nil)
+	if err != nil {
+		handleError(t, err)
+	}
+	id, err := GetEnrollIdByCSRHash(csrHash)
+	if err != nil {
+		handleError(t, err)
+	} else if id != de.Id {
+		t.Errorf("Expected id = %v, got %v", de.Id, id)
+	}
+
+	ee := &structs.EnrollError{EnrollId: de.Id.String(), ErrorCode: 1}
+	if err = FailEnrollRecord(ee); err != nil {
+		handleError(t, err)
+	}
+	id, err = GetEnrollIdByCSRHash(csrHash)
+	if err != nil {
+		handleError(t, err)
+	} else if id != de.Id {
+		t.Errorf("Expected id = %v, got %v", de.Id, id)
+	}
+
+	if _, err = GetEnrollIdByCSRHash(uuid.New().String()); !IsDbErrorNoRows(err) {
+		t.Errorf("Expected = %v, got %v", ErrNoRows, err)
+	}
+}
+
 func TestPendingEnrollCount(t *testing.T) {
 	cleanEnrollTable()
 	i, err := GetPendingEnrollCount()
@@ -148,7 +179,7 @@ func TestPendingEnrollCount(t *testing.T) {
 
 func TestHasCSRHash(t *testing.T) {
 	csrHash := uuid.New().String()
-	CreateEnrollRecord(uuid.New().String(), uuid.New().String(), csrHash)
+	CreateEnrollRecord(uuid.New().String(), uuid.New().String(), csrHash, "", nil)
 	ok, err := HasCSRHash(csrHash)
 	if err != nil {
 		handleError(t, err)
@@ -203,7 +234,7 @@ func newEnroll() (*structs.DeviceEntry, error) {
 func newEnrollWithTenantId(tenantId string) (*structs.DeviceEntry, error) {
 	userId := uuid.New().String()
 	csrHash := uuid.New().String()
-	return CreateEnrollRecord(userId, tenantId, csrHash)
+	return CreateEnrollRecord(userId, tenantId, csrHash, "", nil)
 }
 
 func retryWait(count int, fn func() bool) bool {
@@ -215,3 +246,54 @@ func retryWait(count int, fn func() bool) bool {
 	}
 	return false
 }
+
+// a failed enroll keeps its idempotency key
+func TestGetIdempotentRequestAfterFailure(t *testing.T) {
+	tenantId := uuid.New().String()
+	ir := &structs.IdempotentRequest{
+		Key:         uuid.New().String(),
+		RequestHash: "hash",
+	}
+	de, err := CreateEnrollRecord(tenantId, "", uuid.New().String(), "", ir)
+	handleError(t, err)
+	err = FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     de.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	found, err := GetIdempotentRequest(IdempotencyKindEnroll, tenantId, ir.Key)
+	handleError(t, err)
+	if found.Id != de.Id || found.RequestHash != ir.RequestHash {
+		t.Errorf("Unexpected idempotent request: %v", found)
+	}
+}
+
+// enroll created with an idempotency key can be looked up by key
+func TestGetIdempotentRequest(t *testing.T) {
+	tenantId := uuid.New().String()
+	ir := &structs.IdempotentRequest{
+		Key:         uuid.New().String(),
+		RequestHash: "hash",
+	}
+	de, err := CreateEnrollRecord(tenantId, "", uuid.New().String(), "", ir)
+	handleError(t, err)
+
+	found, err := GetIdempotentRequest(IdempotencyKindEnroll, tenantId, ir.Key)
+	handleError(t, err)
+	if found == nil || found.Id != de.Id || found.RequestHash != ir.RequestHash {
+		t.Errorf("Unexpected idempotent request: %v", found)
+	}
+
+	// key is scoped to tenant
+	_, err = GetIdempotentRequest(IdempotencyKindEnroll, uuid.New().String(),
+		ir.Key)
+	expectError(t, err, ErrNoRows)
+
+	// same key in tenant is rejected
+	_, err = CreateEnrollRecord(tenantId, "", uuid.New().String(), "", ir)
+	if !IsDbErrorUniqueViolation(err) {
+		t.Errorf("Expected unique violation, got %v", err)
+	}
+}
@@ -86,6 +86,15 @@ func DeleteExpiredEnrolls(enrollExpirySeconds int) (int64, error) {
 		return 0, err
 	}
 
+	// scep transactions are only useful while their enroll is around
+	_, err = tx.Exec(ctx, fmt.Sprintf(
+		`DELETE FROM scep_transaction WHERE
+		created_at < NOW() - INTERVAL '%d seconds'`, enrollExpirySeconds))
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return 0, err
+	}
+
 	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
 		operationDbDeleteExpiredEnrolls)
 
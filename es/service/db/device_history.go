@@ -0,0 +1,122 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const (
+	// operations are also the request types stored in enroll tables
+	HistoryOperationEnroll      = "enroll"
+	HistoryOperationRenewEnroll = "renew_enroll"
+	HistoryOperationUnenroll    = "unenroll"
+
+	HistoryStatusPending   = "pending"
+	HistoryStatusCompleted = "completed"
+	HistoryStatusFailed    = "failed"
+
+	// status used for rows read from error tables
+	historyStatusError = -1
+)
+
+// every table that holds a step in the life of a device.
+// error tables report historyStatusError so they map to failed.
+// initial enrolls only get a device id once they complete. pending and
+// failed attempts without one belong to the device if they share the
+// csr of one of its enrolls, eg: a failed enroll resubmitted as is.
+const sqlDeviceHistory = `
+	WITH device_csr AS (
+		SELECT csr_hash FROM enroll WHERE tenant_id=$1 AND device_id=$2
+		UNION
+		SELECT csr_hash FROM enroll_archive WHERE tenant_id=$1 AND device_id=$2)
+	SELECT id, request_id, request_type, 'enroll', status,
+		COALESCE(user_id, ''), 0, '', created_at, updated_at
+	FROM enroll WHERE tenant_id=$1 AND (device_id=$2 OR
+		(device_id IS NULL AND csr_hash IN (SELECT csr_hash FROM device_csr)))
+	UNION ALL
+	SELECT id, request_id, request_type, 'enroll_archive', status,
+		COALESCE(user_id, ''), 0, '', created_at, updated_at
+	FROM enroll_archive WHERE tenant_id=$1 AND device_id=$2
+	UNION ALL
+	SELECT id, request_id, request_type, 'enroll_error', -1,
+		COALESCE(user_id, ''), error_code, COALESCE(error_text, ''),
+		created_at, updated_at
+	FROM enroll_error WHERE tenant_id=$1 AND (device_id=$2 OR
+		(device_id IS NULL AND csr_hash IN (SELECT csr_hash FROM device_csr)))
+	UNION ALL
+	SELECT id, request_id, 'unenroll', 'unenroll', status,
+		COALESCE(user_id, ''), 0, '', created_at, updated_at
+	FROM unenroll WHERE tenant_id=$1 AND device_id=$2
+	UNION ALL
+	SELECT id, request_id, 'unenroll', 'unenroll_archive', status,
+		COALESCE(user_id, ''), 0, '', created_at, updated_at
+	FROM unenroll_archive WHERE tenant_id=$1 AND device_id=$2
+	UNION ALL
+	SELECT id, request_id, 'unenroll', 'unenroll_error', -1,
+		COALESCE(user_id, ''), error_code, COALESCE(error_text, ''),
+		created_at, updated_at
+	FROM unenroll_error WHERE tenant_id=$1 AND device_id=$2
+	ORDER BY 9, 1`
+
+// get enroll, renew and unenroll history for a device, oldest first.
+// operation is the request type stored with each enroll.
+func GetDeviceHistory(tenantId string, deviceId uuid.UUID) (
+	[]*structs.DeviceHistoryEntry, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	rows, err := gDbPool.Query(ctx, sqlDeviceHistory, tenantId, deviceId)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*structs.DeviceHistoryEntry{}
+	for rows.Next() {
+		var status int
+		var createdAt, updatedAt pgtype.Timestamptz
+		e := &structs.DeviceHistoryEntry{}
+		if err = rows.Scan(&e.Id, &e.RequestId, &e.Operation, &e.Source,
+			&status, &e.UserId, &e.ErrorCode, &e.ErrorText,
+			&createdAt, &updatedAt); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		e.Status = getHistoryStatus(status)
+		if createdAt.Valid {
+			e.CreatedAt = createdAt.Time
+		}
+		if updatedAt.Valid {
+			e.UpdatedAt = &updatedAt.Time
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetDeviceHistory)
+	return entries, nil
+}
+
+func getHistoryStatus(status int) string {
+	switch status {
+	case historyStatusError:
+		return HistoryStatusFailed
+	case 0:
+		return HistoryStatusPending
+	}
+	return HistoryStatusCompleted
+}
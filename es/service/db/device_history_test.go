@@ -0,0 +1,122 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// enroll, renew, failed renew and unenroll show up in order
+func TestGetDeviceHistory(t *testing.T) {
+	tenantId := uuid.New().String()
+	deviceId := uuid.New()
+
+	de, err := CreateEnrollRecord(tenantId, uuid.New().String(),
+		uuid.New().String(), "", nil)
+	handleError(t, err)
+	err = UpdateEnrollRecord(&structs.EnrollResult{
+		EnrollId:    de.Id,
+		DeviceId:    deviceId,
+		Certificate: "cert bytes",
+	})
+	handleError(t, err)
+
+	_, err = RenewEnroll(tenantId, deviceId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+
+	failed, err := RenewEnroll(tenantId, deviceId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+	err = FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     failed.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	_, err = Unenroll(tenantId, deviceId, "", nil)
+	handleError(t, err)
+
+	entries, err := GetDeviceHistory(tenantId, deviceId)
+	handleError(t, err)
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 history entries, found: %d", len(entries))
+	}
+
+	expected := []struct{ operation, status string }{
+		{HistoryOperationEnroll, HistoryStatusCompleted},
+		{HistoryOperationRenewEnroll, HistoryStatusPending},
+		{HistoryOperationRenewEnroll, HistoryStatusFailed},
+		{HistoryOperationUnenroll, HistoryStatusPending},
+	}
+	for i, e := range expected {
+		if entries[i].Operation != e.operation || entries[i].Status != e.status {
+			t.Errorf("Entry %d: expected %s/%s, found: %s/%s", i,
+				e.operation, e.status, entries[i].Operation, entries[i].Status)
+		}
+	}
+	if entries[2].ErrorCode != 123 {
+		t.Errorf("Expected error code 123, found: %d", entries[2].ErrorCode)
+	}
+}
+
+// another tenant cannot see the device history
+func TestGetDeviceHistoryForAnotherTenantIsEmpty(t *testing.T) {
+	deviceId := uuid.New()
+	_, err := Unenroll(uuid.New().String(), deviceId, "", nil)
+	handleError(t, err)
+
+	entries, err := GetDeviceHistory(uuid.New().String(), deviceId)
+	handleError(t, err)
+	if len(entries) != 0 {
+		t.Errorf("Expected no history entries, found: %d", len(entries))
+	}
+}
+
+// a failed initial enroll has no device id. it shows up once the same
+// csr enrolls the device. failed entries keep their request time.
+func TestGetDeviceHistoryLinksFailedInitialEnroll(t *testing.T) {
+	tenantId := uuid.New().String()
+	deviceId := uuid.New()
+	csrHash := uuid.New().String()
+
+	failed, err := CreateEnrollRecord(tenantId, "", csrHash, "", nil)
+	handleError(t, err)
+	err = FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     failed.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	de, err := CreateEnrollRecord(tenantId, "", csrHash, "", nil)
+	handleError(t, err)
+	err = UpdateEnrollRecord(&structs.EnrollResult{
+		EnrollId:    de.Id,
+		DeviceId:    deviceId,
+		Certificate: "cert bytes",
+	})
+	handleError(t, err)
+
+	entries, err := GetDeviceHistory(tenantId, deviceId)
+	handleError(t, err)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 history entries, found: %d", len(entries))
+	}
+	for i, e := range entries {
+		if e.Operation != HistoryOperationEnroll {
+			t.Errorf("Entry %d: expected %s, found: %s", i,
+				HistoryOperationEnroll, e.Operation)
+		}
+	}
+	if entries[0].Id != failed.Id || entries[0].Status != HistoryStatusFailed {
+		t.Errorf("Expected failed initial enroll first, found: %+v", entries[0])
+	}
+	if !entries[0].CreatedAt.Before(entries[1].CreatedAt) {
+		t.Errorf("Expected failed enroll to keep its request time, found: %v",
+			entries[0].CreatedAt)
+	}
+}
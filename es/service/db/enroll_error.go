@@ -7,8 +7,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/metrics"
 	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -37,9 +39,11 @@ func FailEnrollRecord(ee *structs.EnrollError) error {
 	// make error record
 	if _, err = tx.Exec(ctx, `INSERT INTO enroll_error (
 		id, request_id, tenant_id, user_id, csr_hash, status, device_id,
-		certificate, error_code, error_text)
+		certificate, payload, request_type, created_at, idempotency_key,
+		request_hash, error_code, error_text)
 		(SELECT id, request_id, tenant_id, user_id, csr_hash, status, device_id,
-		certificate, $1, $2 FROM enroll WHERE id=$3)`,
+		certificate, payload, request_type, created_at, idempotency_key,
+		request_hash, $1, $2 FROM enroll WHERE id=$3)`,
 		ee.ErrorCode, ee.ErrorMessage, ee.EnrollId); err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return err
@@ -52,5 +56,32 @@ func FailEnrollRecord(ee *structs.EnrollError) error {
 	}
 
 	commit(tx, ctx)
+
+	// drop cached pending status so status lookups find the error record
+	if id, err := uuid.Parse(ee.EnrollId); err == nil {
+		cache.DeleteEnrollStatusById(id)
+		// wake up status requests waiting on this id
+		go cache.PublishStatusChange(id)
+	}
 	return nil
 }
+
+// get error details for a failed enroll id
+func GetEnrollErrorStatus(id uuid.UUID) (*structs.EnrollErrorStatus, error) {
+	start := time.Now()
+	entry := &structs.EnrollErrorStatus{}
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx,
+		`SELECT tenant_id, COALESCE(user_id, ''), device_id, error_code,
+		COALESCE(error_text, '') FROM enroll_error WHERE id=$1`,
+		id).Scan(&entry.TenantId, &entry.UserId, &entry.DeviceId,
+		&entry.ErrorCode, &entry.ErrorText)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetEnrollError)
+	return entry, nil
+}
@@ -39,3 +39,29 @@ func TestFailEnrollRecord(t *testing.T) {
 		t.Errorf("Expected = %v, got %v", pgx.ErrNoRows, err)
 	}
 }
+
+// failed enroll details can be looked up by enroll id
+func TestGetEnrollErrorStatus(t *testing.T) {
+	tenantId := uuid.New().String()
+	er, err := CreateEnrollRecord(tenantId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+	ee := &structs.EnrollError{
+		EnrollId:     er.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	}
+	handleError(t, FailEnrollRecord(ee))
+
+	status, err := GetEnrollErrorStatus(er.Id)
+	handleError(t, err)
+	if status == nil || status.TenantId != tenantId ||
+		status.ErrorCode != ee.ErrorCode ||
+		status.ErrorText != ee.ErrorMessage {
+		t.Errorf("Unexpected enroll error status: %v", status)
+	}
+}
+
+func TestGetEnrollErrorStatusNonExistentIdFails(t *testing.T) {
+	_, err := GetEnrollErrorStatus(uuid.New())
+	expectError(t, err, ErrNoRows)
+}
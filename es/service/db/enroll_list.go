@@ -0,0 +1,79 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// list enroll records for a tenant ordered by (created_at, id).
+// filter.AfterCreatedAt and filter.AfterId form the keyset cursor
+// so each page is an index range scan regardless of tenant size.
+func ListEnrollRecords(tenantId string, filter *structs.EnrollListFilter) (
+	[]*structs.EnrollRecord, error) {
+	start := time.Now()
+
+	var sb strings.Builder
+	args := []interface{}{tenantId}
+	sb.WriteString(`SELECT id, request_id, tenant_id, COALESCE(user_id, ''),
+		device_id, status, created_at, updated_at
+		FROM enroll WHERE tenant_id=$1`)
+	if filter.Status != nil {
+		args = append(args, *filter.Status)
+		fmt.Fprintf(&sb, " AND status=$%d", len(args))
+	}
+	if filter.CreatedAfter != nil {
+		args = append(args, *filter.CreatedAfter)
+		fmt.Fprintf(&sb, " AND created_at > $%d", len(args))
+	}
+	if filter.AfterCreatedAt != nil {
+		args = append(args, *filter.AfterCreatedAt, filter.AfterId)
+		fmt.Fprintf(&sb, " AND (created_at, id) > ($%d, $%d)",
+			len(args)-1, len(args))
+	}
+	args = append(args, filter.Limit)
+	fmt.Fprintf(&sb, " ORDER BY created_at, id LIMIT $%d", len(args))
+
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	rows, err := gDbPool.Query(ctx, sb.String(), args...)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*structs.EnrollRecord{}
+	for rows.Next() {
+		var createdAt, updatedAt pgtype.Timestamptz
+		er := &structs.EnrollRecord{}
+		if err = rows.Scan(&er.Id, &er.RequestId, &er.TenantId, &er.UserId,
+			&er.DeviceId, &er.Status, &createdAt, &updatedAt); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		if createdAt.Valid {
+			er.CreatedAt = createdAt.Time
+		}
+		if updatedAt.Valid {
+			er.UpdatedAt = &updatedAt.Time
+		}
+		records = append(records, er)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbListEnrolls)
+	return records, nil
+}
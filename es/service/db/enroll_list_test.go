@@ -0,0 +1,77 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// create enrolls for a tenant, then page through them
+func TestListEnrollRecordsPaginates(t *testing.T) {
+	tenantId := uuid.New().String()
+	var enrollCount = 5
+	for i := 0; i < enrollCount; i++ {
+		_, err := CreateEnrollRecord(tenantId, uuid.New().String(),
+			uuid.New().String(), "", nil)
+		handleError(t, err)
+	}
+
+	filter := structs.EnrollListFilter{Limit: 2}
+	seen := map[uuid.UUID]bool{}
+	for {
+		records, err := ListEnrollRecords(tenantId, &filter)
+		handleError(t, err)
+		if len(records) == 0 {
+			break
+		}
+		if len(records) > filter.Limit {
+			t.Fatalf("Expected at most %d records, found: %d",
+				filter.Limit, len(records))
+		}
+		for _, r := range records {
+			if r.TenantId != tenantId {
+				t.Errorf("Expected tenant %s, found: %s", tenantId, r.TenantId)
+			}
+			if seen[r.Id] {
+				t.Errorf("Record %v returned more than once", r.Id)
+			}
+			seen[r.Id] = true
+		}
+		last := records[len(records)-1]
+		filter.AfterCreatedAt = &last.CreatedAt
+		filter.AfterId = last.Id
+	}
+	if len(seen) != enrollCount {
+		t.Errorf("Expected %d records, found: %d", enrollCount, len(seen))
+	}
+}
+
+// status filter only returns matching records
+func TestListEnrollRecordsByStatus(t *testing.T) {
+	tenantId := uuid.New().String()
+	de, err := CreateEnrollRecord(tenantId, uuid.New().String(),
+		uuid.New().String(), "", nil)
+	handleError(t, err)
+	_, err = CreateEnrollRecord(tenantId, uuid.New().String(),
+		uuid.New().String(), "", nil)
+	handleError(t, err)
+
+	err = UpdateEnrollRecord(&structs.EnrollResult{
+		EnrollId:    de.Id,
+		DeviceId:    uuid.New(),
+		Certificate: "cert bytes",
+	})
+	handleError(t, err)
+
+	status := 1
+	records, err := ListEnrollRecords(tenantId,
+		&structs.EnrollListFilter{Status: &status, Limit: 10})
+	handleError(t, err)
+	if len(records) != 1 || records[0].Id != de.Id {
+		t.Errorf("Expected only enrolled record %v, found: %v", de.Id, records)
+	}
+}
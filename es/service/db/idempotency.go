@@ -0,0 +1,76 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/cache"
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"go.uber.org/zap"
+)
+
+const (
+	// idempotency keys are scoped to the table of the request
+	IdempotencyKindEnroll   = "enroll"
+	IdempotencyKindUnenroll = "unenroll"
+)
+
+// get the request recorded for an idempotency key in tenant. failed
+// requests keep their key in the error table of kind.
+func GetIdempotentRequest(kind, tenantId, key string) (
+	*structs.IdempotentRequest, error) {
+	start := time.Now()
+	if cached, err := cache.GetIdempotentRequest(kind, tenantId, key); err == nil {
+		metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
+			start, operationDbGetIdempotentRequest)
+		return cached, nil
+	}
+
+	ir := structs.IdempotentRequest{Key: key}
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	// kind is one of the table constants above
+	err := gDbPool.QueryRow(ctx, fmt.Sprintf(
+		`SELECT id, request_id, COALESCE(request_hash, '') FROM %[1]s
+		WHERE tenant_id=$1 AND idempotency_key=$2
+		UNION ALL
+		SELECT id, request_id, COALESCE(request_hash, '') FROM %[1]s_error
+		WHERE tenant_id=$1 AND idempotency_key=$2
+		LIMIT 1`, kind),
+		tenantId, key).Scan(&ir.Id, &ir.RequestId, &ir.RequestHash)
+	if err != nil {
+		if !IsDbErrorNoRows(err) {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+		}
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetIdempotentRequest)
+	go cache.SetIdempotentRequest(kind, tenantId, &ir)
+	return &ir, nil
+}
+
+// idempotency key and request hash column values. null if not specified.
+func getIdempotencyValues(ir *structs.IdempotentRequest) (*string, *string) {
+	if ir == nil {
+		return nil, nil
+	}
+	return &ir.Key, &ir.RequestHash
+}
+
+// record created entry against the idempotency key
+func setIdempotentRequest(kind, tenantId string, ir *structs.IdempotentRequest,
+	de *structs.DeviceEntry) {
+	if ir == nil {
+		return
+	}
+	entry := *ir
+	entry.Id = de.Id
+	entry.RequestId = de.RequestId
+	cache.SetIdempotentRequest(kind, tenantId, &entry)
+}
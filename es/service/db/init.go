@@ -43,19 +43,39 @@ const (
 	operationDbGetDetails                 = "get_enroll_details"
 	operationDbGetPendingEnrollCount      = "get_pending_enroll_count" //#nosec G101
 	operationDbCheckCSRHash               = "check_csr_hash"
+	operationDbGetEnrollIdByCSRHash       = "get_enroll_id_by_csr_hash"
 	operationDbGetStatusByTenantAndDevice = "get_status_by_tenant_and_device"
 	operationDbFailEnroll                 = "failed_enroll"
+	operationDbGetEnrollError             = "get_enroll_error"
+	operationDbRetryEnroll                = "retry_enroll"
 	operationDbUnenroll                   = "unenroll"
 	operationDbUpdateUnenroll             = "update_unenroll"
 	operationDbFailUnenroll               = "failed_unenroll"
+	operationDbGetUnenrollError           = "get_unenroll_error"
 	operationDbGetPublicKey               = "get_publickey"
 	operationDbSetPublicKey               = "set_publickey"
+	operationDbRevokeKey                  = "revoke_publickey"
+	operationDbListPublicKeys             = "list_publickeys"
+	operationDbGetNewestKeyTime           = "get_newest_publickey_time"
 	operationDbCreatePolicy               = "create_policy"
 	operationDbGetPolicy                  = "get_policy"
 	operationDbUpdatePolicy               = "update_policy"
 	operationDbGetPolicyByTenant          = "get_policy_by_tenant"
+	operationDbListEnrolls                = "list_enrolls"
+	operationDbGetDeviceHistory           = "get_device_history"
+	operationDbGetIdempotentRequest       = "get_idempotent_request"
+	operationDbCreateWebhook              = "create_webhook"
+	operationDbListWebhooks               = "list_webhooks"
+	operationDbDeleteWebhook              = "delete_webhook"
+	operationDbCreateWebhookDeliveries    = "create_webhook_deliveries"
+	operationDbClaimWebhookDeliveries     = "claim_webhook_deliveries"
+	operationDbUpdateWebhookDelivery      = "update_webhook_delivery"
+	operationDbListWebhookDeliveries      = "list_webhook_deliveries"
+	operationDbCreateScepEnroll           = "create_scep_enroll"
+	operationDbGetScepTransaction         = "get_scep_transaction"
 	// internal calls
 	operationDbDeleteExpiredEnrolls = "delete_expired_enrolls"
+	operationDbDeleteUnseenKeys     = "delete_unseen_publickeys"
 )
 
 var (
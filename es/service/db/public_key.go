@@ -8,44 +8,50 @@ import (
 	"time"
 
 	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 )
 
-// check if key exists
-func HasKey(kid string) (bool, error) {
+// check if key exists for key source
+func HasKey(source, kid string) (bool, error) {
 	count := 0
 	ctx, cancelFunc := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancelFunc()
-	err := gDbPool.QueryRow(ctx, "SELECT count(*) FROM public_key WHERE kid=$1", kid).Scan(&count)
+	err := gDbPool.QueryRow(ctx, "SELECT count(*) FROM public_key WHERE key_source=$1 AND kid=$2",
+		source, kid).Scan(&count)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 	}
 	return count == 1, nil
 }
 
-// If we have a public key saved, look it up and return
-func GetPublicKey(kid string) (string, error) {
-	var key string
-	var err error
+// If we have a public key saved for key source, look it up and return.
+// keys are only valid for tokens of the source they were fetched for.
+// revoked keys are not returned. alg is the signing algorithm of the
+// key, or its key type if the source did not publish one.
+func GetPublicKey(source, kid string) (key string, alg string, err error) {
 	start := time.Now()
 	ctx, cancelFunc := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancelFunc()
-	err = gDbPool.QueryRow(ctx, "SELECT public_key FROM public_key WHERE kid=$1", kid).Scan(&key)
+	err = gDbPool.QueryRow(ctx, `SELECT public_key, alg FROM public_key
+		WHERE key_source=$1 AND kid=$2 AND revoked_at IS NULL`,
+		source, kid).Scan(&key, &alg)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
-		return "", err
+		return "", "", err
 	}
 	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
 		start, operationDbGetPublicKey)
-	return key, nil
+	return key, alg, nil
 }
 
-func addPublicKey(kid string, alg string, key string) error {
+func addPublicKey(source, kid string, alg string, key string) error {
 	start := time.Now()
 	ctx, cancelFunc := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancelFunc()
-	_, err := gDbPool.Exec(ctx, "INSERT INTO public_key (kid, alg, public_key) VALUES($1, $2, $3)",
-		kid, alg, key)
+	_, err := gDbPool.Exec(ctx, "INSERT INTO public_key (key_source, kid, alg, public_key) VALUES($1, $2, $3, $4)",
+		source, kid, alg, key)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return err
@@ -55,15 +61,121 @@ func addPublicKey(kid string, alg string, key string) error {
 	return nil
 }
 
-// strip begin and end markers and add to db
-func AddKey(kid string, alg string, key string) error {
-	var err error
-	var ok bool
-	if ok, err = HasKey(kid); err != nil {
+// add key published by source or mark it seen if already added.
+// replicas refresh the same sources so this must not fail on conflict.
+// the stored key of a kid is never replaced.
+func AddKey(source, kid string, alg string, key string) error {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancelFunc()
+	_, err := gDbPool.Exec(ctx,
+		`INSERT INTO public_key (key_source, kid, alg, public_key)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (key_source, kid) DO UPDATE SET last_seen=NOW()`,
+		source, kid, alg, key)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
+		start, operationDbSetPublicKey)
+	return nil
+}
+
+// delete keys of source not published since before. revoked keys
+// are kept. returns the number of keys deleted.
+func DeleteUnseenKeys(source string, before time.Time) (int64, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	res, err := gDbPool.Exec(ctx,
+		`DELETE FROM public_key WHERE key_source=$1 AND last_seen < $2
+		AND revoked_at IS NULL`, source, before)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return 0, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
+		start, operationDbDeleteUnseenKeys)
+	return res.RowsAffected(), nil
+}
+
+// revoke key of source. it no longer verifies tokens even if its
+// source still publishes it. revoking a revoked key is not an error.
+func RevokeKey(source, kid string) error {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	res, err := gDbPool.Exec(ctx,
+		`UPDATE public_key SET revoked_at=COALESCE(revoked_at, NOW())
+		WHERE key_source=$1 AND kid=$2`, source, kid)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return err
 	}
-	if ok {
-		return nil
+	if res.RowsAffected() == 0 {
+		return ErrNoRows
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
+		start, operationDbRevokeKey)
+	return nil
+}
+
+// first seen time of the newest unrevoked key of source.
+// ErrNoRows if source has no keys.
+func GetNewestKeyTime(source string) (time.Time, error) {
+	var firstSeen pgtype.Timestamptz
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx, `SELECT MAX(first_seen) FROM public_key
+		WHERE key_source=$1 AND revoked_at IS NULL`, source).Scan(&firstSeen)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return time.Time{}, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency,
+		start, operationDbGetNewestKeyTime)
+	if !firstSeen.Valid {
+		return time.Time{}, ErrNoRows
+	}
+	return firstSeen.Time, nil
+}
+
+// all stored keys, including revoked keys, by source and first seen
+func ListPublicKeys() ([]*structs.PublicKey, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	rows, err := gDbPool.Query(ctx,
+		`SELECT key_source, kid, alg, first_seen, last_seen, revoked_at
+		FROM public_key ORDER BY key_source, first_seen, kid`)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*structs.PublicKey{}
+	for rows.Next() {
+		var revokedAt pgtype.Timestamptz
+		k := &structs.PublicKey{}
+		if err = rows.Scan(&k.KeySource, &k.Kid, &k.Alg, &k.FirstSeen,
+			&k.LastSeen, &revokedAt); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		if revokedAt.Valid {
+			k.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, k)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
 	}
-	return addPublicKey(kid, alg, key)
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbListPublicKeys)
+	return keys, nil
 }
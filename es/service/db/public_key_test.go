@@ -5,17 +5,20 @@ package db
 
 import (
 	"testing"
+	"time"
 
+	"github.com/HPInc/krypton-es/es/service/structs"
 	"github.com/google/uuid"
 )
 
 const (
-	ktyRSA = "RSA"
+	ktyRSA        = "RSA"
+	keySourceTest = "test"
 )
 
 func TestHasKeyReturnsTrueOnMatch(t *testing.T) {
 	kid, _ := createAndAddPublicKey()
-	ok, err := HasKey(kid)
+	ok, err := HasKey(keySourceTest, kid)
 	if err != nil {
 		handleError(t, err)
 	}
@@ -25,7 +28,7 @@ func TestHasKeyReturnsTrueOnMatch(t *testing.T) {
 }
 
 func TestHasKeyReturnsFalseOnNoMatch(t *testing.T) {
-	ok, err := HasKey(uuid.New().String())
+	ok, err := HasKey(keySourceTest, uuid.New().String())
 	if err != nil {
 		handleError(t, err)
 	}
@@ -36,10 +39,13 @@ func TestHasKeyReturnsFalseOnNoMatch(t *testing.T) {
 
 func TestGetPublicKeySucceedsOnMatch(t *testing.T) {
 	kid, key := createAndAddPublicKey()
-	keyFetched, err := GetPublicKey(kid)
+	keyFetched, alg, err := GetPublicKey(keySourceTest, kid)
 	if err != nil {
 		handleError(t, err)
 	}
+	if alg != ktyRSA {
+		t.Errorf("Expected alg: %s, Found: %s", ktyRSA, alg)
+	}
 	if keyFetched == "" {
 		t.Errorf("Expected key fetched: %s, Found empty string", key)
 	} else if key != keyFetched {
@@ -48,7 +54,7 @@ func TestGetPublicKeySucceedsOnMatch(t *testing.T) {
 }
 
 func TestGetPublicKeyFailsOnNoMatch(t *testing.T) {
-	keyFetched, err := GetPublicKey(uuid.New().String())
+	keyFetched, _, err := GetPublicKey(keySourceTest, uuid.New().String())
 	if err == nil {
 		t.Errorf("Expected no rows error, Found nil")
 	}
@@ -59,22 +65,135 @@ func TestGetPublicKeyFailsOnNoMatch(t *testing.T) {
 
 func TestAddPublicKeySucceeds(t *testing.T) {
 	kid := uuid.New().String()
-	if err := addPublicKey(kid, ktyRSA, uuid.New().String()); err != nil {
+	if err := addPublicKey(keySourceTest, kid, ktyRSA, uuid.New().String()); err != nil {
 		handleError(t, err)
 	}
 }
 
 func TestAddPublicKeyFailsOnDuplicateKey(t *testing.T) {
 	kid, _ := createAndAddPublicKey()
-	if err := addPublicKey(kid, ktyRSA, uuid.New().String()); err == nil {
+	if err := addPublicKey(keySourceTest, kid, ktyRSA, uuid.New().String()); err == nil {
 		t.Errorf("Expected duplicate key error. Found no error")
 	}
 }
 
+// a kid is only found for the source it was added for
+func TestGetPublicKeyFailsForOtherSource(t *testing.T) {
+	kid, _ := createAndAddPublicKey()
+	if ok, _ := HasKey("azuread", kid); ok {
+		t.Errorf("Expected no match for HasKey of other source")
+	}
+	if _, _, err := GetPublicKey("azuread", kid); err == nil {
+		t.Errorf("Expected no rows error for other source, Found nil")
+	}
+	if err := addPublicKey("azuread", kid, ktyRSA, uuid.New().String()); err != nil {
+		handleError(t, err)
+	}
+}
+
+// adding a key again marks it seen and keeps the stored key
+func TestAddKeyMarksKeySeen(t *testing.T) {
+	kid, key := createAndAddPublicKey()
+	handleError(t, AddKey(keySourceTest, kid, ktyRSA, uuid.New().String()))
+	keyFetched, _, err := GetPublicKey(keySourceTest, kid)
+	handleError(t, err)
+	if keyFetched != key {
+		t.Errorf("Expected key fetched: %s, Found: %s", key, keyFetched)
+	}
+	k := findPublicKey(t, kid)
+	if !k.LastSeen.After(k.FirstSeen) {
+		t.Errorf("Expected last seen %v after first seen %v", k.LastSeen,
+			k.FirstSeen)
+	}
+}
+
+func TestRevokedKeyIsNotReturned(t *testing.T) {
+	kid, _ := createAndAddPublicKey()
+	handleError(t, RevokeKey(keySourceTest, kid))
+	// revoking again is not an error
+	handleError(t, RevokeKey(keySourceTest, kid))
+	if _, _, err := GetPublicKey(keySourceTest, kid); err == nil {
+		t.Errorf("Expected no rows error for revoked key, Found nil")
+	}
+	// still published by source
+	handleError(t, AddKey(keySourceTest, kid, ktyRSA, uuid.New().String()))
+	if _, _, err := GetPublicKey(keySourceTest, kid); err == nil {
+		t.Errorf("Expected revoked key to stay revoked, Found key")
+	}
+	if k := findPublicKey(t, kid); k.RevokedAt == nil {
+		t.Errorf("Expected revoked time in key list")
+	}
+}
+
+func TestRevokeKeyFailsOnNoMatch(t *testing.T) {
+	err := RevokeKey(keySourceTest, uuid.New().String())
+	expectError(t, err, ErrNoRows)
+}
+
+// keys not published since the cutoff are deleted. revoked keys are kept.
+func TestDeleteUnseenKeys(t *testing.T) {
+	source := uuid.New().String()
+	kid, revokedKid := uuid.New().String(), uuid.New().String()
+	handleError(t, addPublicKey(source, kid, ktyRSA, uuid.New().String()))
+	handleError(t, addPublicKey(source, revokedKid, ktyRSA, uuid.New().String()))
+	handleError(t, RevokeKey(source, revokedKid))
+
+	count, err := DeleteUnseenKeys(source, time.Now().Add(-time.Hour))
+	handleError(t, err)
+	if count != 0 {
+		t.Errorf("Expected no keys deleted before cutoff, Found: %d", count)
+	}
+	count, err = DeleteUnseenKeys(source, time.Now().Add(time.Hour))
+	handleError(t, err)
+	if count != 1 {
+		t.Errorf("Expected 1 key deleted, Found: %d", count)
+	}
+	if ok, _ := HasKey(source, revokedKid); !ok {
+		t.Errorf("Expected revoked key to be kept")
+	}
+}
+
+func TestGetNewestKeyTime(t *testing.T) {
+	source := uuid.New().String()
+	_, err := GetNewestKeyTime(source)
+	expectError(t, err, ErrNoRows)
+
+	kid, newerKid := uuid.New().String(), uuid.New().String()
+	handleError(t, addPublicKey(source, kid, ktyRSA, uuid.New().String()))
+	first, err := GetNewestKeyTime(source)
+	handleError(t, err)
+	time.Sleep(time.Millisecond * 10)
+	handleError(t, addPublicKey(source, newerKid, ktyRSA, uuid.New().String()))
+	newest, err := GetNewestKeyTime(source)
+	handleError(t, err)
+	if !newest.After(first) {
+		t.Errorf("Expected newest key time %v after %v", newest, first)
+	}
+	// revoked keys do not count
+	handleError(t, RevokeKey(source, newerKid))
+	newest, err = GetNewestKeyTime(source)
+	handleError(t, err)
+	if !newest.Equal(first) {
+		t.Errorf("Expected newest key time %v, Found: %v", first, newest)
+	}
+}
+
+func findPublicKey(t *testing.T, kid string) *structs.PublicKey {
+	keys, err := ListPublicKeys()
+	handleError(t, err)
+	for _, k := range keys {
+		if k.KeySource == keySourceTest && k.Kid == kid {
+			return k
+		}
+	}
+	t.Fatalf("Expected key %s in key list", kid)
+	return nil
+}
+
 // util function addPublicKey
 func createAndAddPublicKey() (string, string) {
 	kid := uuid.New().String()
 	key := uuid.New().String()
-	addPublicKey(kid, ktyRSA, key)
+	addPublicKey(keySourceTest, kid, ktyRSA, key)
 	return kid, key
 }
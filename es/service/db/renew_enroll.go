@@ -17,23 +17,28 @@ import (
 // renew enroll
 // 1. find existing entry and move to enroll_archive
 // 2. create new enroll record
-func RenewEnroll(tenantId string, deviceId uuid.UUID, userId, csrHash string) (
-	*structs.DeviceEntry, error) {
+func RenewEnroll(tenantId string, deviceId uuid.UUID, userId, csrHash, payload string,
+	ir *structs.IdempotentRequest) (*structs.DeviceEntry, error) {
 	defer metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, time.Now(),
 		operationDbRenewEnroll)
 
 	de := structs.DeviceEntry{}
+	key, requestHash := getIdempotencyValues(ir)
 	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
 	defer cancelFunc()
-	err := gDbPool.QueryRow(ctx, `INSERT INTO enroll(tenant_id, user_id, device_id, csr_hash)
-		VALUES($1,$2, $3, $4) RETURNING id, request_id`,
-		tenantId, userId, deviceId, csrHash).Scan(&de.Id, &de.RequestId)
+	err := gDbPool.QueryRow(ctx, `INSERT INTO enroll(tenant_id, user_id, device_id, csr_hash, payload,
+		idempotency_key, request_hash, request_type)
+		VALUES($1,$2, $3, $4, $5, $6, $7, $8) RETURNING id, request_id`,
+		tenantId, userId, deviceId, csrHash, payload, key, requestHash,
+		HistoryOperationRenewEnroll).Scan(
+		&de.Id, &de.RequestId)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return nil, err
 	}
 	go cache.CreateEnrollStatus(de.Id, tenantId, userId, deviceId, 0)
 	go cache.SetCsrHash(csrHash)
+	go setIdempotentRequest(IdempotencyKindEnroll, tenantId, ir, &de)
 
 	return &de, nil
 }
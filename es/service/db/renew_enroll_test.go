@@ -26,7 +26,7 @@ func TestRenewEnrollRecord(t *testing.T) {
 		handleError(t, err)
 	}
 	er2, err := RenewEnroll(
-		tenantId, dc.DeviceId, er.UserId, "csrhash2")
+		tenantId, dc.DeviceId, er.UserId, "csrhash2", "", nil)
 	if err != nil {
 		handleError(t, err)
 	}
@@ -64,7 +64,7 @@ func TestRenewEnrollWithoutUserId(t *testing.T) {
 		handleError(t, err)
 	}
 	er2, err := RenewEnroll(
-		tenantId, dc.DeviceId, "", "csrhash3")
+		tenantId, dc.DeviceId, "", "csrhash3", "", nil)
 	if err != nil {
 		handleError(t, err)
 	}
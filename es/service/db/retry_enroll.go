@@ -0,0 +1,89 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/cache"
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// retry a failed enroll
+// 1. find failed entry for tenant in enroll_error
+// 2. move it back to enroll as pending, keeping the same id
+// returns the restored entry and the payload to hand off again
+func RetryEnrollRecord(id uuid.UUID, tenantId string) (
+	*structs.DeviceEntry, string, error) {
+	defer metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, time.Now(),
+		operationDbRetryEnroll)
+
+	var payload pgtype.Text
+	var csrHash string
+	var deviceId uuid.UUID
+	de := structs.DeviceEntry{Id: id, TenantId: tenantId}
+
+	ctx, cancel := context.WithTimeout(gCtx, dbTimeout)
+	defer cancel()
+
+	tx, err := gDbPool.Begin(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rollback(tx, ctx)
+
+	if err = tx.QueryRow(ctx,
+		`SELECT request_id, COALESCE(user_id, ''), csr_hash, device_id, payload
+		FROM enroll_error WHERE id=$1 AND tenant_id=$2 FOR UPDATE`,
+		id, tenantId).Scan(&de.RequestId, &de.UserId, &csrHash, &deviceId,
+		&payload); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, "", err
+	}
+	if !payload.Valid || payload.String == "" {
+		return nil, "", ErrNoRetryPayload
+	}
+
+	// the device may have enrolled again with the same csr after
+	// the failure. do not create a duplicate csr entry.
+	var count int
+	if err = tx.QueryRow(ctx, "SELECT count(*) FROM enroll WHERE csr_hash=$1",
+		csrHash).Scan(&count); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, "", err
+	}
+	if count > 0 {
+		return nil, "", ErrRetryCsrInUse
+	}
+
+	if _, err = tx.Exec(ctx, `INSERT INTO enroll (
+		id, request_id, tenant_id, user_id, csr_hash, status, device_id, payload,
+		request_type, idempotency_key, request_hash)
+		(SELECT id, request_id, tenant_id, user_id, csr_hash, 0, device_id, payload,
+		request_type, idempotency_key, request_hash
+		FROM enroll_error WHERE id=$1)`, id); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, "", err
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM enroll_error where id=$1", id); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, "", err
+	}
+
+	commit(tx, ctx)
+
+	esLogger.Info("Restored failed enroll entry",
+		zap.String("enroll_id:", id.String()),
+		zap.String("tenant_id:", tenantId))
+
+	go cache.CreateEnrollStatus(id, tenantId, de.UserId, deviceId, 0)
+	go cache.SetCsrHash(csrHash)
+	return &de, payload.String, nil
+}
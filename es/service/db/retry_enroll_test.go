@@ -0,0 +1,66 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// fail an enroll, then retry. enroll is pending again with the same id.
+func TestRetryEnrollRecord(t *testing.T) {
+	tenantId := uuid.New().String()
+	payload := `{"csr":"Y3Ny"}`
+	er, err := CreateEnrollRecord(tenantId, "", uuid.New().String(), payload, nil)
+	handleError(t, err)
+	handleError(t, FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     er.Id.String(),
+		ErrorMessage: "ca unavailable",
+	}))
+
+	de, retryPayload, err := RetryEnrollRecord(er.Id, tenantId)
+	handleError(t, err)
+	if de == nil || de.RequestId != er.RequestId || retryPayload != payload {
+		t.Errorf("Unexpected retry result: %v, %s", de, retryPayload)
+	}
+
+	status, err := GetEnrollStatus(er.Id)
+	handleError(t, err)
+	if status == nil || status.Status != 0 {
+		t.Errorf("Expected pending status, got %v", status)
+	}
+
+	_, err = GetEnrollErrorStatus(er.Id)
+	expectError(t, err, ErrNoRows)
+}
+
+// retry is scoped to tenant
+func TestRetryEnrollRecordForAnotherTenantFails(t *testing.T) {
+	er, err := CreateEnrollRecord(uuid.New().String(), "",
+		uuid.New().String(), `{"csr":"Y3Ny"}`, nil)
+	handleError(t, err)
+	handleError(t, FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     er.Id.String(),
+		ErrorMessage: "ca unavailable",
+	}))
+
+	_, _, err = RetryEnrollRecord(er.Id, uuid.New().String())
+	expectError(t, err, ErrNoRows)
+}
+
+// enrolls failed without a payload cannot be retried
+func TestRetryEnrollRecordWithoutPayloadFails(t *testing.T) {
+	tenantId := uuid.New().String()
+	er, err := CreateEnrollRecord(tenantId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+	handleError(t, FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     er.Id.String(),
+		ErrorMessage: "ca unavailable",
+	}))
+
+	_, _, err = RetryEnrollRecord(er.Id, tenantId)
+	expectError(t, err, ErrNoRetryPayload)
+}
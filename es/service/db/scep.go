@@ -0,0 +1,89 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/cache"
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// create the enroll of a scep transaction and record the transaction in
+// one db transaction, so a retried transaction always finds its enroll.
+// the enroll is a renew when deviceId is set. transaction ids are client
+// chosen, so they are scoped to the hash of the signer key.
+func CreateScepEnroll(tenantId string, deviceId uuid.UUID, userId, csrHash,
+	payload, transactionId, signerKeyHash string) (*structs.DeviceEntry, error) {
+	start := time.Now()
+	de := structs.DeviceEntry{TenantId: tenantId, UserId: userId}
+	requestType := HistoryOperationEnroll
+	var device any
+	if deviceId != uuid.Nil {
+		requestType = HistoryOperationRenewEnroll
+		device = deviceId
+	}
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	tx, err := gDbPool.Begin(ctx)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rollback(tx, ctx)
+
+	if err = tx.QueryRow(ctx,
+		`INSERT INTO enroll(tenant_id, user_id, device_id, csr_hash, payload,
+		request_type) VALUES($1,$2,$3,$4,$5,$6) RETURNING id, request_id`,
+		tenantId, userId, device, csrHash, payload, requestType).Scan(
+		&de.Id, &de.RequestId); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	if _, err = tx.Exec(ctx,
+		`INSERT INTO scep_transaction(transaction_id, signer_key_hash, enroll_id)
+		VALUES($1, $2, $3)`,
+		transactionId, signerKeyHash, de.Id); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	// the enroll is handed off after this. do not hand off an enroll
+	// that was not stored.
+	if err = tx.Commit(ctx); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		metrics.MetricDatabaseCommitErrors.Inc()
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbCreateScepEnroll)
+	go cache.CreateEnrollStatus(de.Id, tenantId, userId, deviceId, 0)
+	go cache.SetCsrHash(csrHash)
+	return &de, nil
+}
+
+// get the enroll id of a scep transaction
+func GetScepTransaction(transactionId, signerKeyHash string) (uuid.UUID, error) {
+	var id uuid.UUID
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx,
+		`SELECT enroll_id FROM scep_transaction
+		WHERE transaction_id=$1 AND signer_key_hash=$2`,
+		transactionId, signerKeyHash).Scan(&id)
+	if err != nil {
+		if !IsDbErrorNoRows(err) {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+		}
+		return uuid.Nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetScepTransaction)
+	return id, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestScepTransaction(t *testing.T) {
+	transactionId := uuid.New().String()
+	signerKeyHash := uuid.New().String()
+	de, err := CreateScepEnroll(uuid.NewString(), uuid.Nil, uuid.NewString(),
+		uuid.NewString(), "{}", transactionId, signerKeyHash)
+	if err != nil {
+		t.Fatalf("Failed to create scep enroll: %v", err)
+	}
+	id, err := GetScepTransaction(transactionId, signerKeyHash)
+	if err != nil {
+		handleError(t, err)
+	} else if id != de.Id {
+		t.Errorf("Expected id = %v, got %v", de.Id, id)
+	}
+
+	// same transaction id signed by another key is not visible
+	_, err = GetScepTransaction(transactionId, uuid.New().String())
+	if !IsDbErrorNoRows(err) {
+		t.Errorf("Expected = %v, got %v", ErrNoRows, err)
+	}
+
+	// a transaction id is only recorded once per key. the enroll of
+	// the repeat is not kept.
+	csrHash := uuid.NewString()
+	if _, err = CreateScepEnroll(uuid.NewString(), uuid.New(),
+		uuid.NewString(), csrHash, "{}", transactionId,
+		signerKeyHash); err == nil {
+		t.Errorf("Expected error. Got no error")
+	}
+	if id, err = GetEnrollIdByCSRHash(csrHash); !IsDbErrorNoRows(err) {
+		t.Errorf("Expected no enroll, got %v: %v", id, err)
+	}
+}
@@ -0,0 +1,4 @@
+-- drop scep transactions
+drop index scep_transaction_created_at_index;
+DROP TABLE scep_transaction;
+--
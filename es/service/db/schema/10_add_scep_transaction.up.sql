@@ -0,0 +1,11 @@
+-- scep transactions. a transaction id is scoped to the key that signed it.
+CREATE TABLE scep_transaction
+(
+	transaction_id TEXT NOT NULL,
+	signer_key_hash TEXT NOT NULL,
+	enroll_id UUID NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY(transaction_id, signer_key_hash)
+);
+create index scep_transaction_created_at_index on scep_transaction (created_at);
+--
@@ -0,0 +1,7 @@
+-- drop public key source. kids are global again so scoped keys are
+-- dropped and fetched again at startup.
+DELETE FROM public_key;
+ALTER TABLE public_key DROP CONSTRAINT public_key_pkey;
+ALTER TABLE public_key DROP key_source;
+ALTER TABLE public_key ADD PRIMARY KEY(kid);
+--
@@ -0,0 +1,8 @@
+-- scope public keys to the token_types entry whose keys url they came
+-- from. keys without a source are dropped. the jwks refresher fetches
+-- them again at startup.
+DELETE FROM public_key;
+ALTER TABLE public_key ADD key_source VARCHAR(64) NOT NULL;
+ALTER TABLE public_key DROP CONSTRAINT public_key_pkey;
+ALTER TABLE public_key ADD PRIMARY KEY(key_source, kid);
+--
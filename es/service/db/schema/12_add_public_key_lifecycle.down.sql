@@ -0,0 +1,6 @@
+-- drop public key lifecycle
+drop index public_key_last_seen_index;
+ALTER TABLE public_key DROP revoked_at;
+ALTER TABLE public_key DROP last_seen;
+ALTER TABLE public_key DROP first_seen;
+--
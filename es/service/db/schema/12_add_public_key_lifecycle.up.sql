@@ -0,0 +1,9 @@
+-- track when a key was first and last published by its source. keys
+-- no longer published expire after a grace period. revoked keys are
+-- kept so a source that still publishes them cannot add them back.
+ALTER TABLE public_key ADD first_seen TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE public_key ADD last_seen TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE public_key ADD revoked_at TIMESTAMP NULL;
+UPDATE public_key SET first_seen = created_at WHERE created_at IS NOT NULL;
+create index public_key_last_seen_index on public_key (key_source, last_seen);
+--
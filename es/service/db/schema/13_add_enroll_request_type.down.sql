@@ -0,0 +1,5 @@
+-- drop request type from enroll tables
+ALTER TABLE enroll DROP request_type;
+ALTER TABLE enroll_archive DROP request_type;
+ALTER TABLE enroll_error DROP request_type;
+--
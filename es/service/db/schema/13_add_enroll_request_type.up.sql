@@ -0,0 +1,30 @@
+-- store whether an enroll row is an initial enroll or a renewal so
+-- device history does not have to guess it from row order
+-- enroll
+ALTER TABLE enroll ADD request_type VARCHAR(16) NOT NULL DEFAULT 'enroll';
+-- enroll_archive
+ALTER TABLE enroll_archive ADD request_type VARCHAR(16) NOT NULL DEFAULT 'enroll';
+-- enroll error
+ALTER TABLE enroll_error ADD request_type VARCHAR(16) NOT NULL DEFAULT 'enroll';
+--
+-- rows stored before request types were tracked: every enroll of a
+-- device after its earliest one is a renewal
+CREATE TEMPORARY TABLE first_device_enroll AS
+	SELECT tenant_id, device_id, MIN(created_at) AS created_at FROM (
+		SELECT tenant_id, device_id, created_at FROM enroll
+		UNION ALL
+		SELECT tenant_id, device_id, created_at FROM enroll_archive
+		UNION ALL
+		SELECT tenant_id, device_id, created_at FROM enroll_error) e
+	WHERE device_id IS NOT NULL GROUP BY tenant_id, device_id;
+UPDATE enroll e SET request_type = 'renew_enroll' FROM first_device_enroll f
+	WHERE e.tenant_id = f.tenant_id AND e.device_id = f.device_id
+	AND e.created_at > f.created_at;
+UPDATE enroll_archive e SET request_type = 'renew_enroll' FROM first_device_enroll f
+	WHERE e.tenant_id = f.tenant_id AND e.device_id = f.device_id
+	AND e.created_at > f.created_at;
+UPDATE enroll_error e SET request_type = 'renew_enroll' FROM first_device_enroll f
+	WHERE e.tenant_id = f.tenant_id AND e.device_id = f.device_id
+	AND e.created_at > f.created_at;
+DROP TABLE first_device_enroll;
+--
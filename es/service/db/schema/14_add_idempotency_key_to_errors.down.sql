@@ -0,0 +1,10 @@
+-- drop idempotency key and request hash from error tables
+-- enroll error
+drop index enroll_error_idempotency_key_index;
+ALTER TABLE enroll_error DROP idempotency_key;
+ALTER TABLE enroll_error DROP request_hash;
+-- unenroll error
+drop index unenroll_error_idempotency_key_index;
+ALTER TABLE unenroll_error DROP idempotency_key;
+ALTER TABLE unenroll_error DROP request_hash;
+--
@@ -0,0 +1,13 @@
+-- keep idempotency key and request hash of failed enroll and unenroll
+-- so a repeat with the same key gets the original response
+-- enroll error
+ALTER TABLE enroll_error ADD idempotency_key TEXT;
+ALTER TABLE enroll_error ADD request_hash TEXT;
+create unique index enroll_error_idempotency_key_index on enroll_error (tenant_id, idempotency_key)
+	where idempotency_key is not null;
+-- unenroll error
+ALTER TABLE unenroll_error ADD idempotency_key TEXT;
+ALTER TABLE unenroll_error ADD request_hash TEXT;
+create unique index unenroll_error_idempotency_key_index on unenroll_error (tenant_id, idempotency_key)
+	where idempotency_key is not null;
+--
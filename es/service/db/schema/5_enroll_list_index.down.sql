@@ -0,0 +1,2 @@
+-- drop tenant scoped keyset index on enroll table
+drop index enroll_tenant_created_at_index;
@@ -0,0 +1,2 @@
+-- tenant scoped keyset index for listing enroll records
+create index enroll_tenant_created_at_index on enroll (tenant_id, created_at, id);
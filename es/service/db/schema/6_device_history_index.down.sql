@@ -0,0 +1,7 @@
+-- drop tenant and device indexes used for device history
+drop index enroll_tenant_device_index;
+drop index enroll_archive_tenant_device_index;
+drop index enroll_error_tenant_device_index;
+drop index unenroll_tenant_device_index;
+drop index unenroll_archive_tenant_device_index;
+drop index unenroll_error_tenant_device_index;
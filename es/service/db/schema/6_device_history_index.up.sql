@@ -0,0 +1,7 @@
+-- tenant and device index on enroll and unenroll tables for device history
+create index enroll_tenant_device_index on enroll (tenant_id, device_id);
+create index enroll_archive_tenant_device_index on enroll_archive (tenant_id, device_id);
+create index enroll_error_tenant_device_index on enroll_error (tenant_id, device_id);
+create index unenroll_tenant_device_index on unenroll (tenant_id, device_id);
+create index unenroll_archive_tenant_device_index on unenroll_archive (tenant_id, device_id);
+create index unenroll_error_tenant_device_index on unenroll_error (tenant_id, device_id);
@@ -0,0 +1,8 @@
+-- drop enroll payload from enroll tables
+-- enroll
+ALTER TABLE enroll DROP payload;
+-- enroll_archive
+ALTER TABLE enroll_archive DROP payload;
+-- enroll error
+ALTER TABLE enroll_error DROP payload;
+--
@@ -0,0 +1,8 @@
+-- keep original enroll payload so failed enrolls can be retried
+-- enroll
+ALTER TABLE enroll ADD payload TEXT;
+-- enroll_archive
+ALTER TABLE enroll_archive ADD payload TEXT;
+-- enroll error
+ALTER TABLE enroll_error ADD payload TEXT;
+--
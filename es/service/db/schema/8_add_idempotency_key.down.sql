@@ -0,0 +1,10 @@
+-- drop idempotency key and request hash
+-- enroll
+drop index enroll_idempotency_key_index;
+ALTER TABLE enroll DROP idempotency_key;
+ALTER TABLE enroll DROP request_hash;
+-- unenroll
+drop index unenroll_idempotency_key_index;
+ALTER TABLE unenroll DROP idempotency_key;
+ALTER TABLE unenroll DROP request_hash;
+--
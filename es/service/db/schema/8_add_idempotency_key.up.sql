@@ -0,0 +1,12 @@
+-- idempotency key and request hash for repeatable enroll and unenroll calls
+-- enroll
+ALTER TABLE enroll ADD idempotency_key TEXT;
+ALTER TABLE enroll ADD request_hash TEXT;
+create unique index enroll_idempotency_key_index on enroll (tenant_id, idempotency_key)
+	where idempotency_key is not null;
+-- unenroll
+ALTER TABLE unenroll ADD idempotency_key TEXT;
+ALTER TABLE unenroll ADD request_hash TEXT;
+create unique index unenroll_idempotency_key_index on unenroll (tenant_id, idempotency_key)
+	where idempotency_key is not null;
+--
@@ -0,0 +1,7 @@
+-- drop tenant webhooks
+drop index webhook_delivery_webhook_index;
+drop index webhook_delivery_due_index;
+DROP TABLE webhook_delivery;
+drop index webhook_tenant_index;
+DROP TABLE webhook;
+--
@@ -0,0 +1,32 @@
+-- tenant webhooks for enroll lifecycle events
+CREATE TABLE webhook
+(
+	id UUID NOT NULL DEFAULT uuid_generate_v4(),
+	tenant_id TEXT NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY(id)
+);
+create index webhook_tenant_index on webhook (tenant_id);
+-- one row per event per webhook. also serves as the delivery log.
+CREATE TABLE webhook_delivery
+(
+	id UUID NOT NULL DEFAULT uuid_generate_v4(),
+	webhook_id UUID NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+	tenant_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status SMALLINT NOT NULL DEFAULT 0,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_code INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP NULL,
+	PRIMARY KEY(id)
+);
+create index webhook_delivery_due_index on webhook_delivery (next_attempt_at)
+	where status = 0;
+create index webhook_delivery_webhook_index on webhook_delivery (webhook_id, created_at);
+--
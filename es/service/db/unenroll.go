@@ -17,16 +17,20 @@ import (
 // unenroll
 // 1. create new unenroll record denoting an unenroll entry
 // this db entry will be used to track the queue result
-func Unenroll(tenantId string, deviceId uuid.UUID) (
-	*structs.DeviceEntry, error) {
+// ir is nil unless the request specified an idempotency key
+// userId is the admin user that requested it, empty for devices
+func Unenroll(tenantId string, deviceId uuid.UUID, userId string,
+	ir *structs.IdempotentRequest) (*structs.DeviceEntry, error) {
 	start := time.Now()
 
 	de := structs.DeviceEntry{}
+	key, requestHash := getIdempotencyValues(ir)
 	ctx, cancelFunc := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancelFunc()
-	err := gDbPool.QueryRow(ctx, `INSERT INTO unenroll(tenant_id, device_id)
-		VALUES($1,$2) RETURNING id, request_id`,
-		tenantId, deviceId).Scan(&de.Id, &de.RequestId)
+	err := gDbPool.QueryRow(ctx, `INSERT INTO unenroll(tenant_id, device_id,
+		idempotency_key, request_hash, user_id)
+		VALUES($1,$2,$3,$4,NULLIF($5, '')) RETURNING id, request_id`,
+		tenantId, deviceId, key, requestHash, userId).Scan(&de.Id, &de.RequestId)
 	if err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return nil, err
@@ -35,6 +39,7 @@ func Unenroll(tenantId string, deviceId uuid.UUID) (
 	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
 		operationDbUnenroll)
 	go cache.CreateUnenrollStatus(de.Id, tenantId, deviceId.String(), 0)
+	go setIdempotentRequest(IdempotencyKindUnenroll, tenantId, ir, &de)
 	return &de, nil
 }
 
@@ -58,7 +63,9 @@ func UpdateUnenrollRecord(res *structs.UnenrollResult) error {
 	// update unenroll time for average
 	cache.AddUnenrollElapsed(elapsed)
 	// set status in cache
-	go cache.SetUnenrollStatus(res.UnenrollId, 1)
+	cache.SetUnenrollStatus(res.UnenrollId, 1)
+	// wake up status requests waiting on this id
+	go cache.PublishStatusChange(res.UnenrollId)
 	return nil
 }
 
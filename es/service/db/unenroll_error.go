@@ -7,8 +7,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/metrics"
 	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -34,10 +36,11 @@ func FailUnenrollRecord(ee *structs.EnrollError) error {
 
 	// make error record
 	if _, err = tx.Exec(ctx, `INSERT INTO unenroll_error (
-		id, request_id, tenant_id, device_id, status,
-		error_code, error_text)
-		(SELECT id, request_id, tenant_id, device_id, status,
-		$1, $2 FROM unenroll WHERE id=$3)`,
+		id, request_id, tenant_id, user_id, device_id, status,
+		created_at, idempotency_key, request_hash, error_code, error_text)
+		(SELECT id, request_id, tenant_id, user_id, device_id, status,
+		created_at, idempotency_key, request_hash, $1, $2
+		FROM unenroll WHERE id=$3)`,
 		ee.ErrorCode, ee.ErrorMessage, ee.EnrollId); err != nil {
 		esLogger.Error("DB: SQL Error", zap.Error(err))
 		return err
@@ -50,5 +53,32 @@ func FailUnenrollRecord(ee *structs.EnrollError) error {
 	}
 
 	commit(tx, ctx)
+
+	// drop cached pending status so status lookups find the error record
+	if id, err := uuid.Parse(ee.EnrollId); err == nil {
+		cache.DeleteUnenrollStatusById(id)
+		// wake up status requests waiting on this id
+		go cache.PublishStatusChange(id)
+	}
 	return nil
 }
+
+// get error details for a failed unenroll id
+func GetUnenrollErrorStatus(id uuid.UUID) (*structs.EnrollErrorStatus, error) {
+	start := time.Now()
+	entry := &structs.EnrollErrorStatus{}
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx,
+		`SELECT tenant_id, COALESCE(user_id, ''), device_id, error_code,
+		COALESCE(error_text, '') FROM unenroll_error WHERE id=$1`,
+		id).Scan(&entry.TenantId, &entry.UserId, &entry.DeviceId,
+		&entry.ErrorCode, &entry.ErrorText)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbGetUnenrollError)
+	return entry, nil
+}
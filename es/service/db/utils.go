@@ -9,17 +9,33 @@ import (
 
 	"github.com/HPInc/krypton-es/es/service/metrics"
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 )
 
+const (
+	// postgres error code for unique_violation
+	pgCodeUniqueViolation = "23505"
+)
+
 var (
 	ErrNoRows = pgx.ErrNoRows
+	// failed enroll was recorded before payloads were kept
+	ErrNoRetryPayload = errors.New("failed enroll has no payload to retry")
+	// csr of failed enroll has since been used by another enroll
+	ErrRetryCsrInUse = errors.New("csr of failed enroll is in use")
 )
 
 func IsDbErrorNoRows(err error) bool {
 	return err == ErrNoRows
 }
 
+// unique constraint violation. eg: idempotency key already used
+func IsDbErrorUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgCodeUniqueViolation
+}
+
 func commit(tx pgx.Tx, ctx context.Context) {
 	err := tx.Commit(ctx)
 	if err != nil {
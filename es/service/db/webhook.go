@@ -0,0 +1,239 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const (
+	WebhookDeliveryPending   = 0
+	WebhookDeliveryDelivered = 1
+	WebhookDeliveryFailed    = -1
+)
+
+// register a webhook for tenant
+func CreateWebhook(tenantId, url, secret string) (*structs.Webhook, error) {
+	start := time.Now()
+	var createdAt pgtype.Timestamptz
+
+	wh := structs.Webhook{TenantId: tenantId, Url: url, Secret: secret}
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+	err := gDbPool.QueryRow(ctx,
+		`INSERT INTO webhook(tenant_id, url, secret) VALUES($1,$2,$3)
+		RETURNING id, created_at`,
+		tenantId, url, secret).Scan(&wh.Id, &createdAt)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	if createdAt.Valid {
+		wh.CreatedAt = createdAt.Time
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbCreateWebhook)
+	return &wh, nil
+}
+
+// list webhooks of tenant. secrets are not returned.
+func ListWebhooks(tenantId string) ([]*structs.Webhook, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	rows, err := gDbPool.Query(ctx,
+		`SELECT id, url, created_at FROM webhook WHERE tenant_id=$1
+		ORDER BY created_at, id`, tenantId)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*structs.Webhook{}
+	for rows.Next() {
+		var createdAt pgtype.Timestamptz
+		wh := &structs.Webhook{TenantId: tenantId}
+		if err = rows.Scan(&wh.Id, &wh.Url, &createdAt); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		if createdAt.Valid {
+			wh.CreatedAt = createdAt.Time
+		}
+		webhooks = append(webhooks, wh)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbListWebhooks)
+	return webhooks, nil
+}
+
+// delete webhook and its delivery log
+func DeleteWebhook(id uuid.UUID, tenantId string) error {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	res, err := gDbPool.Exec(ctx,
+		`DELETE FROM webhook WHERE id=$1 AND tenant_id=$2`, id, tenantId)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNoRows
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbDeleteWebhook)
+	return nil
+}
+
+// queue an event for every webhook of tenant.
+// returns the number of deliveries queued.
+func CreateWebhookDeliveries(tenantId, eventType, payload string) (int64, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	res, err := gDbPool.Exec(ctx,
+		`INSERT INTO webhook_delivery(webhook_id, tenant_id, event_type, payload)
+		SELECT id, tenant_id, $2, $3 FROM webhook WHERE tenant_id=$1`,
+		tenantId, eventType, payload)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return 0, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbCreateWebhookDeliveries)
+	return res.RowsAffected(), nil
+}
+
+// claim due deliveries for an attempt. claimed deliveries are not due
+// again until lease expires so an attempt lost with its replica is retried.
+// attempts is incremented on claim.
+func ClaimWebhookDeliveries(limit int, lease time.Duration) (
+	[]*structs.WebhookDelivery, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	rows, err := gDbPool.Query(ctx,
+		`UPDATE webhook_delivery d SET attempts=d.attempts+1,
+		next_attempt_at=now() + make_interval(secs => $2), updated_at=now()
+		FROM webhook w
+		WHERE w.id=d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_delivery
+			WHERE status=0 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.webhook_id, d.tenant_id, d.event_type, d.payload,
+		d.attempts, w.url, w.secret`,
+		limit, lease.Seconds())
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*structs.WebhookDelivery{}
+	for rows.Next() {
+		d := &structs.WebhookDelivery{}
+		if err = rows.Scan(&d.Id, &d.WebhookId, &d.TenantId, &d.EventType,
+			&d.Payload, &d.Attempts, &d.Url, &d.Secret); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbClaimWebhookDeliveries)
+	return deliveries, nil
+}
+
+// record result of a delivery attempt.
+// a pending delivery is due again after retryIn.
+func UpdateWebhookDelivery(d *structs.WebhookDelivery,
+	retryIn time.Duration) error {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	_, err := gDbPool.Exec(ctx,
+		`UPDATE webhook_delivery SET status=$2, response_code=$3,
+		last_error=NULLIF($4, ''),
+		next_attempt_at=now() + make_interval(secs => $5), updated_at=now()
+		WHERE id=$1`,
+		d.Id, d.Status, d.ResponseCode, d.LastError, retryIn.Seconds())
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbUpdateWebhookDelivery)
+	return nil
+}
+
+// delivery log of a webhook, newest first
+func ListWebhookDeliveries(webhookId uuid.UUID, tenantId string, limit int) (
+	[]*structs.WebhookDelivery, error) {
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(gCtx, dbTimeout)
+	defer cancelFunc()
+
+	rows, err := gDbPool.Query(ctx,
+		`SELECT id, event_type, payload, status, attempts, response_code,
+		COALESCE(last_error, ''), next_attempt_at, created_at, updated_at
+		FROM webhook_delivery WHERE webhook_id=$1 AND tenant_id=$2
+		ORDER BY created_at DESC, id LIMIT $3`,
+		webhookId, tenantId, limit)
+	if err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*structs.WebhookDelivery{}
+	for rows.Next() {
+		var nextAttemptAt, createdAt, updatedAt pgtype.Timestamptz
+		d := &structs.WebhookDelivery{WebhookId: webhookId, TenantId: tenantId}
+		if err = rows.Scan(&d.Id, &d.EventType, &d.Payload, &d.Status,
+			&d.Attempts, &d.ResponseCode, &d.LastError, &nextAttemptAt,
+			&createdAt, &updatedAt); err != nil {
+			esLogger.Error("DB: SQL Error", zap.Error(err))
+			return nil, err
+		}
+		if nextAttemptAt.Valid {
+			d.NextAttemptAt = nextAttemptAt.Time
+		}
+		if createdAt.Valid {
+			d.CreatedAt = createdAt.Time
+		}
+		if updatedAt.Valid {
+			d.UpdatedAt = &updatedAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		esLogger.Error("DB: SQL Error", zap.Error(err))
+		return nil, err
+	}
+	metrics.ReportLatencyMetric(metrics.MetricDatabaseLatency, start,
+		operationDbListWebhookDeliveries)
+	return deliveries, nil
+}
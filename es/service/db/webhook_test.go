@@ -0,0 +1,79 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// queue an event, claim it for delivery and record a retry
+func TestWebhookDeliveryLifecycle(t *testing.T) {
+	tenantId := uuid.New().String()
+	wh, err := CreateWebhook(tenantId, "https://example.com/hook",
+		"test webhook secret")
+	handleError(t, err)
+
+	count, err := CreateWebhookDeliveries(tenantId, "enroll.completed",
+		`{"id":"1"}`)
+	handleError(t, err)
+	if count != 1 {
+		t.Fatalf("Expected 1 delivery, found: %d", count)
+	}
+
+	deliveries, err := ClaimWebhookDeliveries(100, time.Minute)
+	handleError(t, err)
+	var claimed bool
+	for _, d := range deliveries {
+		if d.WebhookId != wh.Id {
+			continue
+		}
+		claimed = true
+		if d.Attempts != 1 || d.Secret != "test webhook secret" {
+			t.Errorf("Unexpected claimed delivery: %v", d)
+		}
+		d.Status = WebhookDeliveryPending
+		d.ResponseCode = 503
+		d.LastError = "unavailable"
+		handleError(t, UpdateWebhookDelivery(d, time.Hour))
+	}
+	if !claimed {
+		t.Fatalf("Expected delivery for webhook %s to be claimed", wh.Id)
+	}
+
+	logs, err := ListWebhookDeliveries(wh.Id, tenantId, 10)
+	handleError(t, err)
+	if len(logs) != 1 || logs[0].ResponseCode != 503 ||
+		logs[0].LastError != "unavailable" {
+		t.Errorf("Unexpected delivery log: %v", logs)
+	}
+
+	// deleting the webhook drops its deliveries
+	handleError(t, DeleteWebhook(wh.Id, tenantId))
+	logs, err = ListWebhookDeliveries(wh.Id, tenantId, 10)
+	handleError(t, err)
+	if len(logs) != 0 {
+		t.Errorf("Expected no deliveries, found: %d", len(logs))
+	}
+}
+
+// no deliveries are queued for a tenant without webhooks
+func TestCreateWebhookDeliveriesWithoutWebhooks(t *testing.T) {
+	count, err := CreateWebhookDeliveries(uuid.New().String(),
+		"enroll.completed", `{}`)
+	handleError(t, err)
+	if count != 0 {
+		t.Errorf("Expected no deliveries, found: %d", count)
+	}
+}
+
+// webhooks are scoped to tenant
+func TestDeleteWebhookOfAnotherTenantFails(t *testing.T) {
+	wh, err := CreateWebhook(uuid.New().String(), "https://example.com/hook",
+		"test webhook secret")
+	handleError(t, err)
+	expectError(t, DeleteWebhook(wh.Id, uuid.New().String()), ErrNoRows)
+}
@@ -75,5 +75,5 @@ func newEnroll() {
 	userId := uuid.New().String()
 	tenantId := uuid.New().String()
 	csrHash := uuid.New().String()
-	db.CreateEnrollRecord(userId, tenantId, csrHash)
+	db.CreateEnrollRecord(userId, tenantId, csrHash, "", nil)
 }
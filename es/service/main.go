@@ -6,6 +6,7 @@ package main
 import (
 	"os"
 
+	"github.com/HPInc/krypton-es/es/service/attestation"
 	dstsclient "github.com/HPInc/krypton-es/es/service/client/dsts"
 	"github.com/HPInc/krypton-es/es/service/config"
 	"github.com/HPInc/krypton-es/es/service/db"
@@ -15,6 +16,7 @@ import (
 	"github.com/HPInc/krypton-es/es/service/policy"
 	"github.com/HPInc/krypton-es/es/service/rest"
 	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/HPInc/krypton-es/es/service/webhook"
 )
 
 func main() {
@@ -42,6 +44,17 @@ func main() {
 	}
 	defer dstsclient.Close()
 
+	// load trusted tpm manufacturer roots for enroll attestation
+	if attestation.Init(config.GetLogger(), &config.Settings.Attestation) != nil {
+		panic("attestation init failed.")
+	}
+
+	// init webhook dispatcher before queue watchers produce events
+	if webhook.Init(config.GetLogger(), &config.Settings.Webhook) != nil {
+		panic("webhook init failed.")
+	}
+	defer webhook.Shutdown()
+
 	// init notification client
 	if notification.Init(
 		config.GetLogger(), &config.Settings.Notification) != nil {
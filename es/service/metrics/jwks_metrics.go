@@ -0,0 +1,70 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// JWKS refreshes by key source, trigger and result
+	metricJwksRefreshes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_jwks_refreshes",
+			Help: "Number of JWKS refreshes, partitioned by key source, trigger and result.",
+		},
+		[]string{"source", "trigger", "result"},
+	)
+	// Age of the newest signing key of each key source
+	metricNewestSigningKeyAge = &signingKeyAgeCollector{
+		desc: prometheus.NewDesc(
+			"es_jwks_newest_key_age_seconds",
+			"Seconds since the newest signing key of a key source was first seen.",
+			[]string{"source"}, nil),
+		firstSeen: map[string]time.Time{},
+	}
+)
+
+// age is computed when metrics are collected so it keeps growing
+// between refreshes
+type signingKeyAgeCollector struct {
+	sync.Mutex
+	desc      *prometheus.Desc
+	firstSeen map[string]time.Time
+}
+
+func (c *signingKeyAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *signingKeyAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+	for source, firstSeen := range c.firstSeen {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue,
+			time.Since(firstSeen).Seconds(), source)
+	}
+}
+
+func registerJwksMetrics() {
+	prometheus.MustRegister(
+		metricJwksRefreshes,
+		metricNewestSigningKeyAge,
+	)
+}
+
+// trigger is scheduled or unknown_kid. result is one of success,
+// discovery_error, fetch_error, parse_error or rate_limited
+func ReportJwksRefresh(source, trigger, result string) {
+	metricJwksRefreshes.WithLabelValues(source, trigger, result).Inc()
+}
+
+func ReportNewestSigningKey(source string, firstSeen time.Time) {
+	metricNewestSigningKeyAge.Lock()
+	defer metricNewestSigningKeyAge.Unlock()
+	metricNewestSigningKeyAge.firstSeen[source] = firstSeen
+}
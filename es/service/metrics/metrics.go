@@ -22,6 +22,9 @@ func RegisterPrometheusMetrics() {
 	registerCacheMetrics()
 	registerDatabaseMetrics()
 	registerJobMetrics()
+	registerJwksMetrics()
 	registerQueueMetrics()
 	registerRestMetrics()
+	registerTokenMetrics()
+	registerWebhookMetrics()
 }
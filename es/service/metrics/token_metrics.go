@@ -0,0 +1,28 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// Token rejections by token type and rule
+	metricTokenRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_token_rejections",
+			Help: "Number of tokens rejected by claim rules, partitioned by token type and reason.",
+		},
+		[]string{"token_type", "reason"},
+	)
+)
+
+func registerTokenMetrics() {
+	prometheus.MustRegister(
+		metricTokenRejections,
+	)
+}
+
+// reason is the claim rule the token failed, eg: issuer or expired
+func ReportTokenRejection(tokenType, reason string) {
+	metricTokenRejections.WithLabelValues(tokenType, reason).Inc()
+}
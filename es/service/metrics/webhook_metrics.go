@@ -0,0 +1,28 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// Webhook delivery attempts by result
+	metricWebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_webhook_deliveries",
+			Help: "Number of webhook delivery attempts, partitioned by result.",
+		},
+		[]string{"result"},
+	)
+)
+
+func registerWebhookMetrics() {
+	prometheus.MustRegister(
+		metricWebhookDeliveries,
+	)
+}
+
+// result is one of delivered, retry or failed
+func ReportWebhookDelivery(result string) {
+	metricWebhookDeliveries.WithLabelValues(result).Inc()
+}
@@ -6,9 +6,12 @@ package notification
 import (
 	"encoding/json"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/HPInc/krypton-es/es/service/metrics"
 	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/webhook"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -57,6 +60,9 @@ func getEnrollErrorMessage() (*structs.EnrollError, error) {
 }
 
 func processEnrollError(ee *structs.EnrollError) {
+	if id, err := uuid.Parse(ee.EnrollId); err == nil {
+		cache.PublishStatusProcessing(id)
+	}
 	if ee.Type == "enroll" || ee.Type == "renew_enroll" {
 		failEnrollRecord(ee)
 	} else if ee.Type == "unenroll" {
@@ -77,6 +83,7 @@ func failEnrollRecord(ee *structs.EnrollError) {
 		metrics.MetricNotificationEnrollErrorsFailed.Inc()
 	} else {
 		metrics.MetricNotificationEnrollErrors.Inc()
+		webhook.EnrollFailed(ee)
 	}
 }
 
@@ -89,6 +96,7 @@ func failUnenrollRecord(ee *structs.EnrollError) {
 		metrics.MetricNotificationUnenrollErrorsFailed.Inc()
 	} else {
 		metrics.MetricNotificationUnenrollErrors.Inc()
+		webhook.UnenrollFailed(ee)
 	}
 }
 
@@ -6,9 +6,11 @@ package notification
 import (
 	"encoding/json"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/HPInc/krypton-es/es/service/metrics"
 	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/webhook"
 	"go.uber.org/zap"
 )
 
@@ -82,12 +84,14 @@ func getEnrolledMessage() (*EnrollEnvelope, error) {
 // process enrolled message by updating the enroll record
 // as successful.
 func processEnrolled(ee *EnrollEnvelope) {
+	cache.PublishStatusProcessing(ee.EnrollResult.EnrollId)
 	err := db.UpdateEnrollRecord(&ee.EnrollResult)
 	if err != nil {
 		esLogger.Error("could not update enroll record", zap.Error(err))
 		metrics.MetricNotificationEnrollsFailed.Inc()
 	} else {
 		metrics.MetricNotificationEnrolls.Inc()
+		webhook.EnrollCompleted(ee.EnrollResult.EnrollId)
 	}
 	deleteEnrolledQueueEntry(ee)
 }
@@ -39,6 +39,8 @@ var (
 const (
 	awsOperationTimeout     = time.Second * 5
 	awsSqsVisibilityTimeout = 60
+	// most messages sqs accepts in a send batch
+	maxSendBatchSize = 10
 )
 
 func Init(logger *zap.Logger, settings *config.Notification) error {
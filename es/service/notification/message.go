@@ -6,7 +6,9 @@ package notification
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"go.uber.org/zap"
@@ -52,6 +54,53 @@ func SendMessage(msg string) (*sqs.SendMessageOutput, error) {
 	return output, nil
 }
 
+// send messages to the pending enroll queue in batches of
+// maxSendBatchSize. returns an error for every message, nil if sent.
+// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessageBatch.html
+func SendMessageBatch(msgs []string) []error {
+	errs := make([]error, len(msgs))
+	for start := 0; start < len(msgs); start += maxSendBatchSize {
+		end := min(start+maxSendBatchSize, len(msgs))
+		sendMessageBatch(msgs[start:end], errs[start:end])
+	}
+	return errs
+}
+
+func sendMessageBatch(msgs []string, errs []error) {
+	entries := make([]types.SendMessageBatchRequestEntry, len(msgs))
+	for i := range msgs {
+		entries[i] = types.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(i)),
+			MessageBody: &msgs[i],
+		}
+	}
+	ctx, cancelFunc := context.WithTimeout(gCtx, awsOperationTimeout)
+	defer cancelFunc()
+	output, err := gSQS.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: &pendingEnrollQueueUrl,
+		Entries:  entries,
+	})
+	if err != nil {
+		err = fmt.Errorf("could not send message batch to queue %v: %v",
+			pendingEnrollQueueUrl, err)
+		for i := range errs {
+			errs[i] = err
+		}
+		return
+	}
+	for _, f := range output.Failed {
+		i, err := strconv.Atoi(aws.ToString(f.Id))
+		if err != nil || i < 0 || i >= len(errs) {
+			esLogger.Error("Unknown id in send message batch result",
+				zap.String("id", aws.ToString(f.Id)))
+			continue
+		}
+		errs[i] = fmt.Errorf("could not send message to queue %v: %s %s",
+			pendingEnrollQueueUrl, aws.ToString(f.Code),
+			aws.ToString(f.Message))
+	}
+}
+
 func deleteMessage(queueUrl, receiptHandle string) error {
 	ctx, cancelFunc := context.WithTimeout(gCtx, awsOperationTimeout)
 	defer cancelFunc()
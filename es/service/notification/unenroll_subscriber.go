@@ -4,8 +4,10 @@
 package notification
 
 import (
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/webhook"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +19,7 @@ const (
 // as successful.
 func processUnenrolled(ee *EnrollEnvelope) {
 	r := &ee.UnenrollResult
+	cache.PublishStatusProcessing(r.UnenrollId)
 	err := db.UpdateUnenrollRecord(r)
 	if err != nil {
 		esLogger.Error("could not update unenroll record",
@@ -28,6 +31,7 @@ func processUnenrolled(ee *EnrollEnvelope) {
 		metrics.MetricNotificationUnenrollsFailed.Inc()
 	} else {
 		metrics.MetricNotificationUnenrolls.Inc()
+		webhook.UnenrollCompleted(r.UnenrollId)
 	}
 	deleteUnenrolledQueueEntry(ee)
 }
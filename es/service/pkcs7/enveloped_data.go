@@ -0,0 +1,259 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+const (
+	// key transport recipients identified by issuer and serial number
+	envelopedDataVersion = 0
+	aes256KeySize        = 32
+)
+
+var (
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+
+	oidDesEde3Cbc = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAes128Cbc  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAes192Cbc  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAes256Cbc  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	ErrNotEnvelopedData       = errors.New("content is not pkcs7 enveloped data")
+	ErrRecipientNotFound      = errors.New("no recipient info for certificate")
+	ErrUnsupportedCipher      = errors.New("unsupported content encryption algorithm")
+	ErrUnsupportedRecipient   = errors.New("recipient public key must be rsa")
+	ErrInvalidEncryptedData   = errors.New("encrypted content is invalid")
+	ErrUnsupportedKeyTransfer = errors.New("unsupported key encryption algorithm")
+)
+
+type recipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerialNumber
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []recipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+// decrypt enveloped data for the recipient with cert and key
+func Decrypt(der []byte, cert *x509.Certificate, key *rsa.PrivateKey) (
+	[]byte, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotEnvelopedData, err)
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailingData
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		return nil, ErrNotEnvelopedData
+	}
+	var ed envelopedData
+	if _, err = asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotEnvelopedData, err)
+	}
+
+	var ri *recipientInfo
+	for i, r := range ed.RecipientInfos {
+		ias := r.IssuerAndSerialNumber
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) &&
+			cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			ri = &ed.RecipientInfos[i]
+			break
+		}
+	}
+	if ri == nil {
+		return nil, ErrRecipientNotFound
+	}
+	if !ri.KeyEncryptionAlgorithm.Algorithm.Equal(oidRsaEncryption) {
+		return nil, ErrUnsupportedKeyTransfer
+	}
+
+	eci := ed.EncryptedContentInfo
+	keySize, err := getContentKeySize(eci.ContentEncryptionAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	// a key with invalid padding is replaced by a random one, so the
+	// content fails to decrypt instead of revealing a padding oracle
+	contentKey := make([]byte, keySize)
+	if _, err = rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	if err = rsa.DecryptPKCS1v15SessionKey(rand.Reader, key, ri.EncryptedKey,
+		contentKey); err != nil {
+		return nil, err
+	}
+	block, err := newBlockCipher(
+		eci.ContentEncryptionAlgorithm.Algorithm, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if _, err = asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes,
+		&iv); err != nil || len(iv) != block.BlockSize() {
+		return nil, ErrInvalidEncryptedData
+	}
+	content := getEncryptedContent(eci.EncryptedContent)
+	if len(content) == 0 || len(content)%block.BlockSize() != 0 {
+		return nil, ErrInvalidEncryptedData
+	}
+	plain := make([]byte, len(content))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, content)
+	return unpad(plain, block.BlockSize())
+}
+
+// encrypt content for recipient with aes-256-cbc
+func Encrypt(content []byte, recipient *x509.Certificate) ([]byte, error) {
+	pub, ok := recipient.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedRecipient
+	}
+	contentKey := make([]byte, aes256KeySize)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, block.BlockSize())
+	if _, err = rand.Read(iv); err != nil {
+		return nil, err
+	}
+	padded := pad(content, block.BlockSize())
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, contentKey)
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	ed := envelopedData{
+		Version: envelopedDataVersion,
+		RecipientInfos: []recipientInfo{{
+			Version: envelopedDataVersion,
+			IssuerAndSerialNumber: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: recipient.RawIssuer},
+				SerialNumber: recipient.SerialNumber,
+			},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidRsaEncryption,
+				Parameters: asn1.NullRawValue,
+			},
+			EncryptedKey: encryptedKey,
+		}},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAes256Cbc,
+				Parameters: asn1.RawValue{FullBytes: ivParam},
+			},
+			EncryptedContent: asn1.RawValue{
+				Class: asn1.ClassContextSpecific,
+				Tag:   0,
+				Bytes: encrypted,
+			},
+		},
+	}
+	data, err := asn1.Marshal(ed)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     explicitTag(data),
+	})
+}
+
+// des-ede3 is still sent by legacy scep clients
+func newBlockCipher(oid asn1.ObjectIdentifier, key []byte) (
+	cipher.Block, error) {
+	switch {
+	case oid.Equal(oidAes128Cbc), oid.Equal(oidAes192Cbc),
+		oid.Equal(oidAes256Cbc):
+		return aes.NewCipher(key)
+	case oid.Equal(oidDesEde3Cbc):
+		return des.NewTripleDESCipher(key)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedCipher, oid)
+}
+
+// key size of a supported content cipher
+func getContentKeySize(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(oidAes128Cbc):
+		return 16, nil
+	case oid.Equal(oidAes192Cbc), oid.Equal(oidDesEde3Cbc):
+		return 24, nil
+	case oid.Equal(oidAes256Cbc):
+		return aes256KeySize, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedCipher, oid)
+}
+
+// encrypted content is a primitive octet string or, in ber,
+// a constructed one made of primitive segments
+func getEncryptedContent(v asn1.RawValue) []byte {
+	if !v.IsCompound {
+		return v.Bytes
+	}
+	var content []byte
+	data := v.Bytes
+	for len(data) > 0 {
+		var segment []byte
+		var err error
+		if data, err = asn1.Unmarshal(data, &segment); err != nil {
+			return nil
+		}
+		content = append(content, segment...)
+	}
+	return content
+}
+
+// pkcs#7 padding
+func pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, ErrInvalidEncryptedData
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrInvalidEncryptedData
+		}
+	}
+	return data[:len(data)-n], nil
+}
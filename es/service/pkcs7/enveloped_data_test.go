@@ -0,0 +1,88 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package pkcs7
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	cert, key := newTestRsaCertificate(t)
+	// block aligned content still gets a full block of padding
+	for _, content := range []string{"content", "0123456789abcdef"} {
+		der, err := Encrypt([]byte(content), cert)
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		plain, err := Decrypt(der, cert, key)
+		if err != nil {
+			t.Fatalf("Failed to decrypt: %v", err)
+		}
+		if string(plain) != content {
+			t.Errorf("Expected %q, found: %q", content, plain)
+		}
+	}
+}
+
+func TestDecryptForOtherRecipientFails(t *testing.T) {
+	cert, _ := newTestRsaCertificate(t)
+	other, otherKey := newTestRsaCertificate(t)
+	der, err := Encrypt([]byte("content"), cert)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if _, err = Decrypt(der, other, otherKey); !errors.Is(err, ErrRecipientNotFound) {
+		t.Errorf("Expected %v, found: %v", ErrRecipientNotFound, err)
+	}
+}
+
+func TestEncryptForEcdsaRecipientFails(t *testing.T) {
+	cert := newTestCertificate(t, "ecdsa")
+	if _, err := Encrypt([]byte("content"), cert); !errors.Is(err, ErrUnsupportedRecipient) {
+		t.Errorf("Expected %v, found: %v", ErrUnsupportedRecipient, err)
+	}
+}
+
+// a content key of the wrong size is replaced by a random key, so
+// the rsa padding check does not fail on its own
+func TestDecryptWithInvalidContentKeyFails(t *testing.T) {
+	cert, key := newTestRsaCertificate(t)
+	der, err := Encrypt([]byte("content"), cert)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	var ci contentInfo
+	if _, err = asn1.Unmarshal(der, &ci); err != nil {
+		t.Fatalf("Failed to parse content info: %v", err)
+	}
+	var ed envelopedData
+	if _, err = asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		t.Fatalf("Failed to parse enveloped data: %v", err)
+	}
+	ed.RecipientInfos[0].EncryptedKey, err = rsa.EncryptPKCS1v15(rand.Reader,
+		cert.PublicKey.(*rsa.PublicKey), make([]byte, 16))
+	if err != nil {
+		t.Fatalf("Failed to encrypt content key: %v", err)
+	}
+	data, err := asn1.Marshal(ed)
+	if err != nil {
+		t.Fatalf("Failed to marshal enveloped data: %v", err)
+	}
+	der, err = asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     explicitTag(data),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal content info: %v", err)
+	}
+
+	plain, err := Decrypt(der, cert, key)
+	if errors.Is(err, rsa.ErrDecryption) || string(plain) == "content" {
+		t.Errorf("Expected content to not decrypt, found: %q (%v)", plain, err)
+	}
+}
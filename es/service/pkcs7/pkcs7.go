@@ -0,0 +1,113 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+// Package pkcs7 encodes and decodes the cms (RFC 5652) structures used
+// by EST and SCEP: degenerate "certs-only" signed data that carries
+// certificates (RFC 7030 4.1.3), and signed and enveloped data with
+// rsa keys for SCEP messages (RFC 8894 3).
+package pkcs7
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+const (
+	// signed data version without attribute certificates or other formats
+	signedDataVersion = 1
+)
+
+var (
+	oidData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+	ErrNoCertificates     = errors.New("no certificates to encode")
+	ErrNotSignedData      = errors.New("content is not pkcs7 signed data")
+	ErrTrailingData       = errors.New("trailing data after pkcs7 content")
+	ErrInvalidCertificate = errors.New("invalid certificate in pkcs7 content")
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      encapsulatedContentInfo
+	// [0] IMPLICIT SET OF Certificate
+	Certificates asn1.RawValue   `asn1:"optional,tag:0"`
+	SignerInfos  []asn1.RawValue `asn1:"set"`
+}
+
+// encode certificates as der certs-only signed data
+func DegenerateCertificates(certs []*x509.Certificate) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, ErrNoCertificates
+	}
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	sd := signedData{
+		Version:          signedDataVersion,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{},
+		ContentInfo:      encapsulatedContentInfo{ContentType: oidData},
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      raw,
+		},
+		SignerInfos: []asn1.RawValue{},
+	}
+	content, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		// raw values are written as is. add the explicit tag here.
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      content,
+		},
+	})
+}
+
+// decode certificates from der signed data. signers are not verified.
+func ParseCertificates(der []byte) ([]*x509.Certificate, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailingData
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, ErrNotSignedData
+	}
+	var sd signedData
+	if _, err = asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+	}
+	if len(sd.Certificates.Bytes) == 0 {
+		return nil, nil
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	return certs, nil
+}
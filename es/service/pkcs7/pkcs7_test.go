@@ -0,0 +1,88 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package pkcs7
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDegenerateCertificatesRoundTrip(t *testing.T) {
+	certs := []*x509.Certificate{
+		newTestCertificate(t, "device"),
+		newTestCertificate(t, "issuing ca"),
+	}
+	der, err := DegenerateCertificates(certs)
+	if err != nil {
+		t.Fatalf("Failed to encode certificates: %v", err)
+	}
+	parsed, err := ParseCertificates(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificates: %v", err)
+	}
+	if len(parsed) != len(certs) {
+		t.Fatalf("Expected %d certificates, found: %d", len(certs), len(parsed))
+	}
+	for i := range certs {
+		if !parsed[i].Equal(certs[i]) {
+			t.Errorf("%d: certificate does not match", i)
+		}
+	}
+}
+
+func TestDegenerateCertificatesWithoutCertificatesFails(t *testing.T) {
+	if _, err := DegenerateCertificates(nil); !errors.Is(err, ErrNoCertificates) {
+		t.Errorf("Expected %v, found: %v", ErrNoCertificates, err)
+	}
+}
+
+func TestParseCertificatesInvalidContentFails(t *testing.T) {
+	der, err := DegenerateCertificates(
+		[]*x509.Certificate{newTestCertificate(t, "device")})
+	if err != nil {
+		t.Fatalf("Failed to encode certificates: %v", err)
+	}
+	tests := []struct {
+		der []byte
+		err error
+	}{
+		{[]byte("not der"), ErrNotSignedData},
+		{append(der, 0), ErrTrailingData},
+	}
+	for i, test := range tests {
+		if _, err := ParseCertificates(test.der); !errors.Is(err, test.err) {
+			t.Errorf("%d: expected %v, found: %v", i, test.err, err)
+		}
+	}
+}
+
+func newTestCertificate(t *testing.T, cn string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
@@ -0,0 +1,371 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var (
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+
+	oidRsaEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSha1WithRsa   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSha256WithRsa = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSha384WithRsa = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSha512WithRsa = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+
+	oidSha1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSha256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSha384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSha512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	// sha-1 is still sent by legacy scep clients
+	digestAlgorithms = []struct {
+		oid  asn1.ObjectIdentifier
+		hash crypto.Hash
+	}{
+		{oidSha1, crypto.SHA1},
+		{oidSha256, crypto.SHA256},
+		{oidSha384, crypto.SHA384},
+		{oidSha512, crypto.SHA512},
+	}
+
+	ErrNoSigner                   = errors.New("signed data has no signer")
+	ErrSignerNotFound             = errors.New("signer certificate not found")
+	ErrUnsupportedDigest          = errors.New("unsupported digest algorithm")
+	ErrUnsupportedSignature       = errors.New("unsupported signature algorithm")
+	ErrMessageDigestMismatch      = errors.New("message digest does not match content")
+	ErrAttributeNotFound          = errors.New("attribute not found")
+	ErrMissingSignedAttributes    = errors.New("signer has no signed attributes")
+	ErrUnsupportedSignerPublicKey = errors.New("signer public key must be rsa")
+)
+
+// attribute of a signer. value is marshalled as the single value of the set.
+type Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value interface{}
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type signedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedDataWithSigners struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      signedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+// parsed signed data with a single signer
+type SignedData struct {
+	// encapsulated content. nil if there is none.
+	Content      []byte
+	Certificates []*x509.Certificate
+	// signer certificate from certificates
+	Signer *x509.Certificate
+
+	signerInfo signerInfo
+	attributes []attribute
+}
+
+// parse der signed data. call Verify before trusting content.
+func ParseSignedData(der []byte) (*SignedData, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailingData
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, ErrNotSignedData
+	}
+	var sd signedDataWithSigners
+	if _, err = asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, ErrNoSigner
+	}
+	p := SignedData{signerInfo: sd.SignerInfos[0]}
+	if len(sd.ContentInfo.Content.Bytes) > 0 {
+		if _, err = asn1.Unmarshal(sd.ContentInfo.Content.Bytes,
+			&p.Content); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+		}
+	}
+	if len(sd.Certificates.Bytes) > 0 {
+		p.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+	}
+	if p.attributes, err = parseAttributes(
+		p.signerInfo.AuthenticatedAttributes.Bytes); err != nil {
+		return nil, err
+	}
+	ias := p.signerInfo.IssuerAndSerialNumber
+	for _, c := range p.Certificates {
+		if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) &&
+			c.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			p.Signer = c
+			break
+		}
+	}
+	if p.Signer == nil {
+		return nil, ErrSignerNotFound
+	}
+	return &p, nil
+}
+
+// verify signature of signer over signed attributes and the
+// message digest of content. the signer certificate is not verified.
+func (sd *SignedData) Verify() error {
+	si := sd.signerInfo
+	if len(si.AuthenticatedAttributes.Bytes) == 0 {
+		return ErrMissingSignedAttributes
+	}
+	hash, err := getHash(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	var digest []byte
+	if err = sd.GetAttribute(oidAttributeMessageDigest, &digest); err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(sd.Content)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return ErrMessageDigestMismatch
+	}
+	if !isRsaSignature(si.DigestEncryptionAlgorithm.Algorithm) {
+		return ErrUnsupportedSignature
+	}
+	pub, ok := sd.Signer.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return ErrUnsupportedSignerPublicKey
+	}
+	// signature is over the attributes with a set tag
+	signed, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSet,
+		IsCompound: true,
+		Bytes:      si.AuthenticatedAttributes.Bytes,
+	})
+	if err != nil {
+		return err
+	}
+	h = hash.New()
+	h.Write(signed)
+	return rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), si.EncryptedDigest)
+}
+
+// unmarshal the value of a signed attribute
+func (sd *SignedData) GetAttribute(oid asn1.ObjectIdentifier,
+	out interface{}) error {
+	for _, a := range sd.attributes {
+		if !a.Type.Equal(oid) {
+			continue
+		}
+		if _, err := asn1.Unmarshal(a.Values.Bytes, out); err != nil {
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrAttributeNotFound, oid)
+}
+
+// sign content with sha-256 and rsa. content type, signing time and
+// message digest attributes are added to attributes.
+func Sign(content []byte, attributes []Attribute, cert *x509.Certificate,
+	key *rsa.PrivateKey) ([]byte, error) {
+	digest := crypto.SHA256.New()
+	digest.Write(content)
+	attributes = append([]Attribute{
+		{oidAttributeContentType, oidData},
+		{oidAttributeSigningTime, time.Now().UTC()},
+		{oidAttributeMessageDigest, digest.Sum(nil)},
+	}, attributes...)
+	signedAttributes, err := marshalAttributes(attributes)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSet,
+		IsCompound: true,
+		Bytes:      signedAttributes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := crypto.SHA256.New()
+	h.Write(signed)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256,
+		h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{
+		Algorithm:  oidSha256,
+		Parameters: asn1.NullRawValue,
+	}
+	sd := signedDataWithSigners{
+		Version:          signedDataVersion,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		ContentInfo:      signedContentInfo{ContentType: oidData},
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      cert.Raw,
+		},
+		SignerInfos: []signerInfo{{
+			Version: signedDataVersion,
+			IssuerAndSerialNumber: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+				SerialNumber: cert.SerialNumber,
+			},
+			DigestAlgorithm: sha256Algorithm,
+			AuthenticatedAttributes: asn1.RawValue{
+				Class:      asn1.ClassContextSpecific,
+				Tag:        0,
+				IsCompound: true,
+				Bytes:      signedAttributes,
+			},
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidRsaEncryption,
+				Parameters: asn1.NullRawValue,
+			},
+			EncryptedDigest: signature,
+		}},
+	}
+	if len(content) > 0 {
+		octets, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		sd.ContentInfo.Content = explicitTag(octets)
+	}
+	data, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     explicitTag(data),
+	})
+}
+
+// raw values are written as is. wrap in an explicit [0] tag.
+func explicitTag(der []byte) asn1.RawValue {
+	return asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        0,
+		IsCompound: true,
+		Bytes:      der,
+	}
+}
+
+func parseAttributes(data []byte) ([]attribute, error) {
+	var attributes []attribute
+	for len(data) > 0 {
+		var a attribute
+		var err error
+		if data, err = asn1.Unmarshal(data, &a); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotSignedData, err)
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes, nil
+}
+
+// der encoding of a set of attributes. der sorts set members.
+func marshalAttributes(attributes []Attribute) ([]byte, error) {
+	encoded := make([][]byte, 0, len(attributes))
+	for _, a := range attributes {
+		value, err := asn1.Marshal(a.Value)
+		if err != nil {
+			return nil, err
+		}
+		b, err := asn1.Marshal(attribute{
+			Type: a.Type,
+			Values: asn1.RawValue{
+				Class:      asn1.ClassUniversal,
+				Tag:        asn1.TagSet,
+				IsCompound: true,
+				Bytes:      value,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, b)
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	return bytes.Join(encoded, nil), nil
+}
+
+func getHash(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for _, d := range digestAlgorithms {
+		if d.oid.Equal(oid) {
+			return d.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedDigest, oid)
+}
+
+// signers may name the signature or only the key algorithm
+func isRsaSignature(oid asn1.ObjectIdentifier) bool {
+	for _, o := range []asn1.ObjectIdentifier{oidRsaEncryption,
+		oidSha1WithRsa, oidSha256WithRsa, oidSha384WithRsa,
+		oidSha512WithRsa} {
+		if o.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package pkcs7
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var oidTestAttribute = asn1.ObjectIdentifier{1, 2, 3, 4}
+
+func TestSignAndVerify(t *testing.T) {
+	cert, key := newTestRsaCertificate(t)
+	for _, content := range [][]byte{[]byte("content"), nil} {
+		der, err := Sign(content, []Attribute{{oidTestAttribute, "value"}},
+			cert, key)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		sd, err := ParseSignedData(der)
+		if err != nil {
+			t.Fatalf("Failed to parse signed data: %v", err)
+		}
+		if err = sd.Verify(); err != nil {
+			t.Errorf("Expected signature to verify, found: %v", err)
+		}
+		if string(sd.Content) != string(content) {
+			t.Errorf("Expected content %q, found: %q", content, sd.Content)
+		}
+		if !sd.Signer.Equal(cert) {
+			t.Errorf("Expected signer certificate")
+		}
+		var value string
+		if err = sd.GetAttribute(oidTestAttribute, &value); err != nil ||
+			value != "value" {
+			t.Errorf("Expected attribute value, found: %q, %v", value, err)
+		}
+		err = sd.GetAttribute(asn1.ObjectIdentifier{1, 2, 3, 5}, &value)
+		if !errors.Is(err, ErrAttributeNotFound) {
+			t.Errorf("Expected %v, found: %v", ErrAttributeNotFound, err)
+		}
+	}
+}
+
+func TestVerifyTamperedContentFails(t *testing.T) {
+	cert, key := newTestRsaCertificate(t)
+	der, err := Sign([]byte("content"), nil, cert, key)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	sd, err := ParseSignedData(der)
+	if err != nil {
+		t.Fatalf("Failed to parse signed data: %v", err)
+	}
+	sd.Content = []byte("tampered")
+	if err = sd.Verify(); !errors.Is(err, ErrMessageDigestMismatch) {
+		t.Errorf("Expected %v, found: %v", ErrMessageDigestMismatch, err)
+	}
+}
+
+func newTestRsaCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test rsa"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
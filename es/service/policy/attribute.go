@@ -4,7 +4,9 @@
 package policy
 
 import (
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 // look up attribute value, convert to int32 and return
@@ -18,3 +20,27 @@ func (p *Policy) GetAttributeInt(a PolicyAttribute) (int, error) {
 	}
 	return 0, ErrPolicyUnknownAttribute
 }
+
+// look up comma separated attribute value. ok is false if not present.
+func (p *Policy) GetAttributeList(a PolicyAttribute) (list []string, ok bool) {
+	val, ok := p.Attributes[a]
+	if !ok {
+		return nil, false
+	}
+	for _, v := range strings.Split(val, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list, true
+}
+
+// look up attribute value and compile it as a pattern for whole values.
+// returns nil if not present.
+func (p *Policy) GetAttributePattern(a PolicyAttribute) (*regexp.Regexp, error) {
+	val, ok := p.Attributes[a]
+	if !ok {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + val + ")$")
+}
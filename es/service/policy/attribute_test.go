@@ -0,0 +1,91 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGetAttributeInt(t *testing.T) {
+	p := &Policy{Attributes: map[PolicyAttribute]string{
+		BulkEnrollTokenLifetimeDays: "30",
+		MinRsaKeyBits:               "many",
+	}}
+	if i, err := p.GetAttributeInt(BulkEnrollTokenLifetimeDays); err != nil ||
+		i != 30 {
+		t.Errorf("Expected 30, found: %d, %v", i, err)
+	}
+	if _, err := p.GetAttributeInt(MinRsaKeyBits); err == nil {
+		t.Errorf("Expected error for a value that is not a number")
+	}
+	if _, err := p.GetAttributeInt(AllowedCurves); !errors.Is(err,
+		ErrPolicyUnknownAttribute) {
+		t.Errorf("Expected %v, found: %v", ErrPolicyUnknownAttribute, err)
+	}
+}
+
+func TestGetAttributeList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *string
+		list  []string
+		ok    bool
+	}{
+		{"missing", nil, nil, false},
+		{"single", ptr("RSA"), []string{"RSA"}, true},
+		{"spaces and empty items", ptr(" RSA, ,ECDSA ,"),
+			[]string{"RSA", "ECDSA"}, true},
+		// present but empty allows nothing
+		{"empty", ptr(""), nil, true},
+	}
+	for _, test := range tests {
+		p := &Policy{Attributes: map[PolicyAttribute]string{}}
+		if test.value != nil {
+			p.Attributes[AllowedKeyAlgorithms] = *test.value
+		}
+		list, ok := p.GetAttributeList(AllowedKeyAlgorithms)
+		if ok != test.ok || !slices.Equal(list, test.list) {
+			t.Errorf("%s: expected %q, %t, found: %q, %t", test.name,
+				test.list, test.ok, list, ok)
+		}
+	}
+}
+
+func TestGetAttributePattern(t *testing.T) {
+	p := &Policy{Attributes: map[PolicyAttribute]string{
+		SubjectPattern:        `device|host-\d+`,
+		SubjectAltNamePattern: "(",
+	}}
+	re, err := p.GetAttributePattern(SubjectPattern)
+	handleError(t, err)
+	// alternatives are anchored as a group
+	tests := []struct {
+		value string
+		match bool
+	}{
+		{"device", true},
+		{"host-12", true},
+		{"my-device", false},
+		{"host-12.example.com", false},
+	}
+	for _, test := range tests {
+		if re.MatchString(test.value) != test.match {
+			t.Errorf("%s: expected match %t", test.value, test.match)
+		}
+	}
+
+	if _, err = p.GetAttributePattern(SubjectAltNamePattern); err == nil {
+		t.Errorf("Expected error for an invalid pattern")
+	}
+	if re, err = p.GetAttributePattern(AllowedCurves); re != nil || err != nil {
+		t.Errorf("Expected no pattern for a missing attribute, found: %v, %v",
+			re, err)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// check csr against csr requirements in policy. the error names the
+// rule that failed. policies with malformed values fail with
+// ErrInvalidPolicy.
+func (p *Policy) CheckCSR(cr *x509.CertificateRequest) error {
+	algorithm := cr.PublicKeyAlgorithm.String()
+	if allowed, ok := p.GetAttributeList(AllowedKeyAlgorithms); ok &&
+		!containsFold(allowed, algorithm) {
+		return fmt.Errorf("%w: %s. %s is %s", ErrCsrKeyAlgorithmNotAllowed,
+			algorithm, AllowedKeyAlgorithms, p.Attributes[AllowedKeyAlgorithms])
+	}
+
+	switch key := cr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if _, ok := p.Attributes[MinRsaKeyBits]; ok {
+			minBits, err := p.GetAttributeInt(MinRsaKeyBits)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidPolicy, MinRsaKeyBits)
+			}
+			if key.N.BitLen() < minBits {
+				return fmt.Errorf("%w: %d bits. %s is %d", ErrCsrRsaKeyTooSmall,
+					key.N.BitLen(), MinRsaKeyBits, minBits)
+			}
+		}
+	case *ecdsa.PublicKey:
+		curve := key.Curve.Params().Name
+		if allowed, ok := p.GetAttributeList(AllowedCurves); ok &&
+			!containsFold(allowed, curve) {
+			return fmt.Errorf("%w: %s. %s is %s", ErrCsrCurveNotAllowed,
+				curve, AllowedCurves, p.Attributes[AllowedCurves])
+		}
+	}
+
+	re, err := p.GetAttributePattern(SubjectPattern)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, SubjectPattern)
+	}
+	if re != nil && !re.MatchString(cr.Subject.CommonName) {
+		return fmt.Errorf("%w: common name %q. %s is %s",
+			ErrCsrSubjectNotAllowed, cr.Subject.CommonName, SubjectPattern,
+			p.Attributes[SubjectPattern])
+	}
+
+	re, err = p.GetAttributePattern(SubjectAltNamePattern)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, SubjectAltNamePattern)
+	}
+	if re != nil {
+		if san, ok := findMismatch(re, getSubjectAltNames(cr)); !ok {
+			return fmt.Errorf("%w: %q. %s is %s",
+				ErrCsrSubjectAltNameNotAllowed, san, SubjectAltNamePattern,
+				p.Attributes[SubjectAltNamePattern])
+		}
+	}
+	return nil
+}
+
+// true if policy requires tpm attestation of csr keys
+func (p *Policy) RequiresAttestation() (bool, error) {
+	val, ok := p.Attributes[RequireAttestation]
+	if !ok {
+		return false, nil
+	}
+	required, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrInvalidPolicy, RequireAttestation)
+	}
+	return required, nil
+}
+
+// validate csr requirement attributes
+func (p *Policy) validateCSRAttributes() error {
+	if list, ok := p.GetAttributeList(AllowedKeyAlgorithms); ok {
+		for _, a := range list {
+			if !containsFold(supportedKeyAlgorithms, a) {
+				return fmt.Errorf("%s: unknown key algorithm %s",
+					AllowedKeyAlgorithms, a)
+			}
+		}
+	}
+	if _, ok := p.Attributes[MinRsaKeyBits]; ok {
+		if bits, err := p.GetAttributeInt(MinRsaKeyBits); err != nil || bits < 0 {
+			return fmt.Errorf("%s must be a number of bits", MinRsaKeyBits)
+		}
+	}
+	if list, ok := p.GetAttributeList(AllowedCurves); ok {
+		for _, c := range list {
+			if !containsFold(supportedCurves, c) {
+				return fmt.Errorf("%s: unknown curve %s", AllowedCurves, c)
+			}
+		}
+	}
+	for _, a := range []PolicyAttribute{SubjectPattern, SubjectAltNamePattern} {
+		if _, err := p.GetAttributePattern(a); err != nil {
+			return fmt.Errorf("%s: %v", a, err)
+		}
+	}
+	if _, err := p.RequiresAttestation(); err != nil {
+		return fmt.Errorf("%s must be true or false", RequireAttestation)
+	}
+	return nil
+}
+
+var (
+	supportedKeyAlgorithms = []string{
+		x509.RSA.String(),
+		x509.ECDSA.String(),
+	}
+	supportedCurves = []string{"P-256", "P-384", "P-521"}
+)
+
+func getSubjectAltNames(cr *x509.CertificateRequest) []string {
+	sans := slices.Clone(cr.DNSNames)
+	sans = append(sans, cr.EmailAddresses...)
+	for _, ip := range cr.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cr.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+// first value that does not match. ok if all match.
+func findMismatch(re *regexp.Regexp, values []string) (string, bool) {
+	for _, v := range values {
+		if !re.MatchString(v) {
+			return v, false
+		}
+	}
+	return "", true
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(v string) bool {
+		return strings.EqualFold(v, s)
+	})
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testCommonName = "device-1.example.com"
+
+func TestMain(m *testing.M) {
+	esLogger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestCheckCSR(t *testing.T) {
+	rsa2048 := newTestRsaKey(t, 2048)
+	rsa3072 := newTestRsaKey(t, 3072)
+	p224 := newTestEcdsaKey(t, elliptic.P224())
+	p256 := newTestEcdsaKey(t, elliptic.P256())
+	p384 := newTestEcdsaKey(t, elliptic.P384())
+	sans := &x509.CertificateRequest{
+		DNSNames:       []string{"device-1.example.com"},
+		EmailAddresses: []string{"device-1@example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{{Scheme: "urn", Opaque: "device:1"}},
+	}
+
+	tests := []struct {
+		name       string
+		attributes map[PolicyAttribute]string
+		cr         *x509.CertificateRequest
+		err        error
+	}{
+		// a missing attribute does not restrict csrs
+		{"no requirements", nil,
+			newTestCSR(t, rsa2048, testCommonName, nil), nil},
+
+		{"key algorithm allowed",
+			map[PolicyAttribute]string{AllowedKeyAlgorithms: "rsa, ecdsa"},
+			newTestCSR(t, p256, testCommonName, nil), nil},
+		{"key algorithm not allowed",
+			map[PolicyAttribute]string{AllowedKeyAlgorithms: "ECDSA"},
+			newTestCSR(t, rsa3072, testCommonName, nil),
+			ErrCsrKeyAlgorithmNotAllowed},
+
+		{"rsa key at minimum",
+			map[PolicyAttribute]string{MinRsaKeyBits: "3072"},
+			newTestCSR(t, rsa3072, testCommonName, nil), nil},
+		{"rsa key below minimum",
+			map[PolicyAttribute]string{MinRsaKeyBits: "3072"},
+			newTestCSR(t, rsa2048, testCommonName, nil),
+			ErrCsrRsaKeyTooSmall},
+		// the rsa rule does not apply to ecdsa keys
+		{"ecdsa key with rsa minimum",
+			map[PolicyAttribute]string{MinRsaKeyBits: "3072"},
+			newTestCSR(t, p256, testCommonName, nil), nil},
+
+		{"ecdsa curve at minimum",
+			map[PolicyAttribute]string{AllowedCurves: "P-256,P-384"},
+			newTestCSR(t, p256, testCommonName, nil), nil},
+		{"ecdsa curve above minimum",
+			map[PolicyAttribute]string{AllowedCurves: "p-256,p-384"},
+			newTestCSR(t, p384, testCommonName, nil), nil},
+		{"ecdsa curve below minimum",
+			map[PolicyAttribute]string{AllowedCurves: "P-256,P-384"},
+			newTestCSR(t, p224, testCommonName, nil),
+			ErrCsrCurveNotAllowed},
+		{"ecdsa curve not allowed",
+			map[PolicyAttribute]string{AllowedCurves: "P-384"},
+			newTestCSR(t, p256, testCommonName, nil),
+			ErrCsrCurveNotAllowed},
+
+		{"subject matches",
+			map[PolicyAttribute]string{SubjectPattern: `device-\d+\.example\.com`},
+			newTestCSR(t, p256, testCommonName, nil), nil},
+		// patterns match the whole value
+		{"subject matches part",
+			map[PolicyAttribute]string{SubjectPattern: `device-\d+`},
+			newTestCSR(t, p256, testCommonName, nil),
+			ErrCsrSubjectNotAllowed},
+		{"subject missing",
+			map[PolicyAttribute]string{SubjectPattern: `device-\d+\.example\.com`},
+			newTestCSR(t, p256, "", nil),
+			ErrCsrSubjectNotAllowed},
+
+		{"subject alt names match",
+			map[PolicyAttribute]string{
+				SubjectAltNamePattern: `.*example\.com|10\.0\.0\.\d+|urn:device:\d+`},
+			newTestCSR(t, p256, testCommonName, sans), nil},
+		{"subject alt name does not match",
+			map[PolicyAttribute]string{
+				SubjectAltNamePattern: `.*example\.com|urn:device:\d+`},
+			newTestCSR(t, p256, testCommonName, sans),
+			ErrCsrSubjectAltNameNotAllowed},
+		// every subject alt name must match. none is not a mismatch.
+		{"no subject alt names",
+			map[PolicyAttribute]string{SubjectAltNamePattern: `.*example\.com`},
+			newTestCSR(t, p256, testCommonName, nil), nil},
+
+		{"all rules",
+			map[PolicyAttribute]string{
+				AllowedKeyAlgorithms:  "RSA",
+				MinRsaKeyBits:         "3072",
+				SubjectPattern:        `device-\d+\.example\.com`,
+				SubjectAltNamePattern: `.*example\.com|10\.0\.0\.\d+|urn:device:\d+`},
+			newTestCSR(t, rsa3072, testCommonName, sans), nil},
+
+		// policies with malformed values fail the check
+		{"invalid rsa minimum",
+			map[PolicyAttribute]string{MinRsaKeyBits: "many"},
+			newTestCSR(t, rsa2048, testCommonName, nil), ErrInvalidPolicy},
+		{"invalid subject pattern",
+			map[PolicyAttribute]string{SubjectPattern: "("},
+			newTestCSR(t, p256, testCommonName, nil), ErrInvalidPolicy},
+		{"invalid subject alt name pattern",
+			map[PolicyAttribute]string{SubjectAltNamePattern: "("},
+			newTestCSR(t, p256, testCommonName, nil), ErrInvalidPolicy},
+	}
+	for _, test := range tests {
+		p := &Policy{Version: defaultPolicyVersion, Attributes: test.attributes}
+		if err := p.CheckCSR(test.cr); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, found: %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestRequiresAttestation(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[PolicyAttribute]string
+		required   bool
+		err        error
+	}{
+		{"missing", nil, false, nil},
+		{"true", map[PolicyAttribute]string{RequireAttestation: "true"},
+			true, nil},
+		{"false", map[PolicyAttribute]string{RequireAttestation: "false"},
+			false, nil},
+		{"invalid", map[PolicyAttribute]string{RequireAttestation: "always"},
+			false, ErrInvalidPolicy},
+	}
+	for _, test := range tests {
+		p := &Policy{Version: defaultPolicyVersion, Attributes: test.attributes}
+		required, err := p.RequiresAttestation()
+		if !errors.Is(err, test.err) || required != test.required {
+			t.Errorf("%s: expected %t, %v, found: %t, %v", test.name,
+				test.required, test.err, required, err)
+		}
+	}
+}
+
+// csr signed by key. sans are copied from template if not nil.
+func newTestCSR(t *testing.T, key crypto.Signer, commonName string,
+	template *x509.CertificateRequest) *x509.CertificateRequest {
+	var cr x509.CertificateRequest
+	if template != nil {
+		cr = *template
+	}
+	cr.Subject = pkix.Name{CommonName: commonName}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &cr, key)
+	handleError(t, err)
+	parsed, err := x509.ParseCertificateRequest(der)
+	handleError(t, err)
+	return parsed
+}
+
+func newTestRsaKey(t *testing.T, bits int) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	handleError(t, err)
+	return key
+}
+
+func newTestEcdsaKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	handleError(t, err)
+	return key
+}
+
+func handleError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
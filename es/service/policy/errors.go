@@ -9,4 +9,11 @@ var (
 	ErrLoadDefaultPolicy      = errors.New("error loading default policy")
 	ErrInvalidPolicy          = errors.New("invalid policy")
 	ErrPolicyUnknownAttribute = errors.New("unknown policy attribute")
+
+	ErrCsrKeyAlgorithmNotAllowed   = errors.New("csr key algorithm is not allowed by policy")
+	ErrCsrRsaKeyTooSmall           = errors.New("csr rsa key is smaller than policy allows")
+	ErrCsrCurveNotAllowed          = errors.New("csr ecdsa curve is not allowed by policy")
+	ErrCsrSubjectNotAllowed        = errors.New("csr subject does not match policy")
+	ErrCsrSubjectAltNameNotAllowed = errors.New("csr subject alternative name does not match policy")
+	ErrAttestationRequired         = errors.New("tpm attestation of csr key is required by policy")
 )
@@ -7,6 +7,21 @@ type PolicyAttribute string
 
 const (
 	BulkEnrollTokenLifetimeDays PolicyAttribute = "BulkEnrollTokenLifetimeDays"
+
+	// csr requirements. a missing attribute does not restrict csrs.
+	// lists are comma separated. patterns are regular expressions that
+	// must match the whole value.
+	// RSA, ECDSA
+	AllowedKeyAlgorithms PolicyAttribute = "AllowedKeyAlgorithms"
+	MinRsaKeyBits        PolicyAttribute = "MinRsaKeyBits"
+	// P-256, P-384, P-521
+	AllowedCurves PolicyAttribute = "AllowedCurves"
+	// subject common name must match
+	SubjectPattern PolicyAttribute = "SubjectPattern"
+	// every dns, email, ip and uri subject alternative name must match
+	SubjectAltNamePattern PolicyAttribute = "SubjectAltNamePattern"
+	// true if enrolls must send tpm attestation evidence for the csr key
+	RequireAttestation PolicyAttribute = "RequireAttestation"
 )
 
 type PolicyConditionType string
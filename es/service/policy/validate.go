@@ -25,5 +25,10 @@ func (p *Policy) Validate() error {
 			zap.Int("version", p.Version))
 		return ErrInvalidPolicy
 	}
+	if err := p.validateCSRAttributes(); err != nil {
+		esLogger.Error("Policy csr requirements are not valid",
+			zap.Error(err))
+		return ErrInvalidPolicy
+	}
 	return nil
 }
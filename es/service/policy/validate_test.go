@@ -0,0 +1,78 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package policy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    int
+		attributes map[PolicyAttribute]string
+		err        error
+	}{
+		{"no attributes", defaultPolicyVersion, nil, nil},
+		{"all csr requirements", defaultPolicyVersion,
+			map[PolicyAttribute]string{
+				BulkEnrollTokenLifetimeDays: "30",
+				AllowedKeyAlgorithms:        "RSA, ecdsa",
+				MinRsaKeyBits:               "3072",
+				AllowedCurves:               "P-256,p-384,P-521",
+				SubjectPattern:              `device-\d+`,
+				SubjectAltNamePattern:       `.*\.example\.com`,
+				RequireAttestation:          "true",
+			}, nil},
+		{"missing version", 0, nil, ErrInvalidPolicy},
+		{"unknown version", defaultPolicyVersion + 1, nil, ErrInvalidPolicy},
+		{"unknown key algorithm", defaultPolicyVersion,
+			map[PolicyAttribute]string{AllowedKeyAlgorithms: "RSA,DSA"},
+			ErrInvalidPolicy},
+		{"rsa minimum not a number", defaultPolicyVersion,
+			map[PolicyAttribute]string{MinRsaKeyBits: "3k"},
+			ErrInvalidPolicy},
+		{"negative rsa minimum", defaultPolicyVersion,
+			map[PolicyAttribute]string{MinRsaKeyBits: "-1"},
+			ErrInvalidPolicy},
+		{"unknown curve", defaultPolicyVersion,
+			map[PolicyAttribute]string{AllowedCurves: "P-256,P-224"},
+			ErrInvalidPolicy},
+		{"invalid subject pattern", defaultPolicyVersion,
+			map[PolicyAttribute]string{SubjectPattern: "[a-"},
+			ErrInvalidPolicy},
+		{"invalid subject alt name pattern", defaultPolicyVersion,
+			map[PolicyAttribute]string{SubjectAltNamePattern: "*"},
+			ErrInvalidPolicy},
+		{"invalid require attestation", defaultPolicyVersion,
+			map[PolicyAttribute]string{RequireAttestation: "yes please"},
+			ErrInvalidPolicy},
+	}
+	for _, test := range tests {
+		p := &Policy{Version: test.version, Attributes: test.attributes}
+		if err := p.Validate(); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, found: %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestValidateBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"valid", `{"version":1,"attributes":{"MinRsaKeyBits":"2048"}}`, true},
+		{"invalid attribute", `{"version":1,"attributes":{"MinRsaKeyBits":"x"}}`,
+			false},
+		{"missing version", `{"attributes":{}}`, false},
+		{"not json", `version: 1`, false},
+	}
+	for _, test := range tests {
+		if valid := ValidateBytes([]byte(test.data)); valid != test.valid {
+			t.Errorf("%s: expected %t, found: %t", test.name, test.valid, valid)
+		}
+	}
+}
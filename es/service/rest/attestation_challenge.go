@@ -0,0 +1,113 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/attestation"
+	"go.uber.org/zap"
+)
+
+// an ek certificate and ak public area, base64
+const maxAttestationChallengeRequestSize = 8192
+
+/*
+/api/v1/attestation/challenge
+Credential activation challenge for a TPM AK without an AK certificate.
+1. Verifies the EK certificate against the trusted EK manufacturer roots
+2. Wraps a random secret for the AK with TPM2_MakeCredential
+The device recovers the secret with TPM2_ActivateCredential and sends it
+with the challenge, the EK certificate and the certify evidence in the
+attestation of its enroll.
+Requires:
+- Custom header: X-HP-Token-Type
+  - Value: "azuread" or "enrollment"
+  - Authorization header: Bearer <Token>
+  - Payload:
+    {
+    "ek_certificate":"<base64 der EK certificate>"
+    "ak_public":"<base64 TPMT_PUBLIC of the AK>"
+    }
+
+Returns:
+- 200
+  - challenge, credential blob and encrypted secret, base64. the
+    challenge expires in 5 minutes.
+
+Errors:
+- 400
+  - Malformed or missing Authorization header
+  - Malformed or missing payload
+  - ek roots are not configured
+  - EK certificate is not issued by a trusted tpm manufacturer or its
+    key is not rsa 2048 or ecc nist p-256
+  - AK is not a restricted tpm resident signing key
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 405
+  - Must be POST
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func GetAttestationChallenge(w http.ResponseWriter,
+	r *http.Request) *enrollError {
+	startTime := time.Now()
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	req, err := getAttestationChallengeRequest(r)
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	challenge, err := attestation.NewChallenge(req)
+	if err != nil {
+		esLogger.Error("Failed to create attestation challenge",
+			zap.String("tenant_id", ei.TenantId),
+			zap.Error(err))
+		// errors with a code are about the request
+		if _, ok := getErrorCode(err, http.StatusBadRequest); ok {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	if err = sendJsonResponse(w, http.StatusOK, challenge); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	esLogger.Info(
+		"AttestationChallenge",
+		zap.String("TenantID", ei.TenantId),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+func getAttestationChallengeRequest(r *http.Request) (
+	*attestation.ChallengeRequest, error) {
+	if r.ContentLength == 0 {
+		return nil, ErrPayloadMissing
+	}
+	defer r.Body.Close()
+
+	var req attestation.ChallengeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body,
+		maxAttestationChallengeRequestSize)).Decode(&req); err != nil {
+		return nil, ErrPayloadRead
+	}
+	return &req, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+const pemTypeCertificate = "CERTIFICATE"
+
+// ca certificates for EST and SCEP. loaded once at init.
+var caCerts []*x509.Certificate
+
+// certificates from the worker are base64 of what the ca returned.
+// that is pem or concatenated der.
+func parseStoredCertificates(s string) ([]*x509.Certificate, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		return x509.ParseCertificates(data)
+	}
+	return parsePemCertificates(data)
+}
+
+func parsePemCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != pemTypeCertificate {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, ErrNoCertificates
+	}
+	return certs, nil
+}
+
+// load ca certificates. an empty file name disables EST cacerts and
+// leaves only the ra certificate in SCEP GetCACert.
+func loadCACerts(file string) error {
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return err
+	}
+	certs, err := parsePemCertificates(data)
+	if err != nil {
+		return err
+	}
+	caCerts = certs
+	esLogger.Info("Loaded ca certificates",
+		zap.String("file", file),
+		zap.Int("count", len(certs)))
+	return nil
+}
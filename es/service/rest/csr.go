@@ -0,0 +1,124 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/HPInc/krypton-es/es/service/policy"
+	"go.uber.org/zap"
+)
+
+const (
+	minRsaKeySize = 2048
+	maxRsaKeySize = 8192
+)
+
+// ecdsa curves accepted by the ca
+var supportedCurves = map[string]bool{
+	"P-256": true,
+	"P-384": true,
+	"P-521": true,
+}
+
+// parse a base64 encoded der csr and check it before it is queued
+// for the worker. the ca would reject these much later.
+func parseCSR(csr string) (*x509.CertificateRequest, error) {
+	der, err := base64.StdEncoding.DecodeString(csr)
+	if err != nil {
+		return nil, ErrCsrEncoding
+	}
+	cr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCsr, err)
+	}
+	if err = cr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCsrSignature, err)
+	}
+	if err = checkCSRPublicKey(cr); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func checkCSRPublicKey(cr *x509.CertificateRequest) error {
+	switch key := cr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		size := key.N.BitLen()
+		if size < minRsaKeySize || size > maxRsaKeySize {
+			return fmt.Errorf("%w: rsa key is %d bits. must be %d to %d bits",
+				ErrUnsupportedCsrKeySize, size, minRsaKeySize, maxRsaKeySize)
+		}
+	case *ecdsa.PublicKey:
+		if curve := key.Curve.Params().Name; !supportedCurves[curve] {
+			return fmt.Errorf("%w: ecdsa curve %s", ErrUnsupportedCsrKeySize,
+				curve)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedCsrKey,
+			cr.PublicKeyAlgorithm)
+	}
+	return nil
+}
+
+// check csr against csr requirements in policy p of the tenant and
+// verify tpm attestation of the csr key if sent or required
+func checkCSRPolicy(p *policy.Policy, ep *enrollPayload) *enrollError {
+	err := p.CheckCSR(ep.csr)
+	if err == nil {
+		err = checkAttestation(p, ep)
+	}
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, policy.ErrInvalidPolicy) {
+		esLogger.Error("Tenant policy has invalid csr requirements",
+			zap.String("tenant_id", ep.TenantId),
+			zap.Error(err))
+		return &enrollError{ErrGetPolicy, http.StatusInternalServerError}
+	}
+	return &enrollError{err, http.StatusBadRequest}
+}
+
+// only a result verified here is stored with the enroll
+func checkAttestation(p *policy.Policy, ep *enrollPayload) error {
+	ep.AttestationResult = nil
+	if ep.Attestation == nil {
+		required, err := p.RequiresAttestation()
+		if err != nil {
+			return err
+		}
+		if required {
+			return policy.ErrAttestationRequired
+		}
+		return nil
+	}
+	result, err := ep.Attestation.Verify(ep.csr)
+	if err != nil {
+		esLogger.Error("Failed to verify tpm attestation",
+			zap.String("tenant_id", ep.TenantId),
+			zap.Error(err))
+		return err
+	}
+	ep.AttestationResult = result
+	return nil
+}
+
+// policy of tenant for csr checks
+func getCSRPolicy(tenantId string) (*policy.Policy, *enrollError) {
+	p, err := getPolicy(tenantId)
+	if err != nil {
+		esLogger.Error("Could not get tenant policy",
+			zap.String("tenant_id", tenantId),
+			zap.Error(err))
+		return nil, &enrollError{ErrGetPolicy, getHttpCodeForDbError(err)}
+	}
+	return p, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/attestation"
+	"github.com/HPInc/krypton-es/es/service/policy"
+)
+
+func TestParseCSR(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	smallEcKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	handleError(t, err)
+	smallRsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	handleError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	handleError(t, err)
+
+	// flip a bit in the signature
+	tampered, err := base64.StdEncoding.DecodeString(newTestCSR(t, ecKey))
+	handleError(t, err)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		csr string
+		err error
+	}{
+		{newTestCSR(t, ecKey), nil},
+		{"not base64!", ErrCsrEncoding},
+		{base64.StdEncoding.EncodeToString([]byte("csr")), ErrInvalidCsr},
+		{base64.StdEncoding.EncodeToString(tampered), ErrCsrSignature},
+		{newTestCSR(t, smallEcKey), ErrUnsupportedCsrKeySize},
+		{newTestCSR(t, smallRsaKey), ErrUnsupportedCsrKeySize},
+		{newTestCSR(t, edKey), ErrUnsupportedCsrKey},
+	}
+	for i, test := range tests {
+		_, err := parseCSR(test.csr)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d: expected %v, found: %v", i, test.err, err)
+		}
+	}
+}
+
+// hash is over the der of the csr, not its base64 text
+func TestLoadCSRHashIsOverDer(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	der, err := base64.StdEncoding.DecodeString(csr)
+	handleError(t, err)
+
+	ep := enrollPayload{CSR: csr}
+	handleError(t, ep.loadCSR())
+	expected := sha256.Sum256(der)
+	if ep.CSRHash != hex.EncodeToString(expected[:]) {
+		t.Errorf("Expected %x, found: %s", expected, ep.CSRHash)
+	}
+}
+
+func TestCheckCSRPolicy(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	handleError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	handleError(t, err)
+
+	tests := []struct {
+		attributes map[policy.PolicyAttribute]string
+		key        crypto.Signer
+		err        error
+	}{
+		{nil, ecKey, nil},
+		{map[policy.PolicyAttribute]string{
+			policy.AllowedKeyAlgorithms: "rsa"}, ecKey,
+			policy.ErrCsrKeyAlgorithmNotAllowed},
+		{map[policy.PolicyAttribute]string{
+			policy.AllowedCurves: "P-256"}, ecKey,
+			policy.ErrCsrCurveNotAllowed},
+		{map[policy.PolicyAttribute]string{
+			policy.AllowedCurves: "P-256, P-384"}, ecKey, nil},
+		{map[policy.PolicyAttribute]string{
+			policy.MinRsaKeyBits: "3072"}, rsaKey,
+			policy.ErrCsrRsaKeyTooSmall},
+		{map[policy.PolicyAttribute]string{
+			policy.MinRsaKeyBits: "3072"}, ecKey, nil},
+		{map[policy.PolicyAttribute]string{
+			policy.SubjectPattern: "device-.*"}, ecKey,
+			policy.ErrCsrSubjectNotAllowed},
+		{map[policy.PolicyAttribute]string{
+			policy.SubjectPattern: "test .*"}, ecKey, nil},
+		{map[policy.PolicyAttribute]string{
+			policy.SubjectAltNamePattern: `.*\.example\.com`}, ecKey,
+			policy.ErrCsrSubjectAltNameNotAllowed},
+		{map[policy.PolicyAttribute]string{
+			policy.RequireAttestation: "true"}, ecKey,
+			policy.ErrAttestationRequired},
+		{map[policy.PolicyAttribute]string{
+			policy.RequireAttestation: "false"}, ecKey, nil},
+	}
+	for i, test := range tests {
+		ep := enrollPayload{CSR: newTestCSR(t, test.key)}
+		handleError(t, ep.loadCSR())
+		p := &policy.Policy{Version: 1, Attributes: test.attributes}
+		eErr := checkCSRPolicy(p, &ep)
+		switch {
+		case test.err == nil && eErr != nil:
+			t.Errorf("%d: expected no error, found: %v", i, eErr.Error)
+		case test.err != nil && (eErr == nil || !errors.Is(eErr.Error, test.err) ||
+			eErr.Code != http.StatusBadRequest):
+			t.Errorf("%d: expected %v, found: %+v", i, test.err, eErr)
+		}
+	}
+}
+
+// evidence is rejected without trusted ak roots and a result sent by
+// the client is never kept
+func TestCheckCSRPolicyAttestation(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	ep := enrollPayload{
+		CSR:               newTestCSR(t, key),
+		AttestationResult: &attestation.Result{Manufacturer: "IFX"},
+	}
+	handleError(t, ep.loadCSR())
+	p := &policy.Policy{Version: 1}
+	if eErr := checkCSRPolicy(p, &ep); eErr != nil {
+		t.Errorf("Expected no error, found: %v", eErr.Error)
+	}
+	if ep.AttestationResult != nil {
+		t.Errorf("Expected client attestation result to be dropped")
+	}
+
+	ep.Attestation = &attestation.Evidence{}
+	eErr := checkCSRPolicy(p, &ep)
+	if eErr == nil || !errors.Is(eErr.Error, attestation.ErrNotConfigured) ||
+		eErr.Code != http.StatusBadRequest {
+		t.Errorf("Expected %v, found: %+v", attestation.ErrNotConfigured, eErr)
+	}
+}
+
+func newTestCSR(t *testing.T, key crypto.Signer) string {
+	der, err := x509.CreateCertificateRequest(rand.Reader,
+		&x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: "test device"},
+			DNSNames: []string{"device.test.local"},
+		}, key)
+	handleError(t, err)
+	return base64.StdEncoding.EncodeToString(der)
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type deviceHistoryResponse struct {
+	DeviceId uuid.UUID                     `json:"device_id"`
+	History  []*structs.DeviceHistoryEntry `json:"history"`
+}
+
+/*
+	Get /enroll/{device_id}/history
+	Get enroll, renew and unenroll history of a device in the
+	tenant of the bearer token. Entries are ordered oldest first.
+
+Returns:
+- 200
+  - {"device_id": <uuid>, "history": [...]}
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - device_id must be a uuid
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 404
+  - No history found for device in tenant
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func GetDeviceHistory(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+	requestID := r.Header.Get(headerRequestID)
+
+	if err := validateUserToken(r); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	deviceId, eErr := getUUIDParam(r, paramDeviceID)
+	if eErr != nil {
+		return eErr
+	}
+
+	history, err := db.GetDeviceHistory(ei.TenantId, deviceId)
+	if err != nil {
+		esLogger.Error("GetDeviceHistory: db error",
+			zap.String("Request ID:", requestID),
+			zap.String("TenantId", ei.TenantId),
+			zap.Error(err))
+		return &enrollError{ErrDeviceHistory, getHttpCodeForDbError(err)}
+	}
+	if len(history) == 0 {
+		return &enrollError{ErrNoDeviceHistory, http.StatusNotFound}
+	}
+
+	res := deviceHistoryResponse{DeviceId: deviceId, History: history}
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	esLogger.Info(
+		"GetDeviceHistory",
+		zap.String("TenantID", ei.TenantId),
+		zap.String("DeviceID", deviceId.String()),
+		zap.Int("Count", len(history)),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/google/uuid"
+)
+
+// device tokens cannot read device history
+func TestDeviceHistoryWithDeviceTokenFails(t *testing.T) {
+	info, deviceToken := getDeviceToken()
+	path := fmt.Sprintf("/api/v1/enroll/%s/history", info.deviceId)
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, deviceToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestDeviceHistoryForUnknownDeviceFails(t *testing.T) {
+	path := fmt.Sprintf("/api/v1/enroll/%s/history", uuid.New())
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestDeviceHistory(t *testing.T) {
+	bearerToken := getBearerToken()
+	tenantId := getTenantIdFromBearerToken(t, bearerToken)
+	deviceId := uuid.New()
+
+	_, err := db.RenewEnroll(tenantId, deviceId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+	_, err = db.Unenroll(tenantId, deviceId, "", nil)
+	handleError(t, err)
+
+	path := fmt.Sprintf("/api/v1/enroll/%s/history", deviceId)
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res deviceHistoryResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode history response: %v", err)
+	}
+	if len(res.History) != 2 {
+		t.Errorf("Expected 2 history entries, found: %d", len(res.History))
+	}
+}
@@ -5,14 +5,15 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/HPInc/krypton-es/es/service/notification"
 	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -35,6 +36,8 @@ Requires:
 - Custom header: X-HP-Token-Type
   - Value: "azuread" or "enrollment"
   - Authorization header: Bearer <Token>
+  - Optional header: Idempotency-Key. A repeat with the same key and
+    payload returns the original response.
   - Payload:
     {
     "csr":"<base64 encoded certificate signing request>"
@@ -49,6 +52,11 @@ Errors:
 - 400
   - Malformed or missing Authorization header
   - Malformed or missing payload
+  - csr is not a pkcs#10 request, its signature does not verify or
+    its key type or size is not supported (rsa 2048 - 8192, ecdsa p-256,
+    p-384, p-521)
+  - csr does not meet a csr requirement in tenant policy. the error
+    names the policy attribute
 
 - 401
   - Could not verify token
@@ -58,6 +66,12 @@ Errors:
 - 405
   - Must be POST
 
+- 409
+  - csr was used previously
+
+- 422
+  - Idempotency-Key was used with a different payload
+
 - 500
   - should not be here. yet, here we are.
 */
@@ -82,8 +96,31 @@ func Enroll(w http.ResponseWriter, r *http.Request) *enrollError {
 		return &enrollError{err, http.StatusBadRequest}
 	}
 
+	payload.TenantId = ei.TenantId
+	p, eErr := getCSRPolicy(ei.TenantId)
+	if eErr != nil {
+		return eErr
+	}
+	if eErr = checkCSRPolicy(p, payload); eErr != nil {
+		return eErr
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	// a repeat with the same Idempotency-Key gets the original response
+	ir, err := getIdempotentRequest(r, requestPayloadTypeEnroll, string(data))
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if ok, eErr := replayIdempotentRequest(w, db.IdempotencyKindEnroll,
+		ei.TenantId, ir, startTime); ok {
+		return eErr
+	}
+
 	// check if enroll request is already in db
-	hasCSRHash, err := db.HasCSRHash(payload.CSRHash)
+	hasCSRHash, err := payload.isEnrolledCSR()
 	if err != nil {
 		return &enrollError{ErrLookupCsr, getHttpCodeForDbError(err)}
 	}
@@ -92,8 +129,13 @@ func Enroll(w http.ResponseWriter, r *http.Request) *enrollError {
 		return &enrollError{ErrDuplicateCsr, http.StatusConflict}
 	}
 
-	de, err := db.CreateEnrollRecord(ei.TenantId, ei.UserId, payload.CSRHash)
+	de, err := db.CreateEnrollRecord(ei.TenantId, ei.UserId, payload.CSRHash,
+		string(data), ir)
 	if err != nil {
+		if ok, eErr := replayOnIdempotencyConflict(w, db.IdempotencyKindEnroll,
+			ei.TenantId, ir, err, startTime); ok {
+			return eErr
+		}
 		return &enrollError{ErrCreateEnroll, getHttpCodeForDbError(err)}
 	}
 	payload.ID = de.Id
@@ -127,6 +169,20 @@ func pushToPendingEnrollQueue(ep *enrollPayload) error {
 	return nil
 }
 
+// an enroll that could not be handed off would stay pending until
+// expiry and its csr could not be enrolled again. move it to
+// enroll_error so status shows the failure and the csr can be reused.
+func failEnroll(id uuid.UUID, err error) {
+	if fErr := db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     id.String(),
+		ErrorMessage: err.Error(),
+	}); fErr != nil {
+		esLogger.Error("Enroll: could not fail enroll after handoff error",
+			zap.String("enroll_id", id.String()),
+			zap.Error(fErr))
+	}
+}
+
 func sendEnrollResponse(w http.ResponseWriter, de *structs.DeviceEntry, st time.Time) *enrollError {
 	er := enrollResponse{ID: de.Id, RequestId: de.RequestId}
 	er.Elapsed = fmt.Sprintf("%v", time.Since(st))
@@ -139,6 +195,8 @@ func sendEnrollResponse(w http.ResponseWriter, de *structs.DeviceEntry, st time.
 	return nil
 }
 
+// missing or unsupported X-HP-Token-Type header
 func IsTokenTypeHeaderError(e error) bool {
-	return strings.Contains(e.Error(), headerTokenType)
+	return errors.Is(e, ErrTokenTypeHeaderNotFound) ||
+		errors.Is(e, tokenmgr.ErrUnsupportedTokenType)
 }
@@ -0,0 +1,239 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/notification"
+	"github.com/HPInc/krypton-es/es/service/policy"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// hands off accepted items. tests replace it to fail the handoff.
+var sendEnrollBatch = notification.SendMessageBatch
+
+const (
+	maxEnrollBatchSize = 100
+	// csr is the bulk of an item. allow a few kilobytes per item.
+	maxEnrollBatchRequestSize = maxEnrollBatchSize * 8192
+)
+
+type enrollBatchResponse struct {
+	Accepted int                `json:"accepted"`
+	Failed   int                `json:"failed"`
+	Results  []*enrollBatchItem `json:"results"`
+}
+
+// result of an item. id is set if the item was accepted, error otherwise.
+type enrollBatchItem struct {
+	Index     int                   `json:"index"`
+	Id        *uuid.UUID            `json:"id,omitempty"`
+	RequestId string                `json:"request_id,omitempty"`
+	Error     *enrollBatchItemError `json:"error,omitempty"`
+}
+
+type enrollBatchItemError struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+/*
+	Post /enroll/batch
+	Enroll many devices with one request. For provisioning stations.
+	The token is validated once and every item is handled like a
+	single enroll. Items are independent. A failed item does not fail
+	the batch.
+
+Requires:
+- Custom header: X-HP-Token-Type
+  - Value: "azuread" or "enrollment"
+  - Authorization header: Bearer <Token>
+  - Payload: array of up to 100 enroll payloads
+    [{
+    "csr":"<base64 encoded certificate signing request>"
+    "mgmt_service":"<management service>"
+    "hardware_hash":"<device hardware hash>"
+    }, ...]
+
+Returns:
+- 200
+  - {"accepted": <n>, "failed": <n>, "results": [...]}
+  - results are in payload order. each has the id of the pending enroll
+    or an error with the same code and status as a single enroll.
+
+Errors:
+- 400
+  - Malformed or missing Authorization header
+  - Malformed or missing payload
+  - Payload must have 1 to 100 items
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func EnrollBatch(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	payloads, err := getEnrollBatchPayload(r)
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	res := enrollBatchResponse{
+		Results: make([]*enrollBatchItem, len(payloads)),
+	}
+	p, eErr := getCSRPolicy(ei.TenantId)
+	if eErr != nil {
+		return eErr
+	}
+
+	// accepted items are handed off together at the end
+	var accepted []*enrollBatchItem
+	var msgs []string
+	seen := map[string]bool{}
+	for i, payload := range payloads {
+		item := &enrollBatchItem{Index: i}
+		res.Results[i] = item
+		eErr := createBatchEnroll(ei, p, payload, seen)
+		if eErr != nil {
+			item.Error = newEnrollBatchItemError(eErr)
+			continue
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			item.Error = newEnrollBatchItemError(
+				&enrollError{ErrInternal, http.StatusInternalServerError})
+			continue
+		}
+		item.Id = &payload.ID
+		item.RequestId = payload.RequestId
+		accepted = append(accepted, item)
+		msgs = append(msgs, string(data))
+	}
+
+	for i, err := range sendEnrollBatch(msgs) {
+		if err == nil {
+			continue
+		}
+		esLogger.Error("EnrollBatch: handoff failed",
+			zap.String("ID", accepted[i].Id.String()),
+			zap.Error(err))
+		failEnroll(*accepted[i].Id, ErrHandoffEnroll)
+		accepted[i].Id = nil
+		accepted[i].RequestId = ""
+		accepted[i].Error = newEnrollBatchItemError(
+			&enrollError{ErrHandoffEnroll, http.StatusInternalServerError})
+	}
+	for _, item := range res.Results {
+		if item.Error == nil {
+			res.Accepted++
+		} else {
+			res.Failed++
+		}
+	}
+
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	esLogger.Info(
+		"Enroll batch queued",
+		zap.String("TenantID", ei.TenantId),
+		zap.Int("Accepted", res.Accepted),
+		zap.Int("Failed", res.Failed),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// validate an item and create its enroll record. seen has the
+// csr hashes of earlier items so a csr is not used twice in a batch.
+func createBatchEnroll(ei *EnrollInfo, p *policy.Policy,
+	payload *enrollPayload, seen map[string]bool) *enrollError {
+	var err error
+	if err = payload.loadCSR(); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if err = payload.ValidateManagementService(); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	payload.Type = requestPayloadTypeEnroll
+	payload.TenantId = ei.TenantId
+	if eErr := checkCSRPolicy(p, payload); eErr != nil {
+		return eErr
+	}
+
+	if seen[payload.CSRHash] {
+		return &enrollError{ErrDuplicateCsr, http.StatusConflict}
+	}
+	seen[payload.CSRHash] = true
+	hasCSRHash, err := payload.isEnrolledCSR()
+	if err != nil {
+		return &enrollError{ErrLookupCsr, getHttpCodeForDbError(err)}
+	}
+	if hasCSRHash {
+		return &enrollError{ErrDuplicateCsr, http.StatusConflict}
+	}
+
+	// stored payload is the one a retry would hand off again
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	de, err := db.CreateEnrollRecord(ei.TenantId, ei.UserId, payload.CSRHash,
+		string(data), nil)
+	if err != nil {
+		return &enrollError{ErrCreateEnroll, getHttpCodeForDbError(err)}
+	}
+	payload.ID = de.Id
+	payload.RequestId = de.RequestId
+	return nil
+}
+
+func getEnrollBatchPayload(r *http.Request) ([]*enrollPayload, error) {
+	if r.ContentLength == 0 {
+		return nil, ErrPayloadMissing
+	}
+	defer r.Body.Close()
+
+	var payloads []*enrollPayload
+	if err := json.NewDecoder(
+		http.MaxBytesReader(nil, r.Body, maxEnrollBatchRequestSize)).Decode(
+		&payloads); err != nil {
+		return nil, ErrPayloadRead
+	}
+	if len(payloads) == 0 || len(payloads) > maxEnrollBatchSize {
+		return nil, ErrInvalidBatchSize
+	}
+	for _, p := range payloads {
+		if p == nil {
+			return nil, ErrPayloadRead
+		}
+	}
+	return payloads, nil
+}
+
+func newEnrollBatchItemError(e *enrollError) *enrollBatchItemError {
+	code, _ := getErrorCode(e.Error, e.Code)
+	return &enrollBatchItemError{
+		Code:   code,
+		Status: e.Code,
+		Detail: e.Error.Error(),
+	}
+}
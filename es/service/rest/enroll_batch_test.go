@@ -0,0 +1,103 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/notification"
+)
+
+func TestEnrollBatchWithInvalidSizeFails(t *testing.T) {
+	bearerToken := getBearerToken()
+	for _, size := range []int{0, maxEnrollBatchSize + 1} {
+		payloads := make([]enrollPayload, size)
+		response := postTestEnrollBatch(t, bearerToken, payloads)
+		checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+// invalid items fail alone with the error of a single enroll
+func TestEnrollBatchReportsItemErrors(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	payloads := []enrollPayload{
+		{CSR: "not base64!", ManagementService: "unknown"},
+		{CSR: csr},
+		{CSR: csr, ManagementService: "unknown"},
+	}
+	response := postTestEnrollBatch(t, getBearerToken(), payloads)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res enrollBatchResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode batch response: %v", err)
+	}
+	if res.Accepted != 0 || res.Failed != len(payloads) {
+		t.Errorf("Expected 0 accepted and %d failed, found: %d/%d",
+			len(payloads), res.Accepted, res.Failed)
+	}
+	expected := []string{"invalid_csr_encoding", "missing_mgmt_service",
+		"invalid_mgmt_service"}
+	for i, item := range res.Results {
+		if item.Index != i || item.Id != nil || item.Error == nil ||
+			item.Error.Code != expected[i] {
+			t.Errorf("Expected item %d to fail with %s, found: %+v",
+				i, expected[i], item)
+		}
+	}
+}
+
+func postTestEnrollBatch(t *testing.T, bearerToken string,
+	payloads []enrollPayload) *httptest.ResponseRecorder {
+	body, err := json.Marshal(payloads)
+	handleError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/enroll/batch",
+		bytes.NewReader(body))
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	return executeTestRequest(req)
+}
+
+// an item that could not be handed off is failed so its csr can be
+// submitted again
+func TestEnrollBatchHandoffFailureFailsEnroll(t *testing.T) {
+	sendEnrollBatch = func(msgs []string) []error {
+		errs := make([]error, len(msgs))
+		for i := range errs {
+			errs[i] = errors.New("queue unavailable")
+		}
+		return errs
+	}
+	t.Cleanup(func() { sendEnrollBatch = notification.SendMessageBatch })
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	payloads := []enrollPayload{
+		{CSR: newTestCSR(t, key), ManagementService: "hpconnect"},
+	}
+	// a pending record left behind would fail the retry as a duplicate
+	for i := 0; i < 2; i++ {
+		response := postTestEnrollBatch(t, getBearerToken(), payloads)
+		checkTestResponseCode(t, http.StatusOK, response.Code)
+		var res enrollBatchResponse
+		if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+			t.Fatalf("Failed to decode batch response: %v", err)
+		}
+		item := res.Results[0]
+		if res.Failed != 1 || item.Id != nil || item.Error == nil ||
+			item.Error.Code != "enroll_handoff_failed" {
+			t.Errorf("%d: expected handoff failure, found: %+v", i, item)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// reported only on the event stream when a worker result is
+	// picked up from the queue. status in db is still pending.
+	statusProcessing = "processing"
+
+	// comment line to keep proxies from closing an idle stream
+	eventStreamKeepAlive = time.Second * 15
+	// clients reconnect to continue watching after this
+	eventStreamMaxDuration = time.Minute * 10
+)
+
+type enrollEvent struct {
+	Id        uuid.UUID `json:"id"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ErrorCode int       `json:"error_code,omitempty"`
+	ErrorText string    `json:"error_text,omitempty"`
+}
+
+/*
+	Get /enroll/{enroll_id}/events
+	Stream status transitions of an enroll or unenroll id as server sent events.
+	Same token and matching rules as Get /enroll/{enroll_id}.
+	Current status is sent first. The stream ends after a terminal status.
+
+Returns:
+- 200 text/event-stream
+  - event: pending | processing | enrolled | unenrolled | failed
+  - data: {"id": <uuid>, "status": <status>}
+  - failed data adds "reason", "error_code" and "error_text"
+  - enrolled does not include the certificate. Get /enroll/{enroll_id} for it.
+
+Errors:
+- 400
+  - Malformed or missing Authorization header
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 404
+  - id is not found or does not match token details
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func EnrollEvents(w http.ResponseWriter, r *http.Request) *enrollError {
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	id, eErr := getUUIDParam(r, paramEnrollID)
+	if eErr != nil {
+		return eErr
+	}
+
+	// watch before first lookup so a change in between is not missed
+	changed, cancel := cache.WatchStatusChange(id)
+	defer cancel()
+
+	state, eErr := getEnrollState(id, ei)
+	if eErr != nil {
+		return eErr
+	}
+
+	// stream is long lived. lift the server write timeout for it.
+	rc := http.NewResponseController(w)
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		esLogger.Error("Failed to clear write deadline for event stream",
+			zap.Error(err))
+	}
+	w.Header().Set(headerContentType, contentTypeEventStream)
+	w.Header().Set(headerCacheControl, cacheControlNoCache)
+	w.WriteHeader(http.StatusOK)
+
+	if err = writeEnrollEvent(w, rc, id, state); err != nil ||
+		isTerminalState(state) {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	poll := time.NewTicker(statusWaitPollInterval)
+	defer poll.Stop()
+	timer := time.NewTimer(eventStreamMaxDuration)
+	defer timer.Stop()
+
+	for {
+		var next *enrollState
+		select {
+		case hint := <-changed:
+			if hint == cache.StatusHintProcessing {
+				if state.status != statusPending {
+					continue
+				}
+				next = &enrollState{status: statusProcessing}
+				break
+			}
+			if next, eErr = getEnrollState(id, ei); eErr != nil {
+				return nil
+			}
+		case <-poll.C:
+			if next, eErr = getEnrollState(id, ei); eErr != nil {
+				return nil
+			}
+		case <-keepAlive.C:
+			if err = writeEventComment(w, rc, "keepalive"); err != nil {
+				return nil
+			}
+			continue
+		case <-timer.C:
+			return nil
+		case <-r.Context().Done():
+			return nil
+		}
+		// processing is not recorded in db. do not step back to pending.
+		if next.status == state.status ||
+			(state.status == statusProcessing && next.status == statusPending) {
+			continue
+		}
+		state = next
+		if err = writeEnrollEvent(w, rc, id, state); err != nil ||
+			isTerminalState(state) {
+			return nil
+		}
+	}
+}
+
+func isTerminalState(state *enrollState) bool {
+	return state.status != statusPending && state.status != statusProcessing
+}
+
+func writeEnrollEvent(w http.ResponseWriter, rc *http.ResponseController,
+	id uuid.UUID, state *enrollState) error {
+	ev := enrollEvent{
+		Id:     id,
+		Status: state.status,
+		Reason: state.reason,
+	}
+	if state.failure != nil {
+		ev.ErrorCode = state.failure.ErrorCode
+		ev.ErrorText = state.failure.ErrorText
+	}
+	data, err := json.Marshal(&ev)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n",
+		ev.Status, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func writeEventComment(w http.ResponseWriter, rc *http.ResponseController,
+	comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// completed enroll sends a single terminal event
+func TestDeviceTokenEnrollEventsCompleted(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	entry, err := updateEnroll(info)
+	handleError(t, err)
+
+	body := getTestEnrollEvents(t, bearerToken, entry.Id, http.StatusOK)
+	expectTestEvents(t, body, statusEnrolled)
+}
+
+// pending enroll streams pending and then the failure
+func TestDeviceTokenEnrollEventsPendingToFailed(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	entry, err := db.RenewEnroll(info.tenantId, uuid.MustParse(info.deviceId),
+		info.userId, uuid.New().String(), "", nil)
+	handleError(t, err)
+
+	go func() {
+		time.Sleep(time.Second)
+		handleError(t, db.FailEnrollRecord(&structs.EnrollError{
+			EnrollId:     entry.Id.String(),
+			ErrorCode:    123,
+			ErrorMessage: "failed to generate certificate",
+		}))
+	}()
+
+	body := getTestEnrollEvents(t, bearerToken, entry.Id, http.StatusOK)
+	expectTestEvents(t, body, statusPending, statusFailed)
+	if !strings.Contains(body, `"error_code":123`) {
+		t.Errorf("Expected error details in failed event, got: %s", body)
+	}
+}
+
+// events of another device are not visible
+func TestDeviceTokenEnrollEventsDeviceIdMismatch(t *testing.T) {
+	info, _ := getDeviceToken()
+	_, bearerToken := getDeviceTokenWithParams(info.tenantId,
+		uuid.New().String())
+	entry, err := updateEnroll(info)
+	handleError(t, err)
+
+	getTestEnrollEvents(t, bearerToken, entry.Id, http.StatusNotFound)
+}
+
+func getTestEnrollEvents(t *testing.T, bearerToken string, id uuid.UUID,
+	expectedCode int) string {
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s/events", id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, expectedCode, response.Code)
+	return response.Body.String()
+}
+
+func expectTestEvents(t *testing.T, body string, statuses ...string) {
+	var events []string
+	for _, line := range strings.Split(body, "\n") {
+		if s, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, s)
+		}
+	}
+	if strings.Join(events, ",") != strings.Join(statuses, ",") {
+		t.Errorf("Expected events %v, got: %v", statuses, events)
+	}
+}
@@ -19,9 +19,17 @@ type enrollError struct {
 	Code  int
 }
 
-type JsonError struct {
-	ErrorString string `json:"error"`
-	Code        int    `json:"code"`
+// RFC 7807 problem details sent for all errors
+type ProblemDetails struct {
+	// stable uri of the problem type. about:blank if error has no code.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// symbolic error code such as duplicate_csr
+	Code      string `json:"code"`
+	Instance  string `json:"instance,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
 }
 
 type enrollHandler func(http.ResponseWriter, *http.Request) *enrollError
@@ -36,8 +44,24 @@ func esHandlerFunc(inner enrollHandler) http.HandlerFunc {
 				esLogger.Error("Error serving http request", zap.Error(err.Error))
 				metrics.ReportRestError(r.Method, err.Code)
 			}
-			// send errors as json
-			sendJsonError(w, &JsonError{err.Error.Error(), err.Code})
+			sendProblemDetails(w, newProblemDetails(r, err))
 		}
 	})
 }
+
+func newProblemDetails(r *http.Request, e *enrollError) *ProblemDetails {
+	code, ok := getErrorCode(e.Error, e.Code)
+	problemType := problemTypeBlank
+	if ok {
+		problemType = problemTypeUrnPrefix + code
+	}
+	return &ProblemDetails{
+		Type:      problemType,
+		Title:     http.StatusText(e.Code),
+		Status:    e.Code,
+		Detail:    e.Error.Error(),
+		Code:      code,
+		Instance:  r.URL.Path,
+		RequestId: r.Header.Get(headerRequestID),
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 200
+	pageTokenSeparator  = "|"
+)
+
+// status names accepted in the status filter
+var enrollStatusFilters = map[string]int{
+	"pending":  ENROLL_STATUS_PENDING,
+	"enrolled": ENROLL_STATUS_ENROLLED,
+}
+
+type listEnrollsResponse struct {
+	Enrolls       []*structs.EnrollRecord `json:"enrolls"`
+	NextPageToken string                  `json:"next_page_token,omitempty"`
+}
+
+/*
+	Get /enroll
+	List enroll entries for the tenant in the bearer token
+
+Query:
+  - status: pending | enrolled
+  - created_after: RFC3339 timestamp
+  - page_size: 1 - 200. default 50
+  - page_token: next_page_token from a previous response
+
+Returns:
+- 200
+  - {"enrolls": [...], "next_page_token": <opaque token>}
+  - next_page_token is omitted on the last page
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - Invalid query parameters
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ListEnrolls(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+	requestID := r.Header.Get(headerRequestID)
+
+	if err := validateUserToken(r); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	filter, err := getEnrollListFilter(r)
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	// fetch one extra record to find out if there is a next page
+	pageSize := filter.Limit
+	filter.Limit++
+	records, err := db.ListEnrollRecords(ei.TenantId, filter)
+	if err != nil {
+		esLogger.Error("ListEnrolls: db error",
+			zap.String("Request ID:", requestID),
+			zap.String("TenantId", ei.TenantId),
+			zap.Error(err))
+		return &enrollError{ErrListEnroll, getHttpCodeForDbError(err)}
+	}
+
+	res := listEnrollsResponse{Enrolls: records}
+	if len(records) > pageSize {
+		res.Enrolls = records[:pageSize]
+		last := res.Enrolls[pageSize-1]
+		res.NextPageToken = newPageToken(last.CreatedAt, last.Id)
+	}
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	esLogger.Info(
+		"ListEnrolls",
+		zap.String("TenantID", ei.TenantId),
+		zap.Int("Count", len(res.Enrolls)),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// build list filter from query params
+func getEnrollListFilter(r *http.Request) (*structs.EnrollListFilter, error) {
+	query := r.URL.Query()
+	filter := structs.EnrollListFilter{Limit: defaultListPageSize}
+
+	if s := query.Get(queryStatus); s != "" {
+		status, ok := enrollStatusFilters[strings.ToLower(s)]
+		if !ok {
+			return nil, ErrInvalidStatusFilter
+		}
+		filter.Status = &status
+	}
+
+	if s := query.Get(queryCreatedAfter); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, ErrInvalidCreatedAfter
+		}
+		t = t.UTC()
+		filter.CreatedAfter = &t
+	}
+
+	if s := query.Get(queryPageSize); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 {
+			return nil, ErrInvalidPageSize
+		}
+		filter.Limit = min(size, maxListPageSize)
+	}
+
+	if s := query.Get(queryPageToken); s != "" {
+		createdAt, id, err := parsePageToken(s)
+		if err != nil {
+			return nil, err
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterId = id
+	}
+	return &filter, nil
+}
+
+// page token is an opaque encoding of the last (created_at, id) returned
+func newPageToken(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(
+		createdAt.UTC().Format(time.RFC3339Nano) + pageTokenSeparator +
+			id.String()))
+}
+
+func parsePageToken(token string) (time.Time, uuid.UUID, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidPageToken
+	}
+	parts := strings.Split(string(b), pageTokenSeparator)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, ErrInvalidPageToken
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidPageToken
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidPageToken
+	}
+	return createdAt, id, nil
+}
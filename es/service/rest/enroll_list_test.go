@@ -0,0 +1,113 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// device tokens cannot list tenant enrolls
+func TestListEnrollsWithDeviceTokenFails(t *testing.T) {
+	_, deviceToken := getDeviceToken()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll", nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, deviceToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestListEnrollsWithInvalidQueryFails(t *testing.T) {
+	bearerToken := getBearerToken()
+	queries := []string{
+		"status=unknown",
+		"created_after=yesterday",
+		"page_size=0",
+		"page_token=invalid",
+	}
+	for _, q := range queries {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll?"+q, nil)
+		req.Header.Set(headerTokenType, "test")
+		req.Header.Set(headerAuthorization, bearerToken)
+		response := executeTestRequest(req)
+		checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+// create enrolls for the tenant in bearer token and page through them
+func TestListEnrollsPaginates(t *testing.T) {
+	bearerToken := getBearerToken()
+	tenantId := getTenantIdFromBearerToken(t, bearerToken)
+
+	var enrollCount = 3
+	for i := 0; i < enrollCount; i++ {
+		_, err := db.CreateEnrollRecord(tenantId, uuid.New().String(),
+			uuid.New().String(), "", nil)
+		handleError(t, err)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	pageToken := ""
+	for i := 0; i < enrollCount; i++ {
+		res := listTestEnrolls(t, bearerToken,
+			fmt.Sprintf("page_size=2&page_token=%s", pageToken))
+		for _, e := range res.Enrolls {
+			if e.TenantId != tenantId {
+				t.Errorf("Expected tenant %s, found: %s", tenantId, e.TenantId)
+			}
+			seen[e.Id] = true
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		pageToken = res.NextPageToken
+	}
+	if len(seen) != enrollCount {
+		t.Errorf("Expected %d enrolls, found: %d", enrollCount, len(seen))
+	}
+}
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	createdAt := time.Now().UTC()
+	id := uuid.New()
+	c, i, err := parsePageToken(newPageToken(createdAt, id))
+	handleError(t, err)
+	if !c.Equal(createdAt) || i != id {
+		t.Errorf("Expected %v/%v, found: %v/%v", createdAt, id, c, i)
+	}
+}
+
+func listTestEnrolls(t *testing.T, bearerToken, query string) *listEnrollsResponse {
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll?"+query, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res listEnrollsResponse
+	if err := json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Errorf("Failed to decode list response: %v", err)
+	}
+	return &res
+}
+
+// test tokens carry a random tenant id. read it back without validation.
+func getTenantIdFromBearerToken(t *testing.T, token string) string {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(
+		strings.TrimPrefix(token, bearerToken), claims)
+	if err != nil {
+		t.Fatalf("Failed to parse bearer token: %v", err)
+	}
+	tenantId, _ := claims["tid"].(string)
+	return tenantId
+}
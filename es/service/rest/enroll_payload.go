@@ -5,27 +5,40 @@ package rest
 
 import (
 	"crypto/sha256"
-	"encoding/base64"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/HPInc/krypton-es/es/service/attestation"
 	"github.com/HPInc/krypton-es/es/service/config"
+	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/google/uuid"
 )
 
+// enroll request from device. fields tagged readonly are set by es
+// before handoff to the worker.
 type enrollPayload struct {
-	ID                uuid.UUID `json:"id"`
+	ID                uuid.UUID `json:"id" openapi:"readonly"`
 	CSR               string    `json:"csr"`
-	RequestId         string    `json:"request_id"`
-	TenantId          string    `json:"tenant_id"`
-	DeviceId          uuid.UUID `json:"device_id,omitempty"`
+	RequestId         string    `json:"request_id" openapi:"readonly"`
+	TenantId          string    `json:"tenant_id" openapi:"readonly"`
+	DeviceId          uuid.UUID `json:"device_id,omitempty" openapi:"readonly"`
 	CSRHash           string    `json:"-"`
-	Type              string    `json:"type"`
+	Type              string    `json:"type" openapi:"readonly"`
 	ManagementService string    `json:"mgmt_service"`
 	HardwareHash      string    `json:"hardware_hash"`
+	// optional tpm evidence that the csr key is tpm resident
+	Attestation *attestation.Evidence `json:"attestation,omitempty"`
+	// verified attestation. stored with the enroll for the worker.
+	AttestationResult *attestation.Result `json:"attestation_result,omitempty" openapi:"readonly"`
+	// parsed csr
+	csr *x509.CertificateRequest
+	// hash of the base64 text that enrolls were recorded with before
+	// csr hashes were taken over the der form
+	legacyCSRHash string
 }
 
 func GetEnrollPayload(r *http.Request) (*enrollPayload, error) {
@@ -37,7 +50,7 @@ func GetEnrollPayload(r *http.Request) (*enrollPayload, error) {
 	if err = json.Unmarshal(body, &ep); err != nil {
 		return nil, err
 	}
-	if ep.CSRHash, err = ep.getCSRHash(); err != nil {
+	if err = ep.loadCSR(); err != nil {
 		return nil, err
 	}
 	ep.Type = requestPayloadTypeEnroll
@@ -53,21 +66,49 @@ func GetRenewEnrollPayload(r *http.Request) (*enrollPayload, error) {
 	return ep, nil
 }
 
-func (ep enrollPayload) getCSRHash() (string, error) {
-	_, err := base64.StdEncoding.DecodeString(ep.CSR)
+// validate csr and hash its der form so the same request in a
+// different base64 text is still the same csr
+func (ep *enrollPayload) loadCSR() error {
+	cr, err := parseCSR(ep.CSR)
 	if err != nil {
-		return "", err
+		return err
 	}
-	bs := sha256.Sum256([]byte(ep.CSR))
-	return fmt.Sprintf("%x\n", bs), nil
+	bs := sha256.Sum256(cr.Raw)
+	ep.csr = cr
+	ep.CSRHash = hex.EncodeToString(bs[:])
+	legacy := sha256.Sum256([]byte(ep.CSR))
+	ep.legacyCSRHash = fmt.Sprintf("%x\n", legacy)
+	return nil
+}
+
+// check csr against enrolls recorded with either hash form.
+// the legacy lookup can go once enrolls with text hashes are gone.
+func (ep *enrollPayload) isEnrolledCSR() (bool, error) {
+	for _, h := range []string{ep.CSRHash, ep.legacyCSRHash} {
+		exists, err := db.HasCSRHash(h)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// latest enroll or failed enroll of the csr recorded with either hash
+// form. no rows error if there is none.
+func (ep *enrollPayload) getEnrollIdByCSR() (uuid.UUID, error) {
+	id, err := db.GetEnrollIdByCSRHash(ep.CSRHash)
+	if err != nil && db.IsDbErrorNoRows(err) {
+		id, err = db.GetEnrollIdByCSRHash(ep.legacyCSRHash)
+	}
+	return id, err
 }
 
 func (ep enrollPayload) ValidateManagementService() error {
 	if ep.ManagementService == "" {
-		return errors.New("please specify mgmt_service in payload")
+		return ErrMissingMgmtService
 	} else if !ep.HasManagementService() {
 		return fmt.Errorf(
-			"%s is not a valid mgmt_service. Valid values are %v",
+			"%w: %s. Valid values are %v", ErrInvalidMgmtService,
 			ep.ManagementService, config.GetManagementServices())
 	}
 	return nil
@@ -4,13 +4,46 @@
 package rest
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
 	"testing"
 )
 
-func TestGetCSRHashFailsForNonBase64(t *testing.T) {
+func TestLoadCSRFailsForNonBase64(t *testing.T) {
 	ep := enrollPayload{CSR: "123"}
-	_, err := ep.getCSRHash()
+	err := ep.loadCSR()
 	if err == nil {
 		t.Errorf("Expected error for non base64 encoded csr. Got none")
 	}
 }
+
+// same csr in a different base64 text hashes the same. the legacy
+// hash is over the text as sent.
+func TestLoadCSRHashesDer(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	wrapped := ""
+	for i := 0; i < len(csr); i += 64 {
+		wrapped += csr[i:min(i+64, len(csr))] + "\n"
+	}
+	a := enrollPayload{CSR: csr}
+	b := enrollPayload{CSR: wrapped}
+	if err := a.loadCSR(); err != nil {
+		t.Fatalf("Failed to load csr: %v", err)
+	}
+	if err := b.loadCSR(); err != nil {
+		t.Fatalf("Failed to load wrapped csr: %v", err)
+	}
+	if a.CSRHash != b.CSRHash {
+		t.Errorf("Expected same hash for same csr")
+	}
+	legacy := sha256.Sum256([]byte(csr))
+	if a.legacyCSRHash != fmt.Sprintf("%x\n", legacy) ||
+		a.legacyCSRHash == b.legacyCSRHash {
+		t.Errorf("Unexpected legacy hash %q", a.legacyCSRHash)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/sha1" //#nosec G505 -- fingerprint only
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	headerAccept = "Accept"
+
+	// device certificate followed by parent certificates
+	contentTypePemChain = "application/pem-certificate-chain"
+	// binary certs-only pkcs7 of the same chain
+	contentTypePkcs7Mime = "application/pkcs7-mime"
+)
+
+// completed enroll formats in order of preference on equal quality
+var enrollResultContentTypes = []string{
+	contentTypeJson,
+	contentTypePemChain,
+	contentTypePkcs7Mime,
+}
+
+// parsed certificate metadata so clients do not have to decode der
+type certificateInfo struct {
+	// hex serial number
+	SerialNumber      string    `json:"serial_number"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	FingerprintSha1   string    `json:"fingerprint_sha1"`
+	FingerprintSha256 string    `json:"fingerprint_sha256"`
+}
+
+// json form of a completed enroll. certificate and parent_certificates
+// are kept as base64 for existing clients.
+type enrollResultResponse struct {
+	structs.EnrollResult
+	CertificateInfo        *certificateInfo  `json:"certificate_info,omitempty"`
+	ParentCertificatesInfo []certificateInfo `json:"parent_certificates_info,omitempty"`
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// pick the completed enroll format from the Accept header. json if
+// there is no Accept header.
+func getEnrollResultContentType(r *http.Request) (string, *enrollError) {
+	accept := r.Header.Values(headerAccept)
+	if len(accept) == 0 {
+		return contentTypeJson, nil
+	}
+	for _, ar := range parseAccept(strings.Join(accept, ",")) {
+		for _, ct := range enrollResultContentTypes {
+			if acceptsMediaType(ar.mediaType, ct) {
+				return ct, nil
+			}
+		}
+	}
+	return "", &enrollError{ErrNotAcceptable, http.StatusNotAcceptable}
+}
+
+// media ranges with q > 0, highest quality first. header order
+// breaks ties.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, acceptRange{mediaType, quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
+
+func acceptsMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+func getCompletedEnroll(w http.ResponseWriter, id uuid.UUID,
+	contentType string) *enrollError {
+	dc, err := db.GetEnrollDetailsById(id)
+	if err != nil {
+		return &enrollError{ErrLookupEnroll, http.StatusNotFound}
+	}
+	if contentType == contentTypeJson {
+		return sendEnrollResultJson(w, dc)
+	}
+
+	certs, err := parseStoredCertificates(dc.Certificate)
+	if err == nil {
+		var parents []*x509.Certificate
+		if parents, err = parseStoredCertificates(dc.ParentCertificates); err == nil {
+			certs = append(certs, parents...)
+		}
+	}
+	if err != nil {
+		esLogger.Error("Failed to parse certificates of enroll",
+			zap.String("ID", id.String()),
+			zap.Error(err))
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	if contentType == contentTypePemChain {
+		w.Header().Set(headerContentType, contentTypePemChain)
+		for _, c := range certs {
+			_ = pem.Encode(w, &pem.Block{Type: pemTypeCertificate, Bytes: c.Raw})
+		}
+		return nil
+	}
+	der, err := pkcs7.DegenerateCertificates(certs)
+	if err != nil {
+		esLogger.Error("Failed to encode certificates", zap.Error(err))
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypePkcs7CertsOnly)
+	_, _ = w.Write(der)
+	return nil
+}
+
+// metadata is best effort. the base64 certificates are sent as stored.
+func sendEnrollResultJson(w http.ResponseWriter,
+	dc *structs.EnrollResult) *enrollError {
+	er := enrollResultResponse{EnrollResult: *dc}
+	if certs, err := parseStoredCertificates(dc.Certificate); err == nil &&
+		len(certs) > 0 {
+		er.CertificateInfo = newCertificateInfo(certs[0])
+	} else {
+		esLogger.Error("Failed to parse certificate of enroll",
+			zap.String("ID", dc.EnrollId.String()),
+			zap.Error(err))
+	}
+	if parents, err := parseStoredCertificates(dc.ParentCertificates); err == nil {
+		for _, c := range parents {
+			er.ParentCertificatesInfo = append(er.ParentCertificatesInfo,
+				*newCertificateInfo(c))
+		}
+	}
+	jsonstring, err := json.Marshal(er)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypeJson)
+	_, _ = w.Write(jsonstring)
+	return nil
+}
+
+func newCertificateInfo(c *x509.Certificate) *certificateInfo {
+	sha1Sum := sha1.Sum(c.Raw) //#nosec G401 -- fingerprint only
+	sha256Sum := sha256.Sum256(c.Raw)
+	return &certificateInfo{
+		SerialNumber:      hex.EncodeToString(c.SerialNumber.Bytes()),
+		Subject:           c.Subject.String(),
+		Issuer:            c.Issuer.String(),
+		NotBefore:         c.NotBefore.UTC(),
+		NotAfter:          c.NotAfter.UTC(),
+		FingerprintSha1:   hex.EncodeToString(sha1Sum[:]),
+		FingerprintSha256: hex.EncodeToString(sha256Sum[:]),
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestGetEnrollResultContentType(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", contentTypeJson},
+		{"*/*", contentTypeJson},
+		{"application/json", contentTypeJson},
+		{"application/pem-certificate-chain", contentTypePemChain},
+		{"application/pkcs7-mime; smime-type=certs-only", contentTypePkcs7Mime},
+		{"text/html, application/*;q=0.5", contentTypeJson},
+		{"application/json;q=0.5, application/pem-certificate-chain", contentTypePemChain},
+		{"application/pkcs7-mime, application/pem-certificate-chain", contentTypePkcs7Mime},
+		{"text/html", ""},
+		{"application/json;q=0", ""},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if test.accept != "" {
+			req.Header.Set(headerAccept, test.accept)
+		}
+		ct, eErr := getEnrollResultContentType(req)
+		if test.contentType == "" {
+			if eErr == nil || eErr.Code != http.StatusNotAcceptable {
+				t.Errorf("%d: expected %d, found: %v", i, http.StatusNotAcceptable, eErr)
+			}
+		} else if ct != test.contentType {
+			t.Errorf("%d: expected %s, found: %s (%v)", i, test.contentType, ct, eErr)
+		}
+	}
+}
+
+func TestNewCertificateInfo(t *testing.T) {
+	cert := newTestCertificate(t)
+	info := newCertificateInfo(cert)
+	sum := sha256.Sum256(cert.Raw)
+	if info.FingerprintSha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected sha256 fingerprint: %s", info.FingerprintSha256)
+	}
+	if info.SerialNumber != hex.EncodeToString(cert.SerialNumber.Bytes()) {
+		t.Errorf("Unexpected serial number: %s", info.SerialNumber)
+	}
+	if !info.NotAfter.Equal(cert.NotAfter) || info.Issuer != "CN=test ca" {
+		t.Errorf("Unexpected validity or issuer: %+v", info)
+	}
+}
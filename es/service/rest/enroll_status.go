@@ -4,7 +4,6 @@
 package rest
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -18,9 +17,27 @@ import (
 	Get /enroll/{enroll_id}
 	Get enroll status by id
 
+Headers:
+  - Accept: optional. format of a completed enroll. application/json
+    (default), application/pem-certificate-chain or application/pkcs7-mime
+    (certs-only pkcs7 of the device and parent certificates). Other
+    statuses are always json.
+
+Query:
+  - wait: optional. duration (30s) or seconds to wait for a pending
+    status to change before responding. clamped to max_status_wait_seconds.
+
 Returns:
 - 200
-  - {"device_id": <uuid>, "cert": <base64 encoded cert>}
+  - {"device_id": <uuid>, "certificate": <base64 encoded cert>,
+    "parent_certificates": <base64 encoded certs>,
+    "certificate_info": {"serial_number", "subject", "issuer",
+    "not_before", "not_after", "fingerprint_sha1", "fingerprint_sha256"},
+    "parent_certificates_info": [...]}
+  - pem chain or pkcs7 of the certificates as per Accept
+  - {"id": <uuid>, "status": "failed", "reason": <reason>,
+    "error_code": <code>, "error_text": <text>} if processing failed.
+    This is a terminal status. Do not retry.
 
 Errors:
 - 400
@@ -34,6 +51,9 @@ Errors:
 - 405
   - Must be GET
 
+- 406
+  - enroll is completed and Accept does not include a supported format
+
 - 429
   - Not ready yet / too many requests.
   - "Retry-After:<delay seconds>" header is included in response.
@@ -54,27 +74,109 @@ func EnrollStatus(w http.ResponseWriter, r *http.Request) *enrollError {
 	if enroll_error != nil {
 		return enroll_error
 	}
-	return getEnrollStatus(w, id, ei)
+
+	// long poll. wait for a pending status to change before responding
+	wait, err := getWaitParam(r)
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if wait > 0 {
+		waitForStatusChange(r.Context(), id, ei, wait)
+	}
+	return getEnrollStatus(w, r, id, ei)
+}
+
+const (
+	statusPending    = "pending"
+	statusEnrolled   = "enrolled"
+	statusUnenrolled = "unenrolled"
+	statusFailed     = "failed"
+
+	// machine readable failure reasons
+	failureReasonEnroll   = "enroll_failed"
+	failureReasonUnenroll = "unenroll_failed"
+)
+
+// terminal status for a failed enroll or unenroll
+type failedStatusResponse struct {
+	Id        uuid.UUID `json:"id"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	ErrorCode int       `json:"error_code"`
+	ErrorText string    `json:"error_text"`
+}
+
+// state of an enroll or unenroll id after token matching
+type enrollState struct {
+	status  string
+	reason  string
+	failure *structs.EnrollErrorStatus
+}
+
+// Accept is only negotiated once the enroll is completed. pending
+// and failed statuses are json whatever was asked for.
+func getEnrollStatus(w http.ResponseWriter, r *http.Request, id uuid.UUID,
+	ei *EnrollInfo) *enrollError {
+	state, eErr := getEnrollState(id, ei)
+	if eErr != nil {
+		return eErr
+	}
+	switch state.status {
+	case statusPending:
+		writeRetryAfter(w, getRetryAfterHint())
+		return &enrollError{
+			ErrRequestInProgress,
+			http.StatusTooManyRequests,
+		}
+	case statusEnrolled:
+		contentType, eErr := getEnrollResultContentType(r)
+		if eErr != nil {
+			return eErr
+		}
+		return getCompletedEnroll(w, id, contentType)
+	case statusUnenrolled:
+		return getCompletedUnenroll(w, id)
+	default:
+		return sendFailedStatus(w, id, state.reason, state.failure)
+	}
 }
 
-func getEnrollStatus(w http.ResponseWriter, id uuid.UUID, ei *EnrollInfo) *enrollError {
+// resolve state of an enroll or unenroll id as visible to the token.
+// ids that do not match the token details are reported as not found.
+func getEnrollState(id uuid.UUID, ei *EnrollInfo) (*enrollState, *enrollError) {
 	var entry *structs.EnrollStatus
 	var err error
 	entry, err = db.GetEnrollStatus(id)
+	// a failed enroll is moved to enroll_error. report terminal failure.
+	if err != nil && db.IsDbErrorNoRows(err) {
+		if ee, eErr := db.GetEnrollErrorStatus(id); eErr == nil {
+			return getFailedEnroll(id, ee, ei)
+		}
+	}
 	// if there is a lookup error, consider an unenroll status if we have a device token
 	if err != nil && ei.DeviceId != "" {
-		return getUnenrollStatus(w, id, ei)
+		return getUnenrollState(id, ei)
 	}
 	// if there is an error or if entry does not match token details, dont go further.
-	if err != nil || entry.TenantId != ei.TenantId || entry.UserId != ei.UserId {
+	if err != nil {
+		esLogger.Error("Failed to find enroll record",
+			zap.String("id", id.String()),
+			zap.String("token_tenant_id", ei.TenantId),
+			zap.String("token_user_id", ei.UserId),
+			zap.Error(err))
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
+			http.StatusNotFound,
+		}
+	}
+	if entry.TenantId != ei.TenantId || entry.UserId != ei.UserId {
 		esLogger.Error("Failed to match enroll record",
 			zap.String("token_tenant_id", ei.TenantId),
 			zap.String("token_user_id", ei.UserId),
 			zap.String("entry_tenant_id", entry.TenantId),
-			zap.String("entry_user_id", entry.UserId),
-			zap.Error(err))
-		return &enrollError{
-			fmt.Errorf("id: %s is not found", id),
+			zap.String("entry_user_id", entry.UserId))
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
 			http.StatusNotFound,
 		}
 	}
@@ -90,29 +192,64 @@ func getEnrollStatus(w http.ResponseWriter, id uuid.UUID, ei *EnrollInfo) *enrol
 			esLogger.Error("Failed to match enroll record",
 				zap.String("token_device_id", ei.DeviceId),
 				zap.String("entry_device_id", deviceId))
-			return &enrollError{
-				fmt.Errorf("id: %s is not found", id),
+			return nil, &enrollError{
+				fmt.Errorf("%w: %s", ErrIdNotFound, id),
 				http.StatusNotFound,
 			}
 		}
 	}
 	switch entry.Status {
-	case 0:
-		writeRetryAfter(w, getRetryAfterHint())
-		return &enrollError{
-			ErrRequestInProgress,
-			http.StatusTooManyRequests,
-		}
-	case 1:
-		return getCompletedEnroll(w, id)
+	case ENROLL_STATUS_PENDING:
+		return &enrollState{status: statusPending}, nil
+	case ENROLL_STATUS_ENROLLED:
+		return &enrollState{status: statusEnrolled}, nil
 	default:
-		return &enrollError{
-			fmt.Errorf("id: %s is not found", id),
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
 			http.StatusNotFound,
 		}
 	}
 }
 
+// failed enroll is visible only to the token that could see the enroll
+func getFailedEnroll(id uuid.UUID, ee *structs.EnrollErrorStatus,
+	ei *EnrollInfo) (*enrollState, *enrollError) {
+	if ee.TenantId != ei.TenantId || ee.UserId != ei.UserId ||
+		(ei.DeviceId != "" && ee.DeviceId.String() != ei.DeviceId) {
+		esLogger.Error("Failed to match enroll error record",
+			zap.String("token_tenant_id", ei.TenantId),
+			zap.String("token_user_id", ei.UserId),
+			zap.String("token_device_id", ei.DeviceId),
+			zap.String("entry_tenant_id", ee.TenantId),
+			zap.String("entry_user_id", ee.UserId))
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
+			http.StatusNotFound,
+		}
+	}
+	return &enrollState{
+		status:  statusFailed,
+		reason:  failureReasonEnroll,
+		failure: ee,
+	}, nil
+}
+
+// send terminal failed status with error details from worker
+func sendFailedStatus(w http.ResponseWriter, id uuid.UUID, reason string,
+	ee *structs.EnrollErrorStatus) *enrollError {
+	res := failedStatusResponse{
+		Id:        id,
+		Status:    statusFailed,
+		Reason:    reason,
+		ErrorCode: ee.ErrorCode,
+		ErrorText: ee.ErrorText,
+	}
+	if err := sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	return nil
+}
+
 func writeRetryAfter(w http.ResponseWriter, retryAfter int) {
 	w.Header().Add(headerRetryAfter, fmt.Sprintf("%d", retryAfter))
 }
@@ -134,17 +271,3 @@ func getRetryAfterHint() int {
 	// just an optimistic iota add to average.
 	return avgEnrollSeconds + 1
 }
-
-func getCompletedEnroll(w http.ResponseWriter, id uuid.UUID) *enrollError {
-	dc, err := db.GetEnrollDetailsById(id)
-	if err != nil {
-		return &enrollError{ErrLookupEnroll, http.StatusNotFound}
-	}
-	jsonstring, err := json.Marshal(dc)
-	if err != nil {
-		return &enrollError{ErrInternal, http.StatusInternalServerError}
-	} else {
-		fmt.Fprintf(w, "%s", jsonstring)
-	}
-	return nil
-}
@@ -4,6 +4,7 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -13,6 +14,17 @@ import (
 	"github.com/google/uuid"
 )
 
+// user tokens
+// an unknown id is not found and does not crash the handler
+func TestUserTokenGetEnrollStatusUnknownId(t *testing.T) {
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s", uuid.New().String())
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set("Authorization", getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
 // device tokens
 // no enroll record, no match
 func TestDeviceTokenGetEnrollStatusNoEnrollRecord(t *testing.T) {
@@ -75,5 +87,113 @@ func updateEnroll(info *testTokenInfo) (*structs.DeviceEntry, error) {
 
 func newEnroll(info *testTokenInfo) (*structs.DeviceEntry, error) {
 	csrHash := uuid.New().String()
-	return db.CreateEnrollRecord(info.tenantId, info.userId, csrHash)
+	return db.CreateEnrollRecord(info.tenantId, info.userId, csrHash, "", nil)
+}
+
+// make an enroll record, renew it with a device token and fail the renew
+// we should get a terminal failed status with error details
+func TestDeviceTokenGetEnrollStatusFailed(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	entry, err := db.RenewEnroll(info.tenantId,
+		uuid.MustParse(info.deviceId), info.userId, uuid.New().String(), "", nil)
+	handleError(t, err)
+	err = db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     entry.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s", entry.Id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res failedStatusResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode status response: %v", err)
+	}
+	if res.Status != statusFailed || res.Reason != failureReasonEnroll ||
+		res.ErrorCode != 123 {
+		t.Errorf("Unexpected failed status: %v", res)
+	}
+}
+
+// Accept only applies to completed enrolls. a failed status is json.
+func TestDeviceTokenGetEnrollStatusFailedIgnoresAccept(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	entry, err := db.RenewEnroll(info.tenantId,
+		uuid.MustParse(info.deviceId), info.userId, uuid.New().String(), "", nil)
+	handleError(t, err)
+	err = db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     entry.Id.String(),
+		ErrorCode:    123,
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s", entry.Id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	req.Header.Set(headerAccept, "text/html")
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res failedStatusResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil ||
+		res.Status != statusFailed {
+		t.Errorf("Expected failed status, found: %v (%v)", res, err)
+	}
+}
+
+// failed enroll of another device is not visible
+func TestDeviceTokenGetEnrollStatusFailedDeviceIdMismatch(t *testing.T) {
+	info, _ := getDeviceToken()
+	_, bearerToken := getDeviceTokenWithParams(info.tenantId,
+		uuid.New().String())
+	entry, err := db.RenewEnroll(info.tenantId,
+		uuid.MustParse(info.deviceId), info.userId, uuid.New().String(), "", nil)
+	handleError(t, err)
+	err = db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     entry.Id.String(),
+		ErrorMessage: "failed to generate certificate",
+	})
+	handleError(t, err)
+
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s", entry.Id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+// failed unenroll reports a terminal failed status
+func TestDeviceTokenGetUnenrollStatusFailed(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	entry, err := db.Unenroll(info.tenantId, uuid.MustParse(info.deviceId), "", nil)
+	handleError(t, err)
+	err = db.FailUnenrollRecord(&structs.EnrollError{
+		EnrollId:     entry.Id.String(),
+		ErrorMessage: "device not found",
+	})
+	handleError(t, err)
+
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s", entry.Id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var res failedStatusResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode status response: %v", err)
+	}
+	if res.Status != statusFailed || res.Reason != failureReasonUnenroll {
+		t.Errorf("Unexpected failed status: %v", res)
+	}
 }
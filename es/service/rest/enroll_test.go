@@ -8,13 +8,13 @@ import (
 	"testing"
 )
 
-func TestEnrollWithGetMethodFailsWith405(t *testing.T) {
-	req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll", nil)
+func TestEnrollWithPutMethodFailsWith405(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/enroll", nil)
 	response := executeTestRequest(req)
 	checkTestResponseCode(t, http.StatusMethodNotAllowed, response.Code)
 	allowHeader := response.Header().Get("Allow")
-	if allowHeader != "POST" {
-		t.Errorf("Expected Allow: POST, Got %s\n", allowHeader)
+	if allowHeader != "POST,GET" {
+		t.Errorf("Expected Allow: POST,GET, Got %s\n", allowHeader)
 	}
 }
 
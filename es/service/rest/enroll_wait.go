@@ -0,0 +1,94 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/cache"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// re-check interval while waiting in case a status change
+	// notification was missed or cache is disabled
+	statusWaitPollInterval = time.Second * 5
+)
+
+// get ?wait= as a duration clamped to configured max.
+// accepts go durations (30s) or plain seconds (30).
+func getWaitParam(r *http.Request) (time.Duration, error) {
+	s := r.URL.Query().Get(queryWait)
+	if s == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, aErr := strconv.Atoi(s)
+		if aErr != nil {
+			return 0, ErrInvalidWait
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0, ErrInvalidWait
+	}
+	return min(wait, getMaxStatusWait()), nil
+}
+
+func getMaxStatusWait() time.Duration {
+	return time.Duration(gServerConfig.MaxStatusWaitSeconds) * time.Second
+}
+
+// block until the pending enroll or unenroll id changes status,
+// wait expires or the request is cancelled.
+func waitForStatusChange(ctx context.Context, id uuid.UUID,
+	ei *EnrollInfo, wait time.Duration) {
+	// watch before checking status so a change in between is not missed
+	changed, cancel := cache.WatchStatusChange(id)
+	defer cancel()
+
+	if !isStatusPending(id, ei) {
+		return
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(statusWaitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case hint := <-changed:
+			// status in db is unchanged while a result is processed
+			if hint != "" {
+				continue
+			}
+			esLogger.Debug("Status changed while waiting",
+				zap.String("id", id.String()),
+				zap.String("waited", time.Since(start).String()))
+			return
+		case <-ticker.C:
+			if !isStatusPending(id, ei) {
+				return
+			}
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// is enroll or unenroll id pending and visible to the token.
+// ids that cannot be seen by the token are not waited on.
+func isStatusPending(id uuid.UUID, ei *EnrollInfo) bool {
+	state, eErr := getEnrollState(id, ei)
+	return eErr == nil && state.status == statusPending
+}
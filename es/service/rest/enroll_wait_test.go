@@ -0,0 +1,78 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+func TestGetWaitParam(t *testing.T) {
+	defer func(max int) {
+		gServerConfig.MaxStatusWaitSeconds = max
+	}(gServerConfig.MaxStatusWaitSeconds)
+	gServerConfig.MaxStatusWaitSeconds = 30
+
+	tests := map[string]time.Duration{
+		"":      0,
+		"10s":   10 * time.Second,
+		"10":    10 * time.Second,
+		"2m":    30 * time.Second,
+		"500ms": 500 * time.Millisecond,
+	}
+	for q, expected := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll?wait="+q, nil)
+		wait, err := getWaitParam(req)
+		handleError(t, err)
+		if wait != expected {
+			t.Errorf("wait=%s: expected %v, got %v", q, expected, wait)
+		}
+	}
+
+	for _, q := range []string{"soon", "-5s"} {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/enroll?wait="+q, nil)
+		if _, err := getWaitParam(req); err != ErrInvalidWait {
+			t.Errorf("wait=%s: expected %v, got %v", q, ErrInvalidWait, err)
+		}
+	}
+}
+
+// pending enroll completes while the status request waits
+func TestDeviceTokenGetEnrollStatusWaitsForCompletion(t *testing.T) {
+	defer func(max int) {
+		gServerConfig.MaxStatusWaitSeconds = max
+	}(gServerConfig.MaxStatusWaitSeconds)
+	gServerConfig.MaxStatusWaitSeconds = 30
+
+	info, bearerToken := getDeviceToken()
+	entry, err := db.RenewEnroll(info.tenantId, uuid.MustParse(info.deviceId),
+		info.userId, uuid.New().String(), "", nil)
+	handleError(t, err)
+
+	go func() {
+		time.Sleep(time.Second)
+		handleError(t, db.UpdateEnrollRecord(&structs.EnrollResult{
+			EnrollId:    entry.Id,
+			DeviceId:    uuid.MustParse(info.deviceId),
+			Certificate: "cert bytes",
+		}))
+	}()
+
+	start := time.Now()
+	queryUrl := fmt.Sprintf("/api/v1/enroll/%s?wait=20s", entry.Id)
+	req, _ := http.NewRequest(http.MethodGet, queryUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set("Authorization", bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected wakeup on completion, waited %v", elapsed)
+	}
+}
@@ -8,13 +8,18 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/HPInc/krypton-es/es/service/attestation"
 	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/policy"
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
 )
 
 var (
 	ErrInvalidTokenType        = errors.New("an invalid token type was specified")
 	ErrAppTokenNotProvided     = errors.New("app token expected but none was specified")
 	ErrDeviceTokenNotProvided  = errors.New("device token expected but none was specified")
+	ErrUserTokenNotProvided    = errors.New("user token expected but none was specified")
+	ErrAdminTokenNotProvided   = errors.New("user or app token expected but none was specified")
 	ErrDeviceIdMismatch        = errors.New("device id does not match claim in bearer token")
 	ErrDuplicateCsr            = errors.New("specified csr has been used previously")
 	ErrNoAuthorizationHeader   = errors.New("request does not have an authorization header")
@@ -23,6 +28,7 @@ var (
 	ErrRequestInProgress       = errors.New("request is being processed. Please see 'Retry-After' for a wait hint")
 	ErrTenantIdNotProvided     = errors.New("param tenant_id is not provided")
 	ErrTenantIdMismatch        = errors.New("tenant id does not match claim in bearer token")
+	ErrAppNotAllowedForTenant  = errors.New("app is not allowed to manage devices of the tenant")
 	ErrPayloadRead             = errors.New("payload read error")
 	ErrPayloadMissing          = errors.New("payload missing")
 	ErrInvalidPolicyVersion    = errors.New("invalid policy version")
@@ -40,8 +46,205 @@ var (
 	ErrGetPolicy               = errors.New("could not get policy")
 	ErrUpdatePolicy            = errors.New("could not update policy")
 	ErrInvalidPolicy           = errors.New("invalid policy data")
+	ErrListSigningKeys         = errors.New("could not list signing keys")
+	ErrRevokeSigningKey        = errors.New("could not revoke signing key")
+	ErrListEnroll              = errors.New("could not list enroll entries")
+	ErrInvalidStatusFilter     = errors.New("status must be one of pending, enrolled")
+	ErrInvalidCreatedAfter     = errors.New("created_after must be an RFC3339 timestamp")
+	ErrInvalidPageToken        = errors.New("page_token is invalid")
+	ErrInvalidPageSize         = errors.New("page_size must be a positive integer")
+	ErrDeviceHistory           = errors.New("could not get device history")
+	ErrNoDeviceHistory         = errors.New("no enroll history found for device")
+	ErrRetryEnroll             = errors.New("could not retry enroll")
+	ErrInvalidIdempotencyKey   = errors.New("Idempotency-Key header is too long")
+	ErrIdempotencyKeyMismatch  = errors.New("Idempotency-Key was used with a different request")
+	ErrLookupIdempotencyKey    = errors.New("there was an error while looking up this Idempotency-Key")
+	ErrInvalidWait             = errors.New("wait must be a duration such as 30s or a number of seconds")
+	ErrInvalidWebhookUrl       = errors.New("url must be an absolute https url of a public host")
+	ErrInvalidWebhookSecret    = errors.New("secret must be 16 to 256 characters")
+	ErrTooManyWebhooks         = errors.New("tenant has reached the maximum number of webhooks")
+	ErrCreateWebhook           = errors.New("could not create webhook")
+	ErrListWebhooks            = errors.New("could not list webhooks")
+	ErrDeleteWebhook           = errors.New("could not delete webhook")
+	ErrListWebhookDeliveries   = errors.New("could not list webhook deliveries")
+	ErrIdNotFound              = errors.New("id is not found")
+	ErrMissingPathParam        = errors.New("required path parameter is missing")
+	ErrInvalidUUIDParam        = errors.New("path parameter must be a uuid")
+	ErrMissingMgmtService      = errors.New("please specify mgmt_service in payload")
+	ErrInvalidMgmtService      = errors.New("invalid mgmt_service")
+	ErrDeviceNotEnrolled       = errors.New("device is not enrolled in tenant")
+	ErrInvalidBatchSize        = errors.New("batch must have 1 to 100 items")
+	ErrCsrEncoding             = errors.New("csr must be base64 encoded der")
+	ErrInvalidCsr              = errors.New("csr is not a valid pkcs#10 certificate signing request")
+	ErrCsrSignature            = errors.New("csr signature does not match its public key")
+	ErrUnsupportedCsrKey       = errors.New("csr public key type is not supported")
+	ErrUnsupportedCsrKeySize   = errors.New("csr public key size is not supported")
+	ErrCACertsNotConfigured    = errors.New("ca certificates are not configured")
+	ErrEstContentType          = errors.New("content type must be application/pkcs10")
+	ErrEnrollFailed            = errors.New("enroll of this csr failed")
+	ErrNoCertificates          = errors.New("no certificates found")
+	ErrScepNotConfigured       = errors.New("scep is not configured")
+	ErrScepOperation           = errors.New("unsupported scep operation")
+	ErrScepMessage             = errors.New("invalid scep pki message")
+	ErrScepRAKey               = errors.New("scep ra key must be rsa")
+	ErrNotAcceptable           = errors.New("accept does not include a supported certificate format")
 )
 
+// stable symbolic codes for errors. clients branch on these instead of
+// error text. codes must not change once released. errors are matched
+// with errors.Is so wrapped errors keep their code.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidTokenType, "invalid_token_type"},
+	{ErrAppTokenNotProvided, "app_token_required"},
+	{ErrDeviceTokenNotProvided, "device_token_required"},
+	{ErrUserTokenNotProvided, "user_token_required"},
+	{ErrAdminTokenNotProvided, "admin_token_required"},
+	{ErrDeviceIdMismatch, "device_id_mismatch"},
+	{ErrDuplicateCsr, "duplicate_csr"},
+	{ErrNoAuthorizationHeader, "missing_authorization_header"},
+	{ErrNoBearerTokenSpecified, "missing_bearer_token"},
+	{ErrTokenTypeHeaderNotFound, "missing_token_type_header"},
+	{ErrRequestInProgress, "request_in_progress"},
+	{ErrTenantIdNotProvided, "missing_tenant_id"},
+	{ErrTenantIdMismatch, "tenant_id_mismatch"},
+	{ErrAppNotAllowedForTenant, "app_not_allowed_for_tenant"},
+	{ErrPayloadRead, "invalid_payload"},
+	{ErrPayloadMissing, "missing_payload"},
+	{ErrInvalidPolicyVersion, "invalid_policy_version"},
+	{ErrLookupCsr, "csr_lookup_failed"},
+	{ErrCreateEnroll, "create_enroll_failed"},
+	{ErrRenewEnroll, "renew_enroll_failed"},
+	{ErrUnenroll, "unenroll_failed"},
+	{ErrHandoffEnroll, "enroll_handoff_failed"},
+	{ErrInternal, "internal_error"},
+	{ErrCreateEnrollToken, "create_enroll_token_failed"},
+	{ErrGetEnrollToken, "get_enroll_token_failed"},
+	{ErrLookupEnroll, "enroll_lookup_failed"},
+	{ErrCreatePolicy, "create_policy_failed"},
+	{ErrDeletePolicy, "delete_policy_failed"},
+	{ErrGetPolicy, "get_policy_failed"},
+	{ErrUpdatePolicy, "update_policy_failed"},
+	{ErrInvalidPolicy, "invalid_policy"},
+	{ErrListEnroll, "list_enrolls_failed"},
+	{ErrInvalidStatusFilter, "invalid_status_filter"},
+	{ErrInvalidCreatedAfter, "invalid_created_after"},
+	{ErrInvalidPageToken, "invalid_page_token"},
+	{ErrInvalidPageSize, "invalid_page_size"},
+	{ErrDeviceHistory, "device_history_failed"},
+	{ErrNoDeviceHistory, "no_device_history"},
+	{ErrRetryEnroll, "retry_enroll_failed"},
+	{ErrInvalidIdempotencyKey, "invalid_idempotency_key"},
+	{ErrIdempotencyKeyMismatch, "idempotency_key_mismatch"},
+	{ErrLookupIdempotencyKey, "idempotency_key_lookup_failed"},
+	{ErrInvalidWait, "invalid_wait"},
+	{ErrInvalidWebhookUrl, "invalid_webhook_url"},
+	{ErrInvalidWebhookSecret, "invalid_webhook_secret"},
+	{ErrTooManyWebhooks, "too_many_webhooks"},
+	{ErrCreateWebhook, "create_webhook_failed"},
+	{ErrListWebhooks, "list_webhooks_failed"},
+	{ErrDeleteWebhook, "delete_webhook_failed"},
+	{ErrListWebhookDeliveries, "list_webhook_deliveries_failed"},
+	{ErrIdNotFound, "id_not_found"},
+	{ErrMissingPathParam, "missing_path_param"},
+	{ErrInvalidUUIDParam, "invalid_uuid_param"},
+	{ErrMissingMgmtService, "missing_mgmt_service"},
+	{ErrInvalidMgmtService, "invalid_mgmt_service"},
+	{ErrDeviceNotEnrolled, "device_not_enrolled"},
+	{ErrInvalidBatchSize, "invalid_batch_size"},
+	{ErrCsrEncoding, "invalid_csr_encoding"},
+	{ErrInvalidCsr, "invalid_csr"},
+	{ErrCsrSignature, "invalid_csr_signature"},
+	{ErrUnsupportedCsrKey, "unsupported_csr_key"},
+	{ErrUnsupportedCsrKeySize, "unsupported_csr_key_size"},
+	{ErrCACertsNotConfigured, "ca_certs_not_configured"},
+	{ErrEstContentType, "unsupported_content_type"},
+	{ErrEnrollFailed, "enroll_failed"},
+	{ErrNoCertificates, "no_certificates"},
+	{ErrScepNotConfigured, "scep_not_configured"},
+	{ErrScepOperation, "unsupported_scep_operation"},
+	{ErrScepMessage, "invalid_scep_message"},
+	{ErrScepRAKey, "unsupported_scep_ra_key"},
+	{ErrNotAcceptable, "not_acceptable"},
+	{ErrListSigningKeys, "list_signing_keys_failed"},
+	{ErrRevokeSigningKey, "revoke_signing_key_failed"},
+
+	// tenant policy rules a csr did not meet
+	{policy.ErrCsrKeyAlgorithmNotAllowed, "policy_key_algorithm_not_allowed"},
+	{policy.ErrCsrRsaKeyTooSmall, "policy_rsa_key_too_small"},
+	{policy.ErrCsrCurveNotAllowed, "policy_curve_not_allowed"},
+	{policy.ErrCsrSubjectNotAllowed, "policy_subject_not_allowed"},
+	{policy.ErrCsrSubjectAltNameNotAllowed, "policy_subject_alt_name_not_allowed"},
+	{policy.ErrAttestationRequired, "policy_attestation_required"},
+
+	// tpm attestation evidence that did not verify
+	{attestation.ErrNotConfigured, "attestation_not_configured"},
+	{attestation.ErrInvalidEvidence, "invalid_attestation_evidence"},
+	{attestation.ErrInvalidTPMStructure, "invalid_tpm_structure"},
+	{attestation.ErrUnsupportedTPMAlg, "unsupported_tpm_algorithm"},
+	{attestation.ErrAKNotTrusted, "untrusted_ak_certificate"},
+	{attestation.ErrInvalidAKExtension, "invalid_ak_certificate"},
+	{attestation.ErrEKNotTrusted, "untrusted_ek_certificate"},
+	{attestation.ErrInvalidEKExtension, "invalid_ek_certificate"},
+	{attestation.ErrUnsupportedEK, "unsupported_ek"},
+	{attestation.ErrInvalidChallenge, "invalid_attestation_challenge"},
+	{attestation.ErrChallengeMismatch, "attestation_challenge_mismatch"},
+	{attestation.ErrActivatedSecret, "invalid_activated_secret"},
+	{attestation.ErrAKCertMismatch, "ak_certificate_mismatch"},
+	{attestation.ErrQualifyingData, "invalid_qualifying_data"},
+	{attestation.ErrInvalidAK, "invalid_attestation_key"},
+	{attestation.ErrNotCertifyInfo, "invalid_certify_info"},
+	{attestation.ErrSignature, "invalid_certify_signature"},
+	{attestation.ErrCertifiedKey, "certified_key_mismatch"},
+	{attestation.ErrKeyNotTPMResident, "key_not_tpm_resident"},
+	{attestation.ErrCsrKeyMismatch, "attested_key_csr_mismatch"},
+
+	// token validation errors surface as is
+	{tokenmgr.ErrUnsupportedTokenType, "unsupported_token_type"},
+	{tokenmgr.ErrInvalidToken, "invalid_token"},
+	{tokenmgr.ErrKIDNotFound, "unknown_token_kid"},
+	{tokenmgr.ErrInvalidTokenHeaderKid, "invalid_token_kid"},
+	{tokenmgr.ErrInvalidTokenHeaderSigningAlg, "invalid_token_signing_alg"},
+	{tokenmgr.ErrSigningAlgNotAllowed, "token_signing_alg_not_allowed"},
+	{tokenmgr.ErrTokenExpired, "token_expired"},
+	{tokenmgr.ErrTokenNotYetValid, "token_not_yet_valid"},
+	{tokenmgr.ErrTokenIssuedInFuture, "token_issued_in_future"},
+	{tokenmgr.ErrTokenTooOld, "token_too_old"},
+	{tokenmgr.ErrInvalidIssuerClaim, "invalid_issuer_claim"},
+	{tokenmgr.ErrInvalidAudienceClaim, "invalid_audience_claim"},
+	{tokenmgr.ErrInvalidTypeClaim, "invalid_typ_claim"},
+	{tokenmgr.ErrInvalidSubjectClaim, "invalid_sub_claim"},
+	{tokenmgr.ErrRequiredClaim, "required_claim_mismatch"},
+	{tokenmgr.ErrMappedClaim, "missing_mapped_claim"},
+}
+
+// code for errors without an entry in errorCodes
+var httpErrorCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "too_many_requests",
+	http.StatusInternalServerError: "internal_error",
+}
+
+// symbolic code of error. ok is false if error has no stable code
+// in which case the code is derived from the http status.
+func getErrorCode(err error, httpCode int) (code string, ok bool) {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code, true
+		}
+	}
+	if code, found := httpErrorCodes[httpCode]; found {
+		return code, false
+	}
+	return "error", false
+}
+
 // translate db error to http code
 func getHttpCodeForDbError(err error) int {
 	// all db errors are unexpected. mapping to 500
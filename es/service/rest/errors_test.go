@@ -0,0 +1,91 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/google/uuid"
+)
+
+// codes are part of the api. they must be unique per error.
+func TestErrorCodesAreUnique(t *testing.T) {
+	seen := map[string]error{}
+	for _, ec := range errorCodes {
+		if e, ok := seen[ec.code]; ok {
+			t.Errorf("Code %s is used by %v and %v", ec.code, e, ec.err)
+		}
+		seen[ec.code] = ec.err
+	}
+}
+
+func TestGetErrorCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		httpCode int
+		code     string
+		ok       bool
+	}{
+		{ErrDuplicateCsr, http.StatusConflict, "duplicate_csr", true},
+		{fmt.Errorf("%w: %s", ErrIdNotFound, uuid.New()),
+			http.StatusNotFound, "id_not_found", true},
+		{tokenmgr.ErrUnsupportedTokenType, http.StatusBadRequest,
+			"unsupported_token_type", true},
+		{fmt.Errorf("token is expired"), http.StatusUnauthorized,
+			"unauthorized", false},
+		{fmt.Errorf("teapot"), http.StatusTeapot, "error", false},
+	}
+	for _, test := range tests {
+		code, ok := getErrorCode(test.err, test.httpCode)
+		if code != test.code || ok != test.ok {
+			t.Errorf("%v: expected %s/%v, found: %s/%v",
+				test.err, test.code, test.ok, code, ok)
+		}
+	}
+}
+
+func TestIsTokenTypeHeaderError(t *testing.T) {
+	if !IsTokenTypeHeaderError(ErrTokenTypeHeaderNotFound) ||
+		!IsTokenTypeHeaderError(tokenmgr.ErrUnsupportedTokenType) {
+		t.Errorf("Expected token type header errors to match")
+	}
+	if IsTokenTypeHeaderError(tokenmgr.ErrInvalidToken) {
+		t.Errorf("Expected %v to not match", tokenmgr.ErrInvalidToken)
+	}
+}
+
+// errors are sent as problem details with a code and the request id
+func TestErrorIsProblemDetails(t *testing.T) {
+	requestId := uuid.NewString()
+	url := fmt.Sprintf("/api/v1/enroll/%s", uuid.New())
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set(headerRequestID, requestId)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+
+	if ct := response.Header().Get(headerContentType); ct != contentTypeProblemJson {
+		t.Errorf("Expected content type %s, found: %s",
+			contentTypeProblemJson, ct)
+	}
+	var problem ProblemDetails
+	if err := json.NewDecoder(response.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem details: %v", err)
+	}
+	expected := ProblemDetails{
+		Type:      problemTypeUrnPrefix + "missing_token_type_header",
+		Title:     http.StatusText(http.StatusBadRequest),
+		Status:    http.StatusBadRequest,
+		Detail:    ErrTokenTypeHeaderNotFound.Error(),
+		Code:      "missing_token_type_header",
+		Instance:  url,
+		RequestId: requestId,
+	}
+	if problem != expected {
+		t.Errorf("Expected %+v, found: %+v", expected, problem)
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// EST (RFC 7030) maps onto the enroll flow. the csr is queued like any
+// other enroll and the client polls by sending the same csr again.
+const (
+	estUrlPrefix = "/.well-known/est"
+
+	headerContentTransferEncoding = "Content-Transfer-Encoding"
+	transferEncodingBase64        = "base64"
+
+	contentTypePkcs10         = "application/pkcs10"
+	contentTypePkcs7CertsOnly = "application/pkcs7-mime; smime-type=certs-only"
+
+	// optional ca label path segment. names the mgmt_service.
+	paramEstLabel = "label"
+
+	// a base64 pkcs#10 with the largest key we accept fits easily
+	maxEstRequestSize = 32 * 1024
+)
+
+/*
+	Get /.well-known/est/cacerts
+	EST distribution of ca certificates. Not authenticated.
+
+Returns:
+- 200
+  - certs-only pkcs7 of the ca certificates, base64 encoded
+  - Content-Type: application/pkcs7-mime; smime-type=certs-only
+  - Content-Transfer-Encoding: base64
+
+Errors:
+- 404
+  - ca certificates are not configured (server.ca_certs_file)
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func EstCACerts(w http.ResponseWriter, r *http.Request) *enrollError {
+	if len(caCerts) == 0 {
+		return &enrollError{ErrCACertsNotConfigured, http.StatusNotFound}
+	}
+	return sendEstCertificates(w, caCerts)
+}
+
+/*
+	Post /.well-known/est/simpleenroll
+	Post /.well-known/est/{label}/simpleenroll
+	EST enroll. The label, if present, is the mgmt_service. Otherwise
+	server.default_management_service is used.
+	The first request queues the csr like Post /enroll. Send the same csr
+	again after Retry-After to get the certificate.
+
+Requires:
+- Custom header: X-HP-Token-Type
+  - Value: "azuread" or "enrollment"
+  - Authorization header: Bearer <Token>
+  - Payload: base64 encoded pkcs#10 (application/pkcs10)
+
+Returns:
+- 200
+  - certs-only pkcs7 of the device certificate, base64 encoded
+  - Content-Type: application/pkcs7-mime; smime-type=certs-only
+  - Content-Transfer-Encoding: base64
+
+- 202
+  - csr is queued or pending. "Retry-After:<delay seconds>" header is
+    included in response.
+
+Errors:
+- 400
+  - Malformed or missing Authorization header
+  - Missing payload or invalid mgmt_service
+  - csr is not a pkcs#10 request, its signature does not verify or
+    its key type or size is not supported
+  - csr does not meet a csr requirement in tenant policy
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 409
+  - csr was used by another tenant, user or device
+
+- 415
+  - Content-Type must be application/pkcs10
+
+- 422
+  - enroll of this csr failed. This is terminal. Do not retry.
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func EstSimpleEnroll(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	payload, eErr := getEstPayload(r, requestPayloadTypeEnroll)
+	if eErr != nil {
+		return eErr
+	}
+	payload.ManagementService = getEstManagementService(r)
+	if err = payload.ValidateManagementService(); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	payload.TenantId = ei.TenantId
+	return estEnroll(w, ei, payload, startTime)
+}
+
+/*
+	Post /.well-known/est/simplereenroll
+	EST renew of the certificate of the device in the bearer token.
+	Polling works as in simpleenroll.
+
+Requires:
+- Custom header: X-HP-Token-Type
+  - Value: "device"
+  - Authorization header: Bearer <Token>
+  - Payload: base64 encoded pkcs#10 (application/pkcs10)
+
+Returns:
+- 200
+  - certs-only pkcs7 of the device certificate, base64 encoded
+
+- 202
+  - csr is queued or pending. "Retry-After:<delay seconds>" header is
+    included in response.
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to device
+  - Missing payload
+  - csr is not a pkcs#10 request, its signature does not verify or
+    its key type or size is not supported
+  - csr does not meet a csr requirement in tenant policy
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 409
+  - csr was used by another tenant, user or device
+
+- 415
+  - Content-Type must be application/pkcs10
+
+- 422
+  - renew of this csr failed. This is terminal. Do not retry.
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func EstSimpleReenroll(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+
+	if err := validateDeviceToken(r); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+	deviceId, err := uuid.Parse(ei.DeviceId)
+	if err != nil {
+		return &enrollError{tokenmgr.ErrInvalidToken, http.StatusUnauthorized}
+	}
+
+	payload, eErr := getEstPayload(r, requestPayloadTypeReenroll)
+	if eErr != nil {
+		return eErr
+	}
+	payload.TenantId = ei.TenantId
+	payload.DeviceId = deviceId
+	return estEnroll(w, ei, payload, startTime)
+}
+
+// queue a new csr or report the state of the enroll of a known csr
+func estEnroll(w http.ResponseWriter, ei *EnrollInfo, payload *enrollPayload,
+	startTime time.Time) *enrollError {
+	p, eErr := getCSRPolicy(ei.TenantId)
+	if eErr != nil {
+		return eErr
+	}
+	if eErr = checkCSRPolicy(p, payload); eErr != nil {
+		return eErr
+	}
+
+	// a csr seen before is a poll for its enroll
+	id, err := payload.getEnrollIdByCSR()
+	if err == nil {
+		return sendEstEnrollState(w, id, ei)
+	}
+	if !db.IsDbErrorNoRows(err) {
+		return &enrollError{ErrLookupCsr, getHttpCodeForDbError(err)}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	var de *structs.DeviceEntry
+	if payload.Type == requestPayloadTypeReenroll {
+		de, err = db.RenewEnroll(ei.TenantId, payload.DeviceId, ei.UserId,
+			payload.CSRHash, string(data), nil)
+		if err != nil {
+			return &enrollError{ErrRenewEnroll, getHttpCodeForDbError(err)}
+		}
+	} else {
+		de, err = db.CreateEnrollRecord(ei.TenantId, ei.UserId,
+			payload.CSRHash, string(data), nil)
+		if err != nil {
+			return &enrollError{ErrCreateEnroll, getHttpCodeForDbError(err)}
+		}
+	}
+	payload.ID = de.Id
+	payload.RequestId = de.RequestId
+
+	// a failed enroll answers later polls with failure, not pending
+	if err = pushToPendingEnrollQueue(payload); err != nil {
+		esLogger.Error("EST enroll handoff failed",
+			zap.String("ID", payload.ID.String()),
+			zap.Error(err))
+		failEnroll(payload.ID, ErrHandoffEnroll)
+		return &enrollError{ErrHandoffEnroll, http.StatusInternalServerError}
+	}
+
+	sendEstPending(w)
+	esLogger.Info(
+		"EST enroll queued",
+		zap.String("ID", payload.ID.String()),
+		zap.String("RequestID", de.RequestId),
+		zap.String("TenantID", ei.TenantId),
+		zap.String("Type", payload.Type),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// enrolls of a csr not visible to the token are a csr reused elsewhere
+func sendEstEnrollState(w http.ResponseWriter, id uuid.UUID,
+	ei *EnrollInfo) *enrollError {
+	state, eErr := getEnrollState(id, ei)
+	if eErr != nil {
+		return &enrollError{ErrDuplicateCsr, http.StatusConflict}
+	}
+	switch state.status {
+	case statusPending:
+		sendEstPending(w)
+		return nil
+	case statusEnrolled:
+		dc, err := db.GetEnrollDetailsById(id)
+		if err != nil {
+			return &enrollError{ErrLookupEnroll, getHttpCodeForDbError(err)}
+		}
+		certs, err := parseStoredCertificates(dc.Certificate)
+		if err != nil {
+			esLogger.Error("Failed to parse certificate of enroll",
+				zap.String("ID", id.String()),
+				zap.Error(err))
+			return &enrollError{ErrInternal, http.StatusInternalServerError}
+		}
+		return sendEstCertificates(w, certs)
+	case statusFailed:
+		return &enrollError{
+			fmt.Errorf("%w: %s", ErrEnrollFailed, state.failure.ErrorText),
+			http.StatusUnprocessableEntity,
+		}
+	}
+	return &enrollError{ErrDuplicateCsr, http.StatusConflict}
+}
+
+// EST uses 202 with Retry-After for pending requests
+func sendEstPending(w http.ResponseWriter) {
+	writeRetryAfter(w, getRetryAfterHint())
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func sendEstCertificates(w http.ResponseWriter,
+	certs []*x509.Certificate) *enrollError {
+	der, err := pkcs7.DegenerateCertificates(certs)
+	if err != nil {
+		esLogger.Error("Failed to encode certificates", zap.Error(err))
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypePkcs7CertsOnly)
+	w.Header().Set(headerContentTransferEncoding, transferEncodingBase64)
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, base64.StdEncoding.EncodeToString(der))
+	return nil
+}
+
+// read a pkcs#10 body into an enroll payload. EST sends base64 der.
+// raw der is accepted as well.
+func getEstPayload(r *http.Request, payloadType string) (
+	*enrollPayload, *enrollError) {
+	if ct := r.Header.Get(headerContentType); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || mediaType != contentTypePkcs10 {
+			return nil, &enrollError{ErrEstContentType,
+				http.StatusUnsupportedMediaType}
+		}
+	}
+	defer r.Body.Close()
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxEstRequestSize))
+	if err != nil {
+		return nil, &enrollError{ErrPayloadRead, http.StatusBadRequest}
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, &enrollError{ErrPayloadMissing, http.StatusBadRequest}
+	}
+	der, err := base64.StdEncoding.DecodeString(
+		strings.Join(strings.Fields(string(body)), ""))
+	if err != nil {
+		der = body
+	}
+	ep := enrollPayload{
+		CSR:  base64.StdEncoding.EncodeToString(der),
+		Type: payloadType,
+	}
+	if err = ep.loadCSR(); err != nil {
+		return nil, &enrollError{err, http.StatusBadRequest}
+	}
+	return &ep, nil
+}
+
+func getEstManagementService(r *http.Request) string {
+	if label, ok := mux.Vars(r)[paramEstLabel]; ok {
+		return label
+	}
+	return gServerConfig.DefaultManagementService
+}
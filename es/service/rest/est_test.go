@@ -0,0 +1,179 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+	"github.com/google/uuid"
+)
+
+func TestEstCACertsNotConfigured(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/est/cacerts", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestEstCACerts(t *testing.T) {
+	cert := newTestCertificate(t)
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	handleError(t, os.WriteFile(file, pem.EncodeToMemory(
+		&pem.Block{Type: pemTypeCertificate, Bytes: cert.Raw}), 0600))
+	handleError(t, loadCACerts(file))
+	defer func() { caCerts = nil }()
+
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/est/cacerts", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+	if ct := response.Header().Get(headerContentType); ct != contentTypePkcs7CertsOnly {
+		t.Errorf("Expected content type %s, found: %s", contentTypePkcs7CertsOnly, ct)
+	}
+	der, err := base64.StdEncoding.DecodeString(response.Body.String())
+	handleError(t, err)
+	certs, err := pkcs7.ParseCertificates(der)
+	handleError(t, err)
+	if len(certs) != 1 || !certs[0].Equal(cert) {
+		t.Errorf("Expected ca certificate in response")
+	}
+}
+
+func TestEstSimpleEnrollWithoutTokenTypeFails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, "/.well-known/est/simpleenroll",
+		strings.NewReader(newTestCSR(t, key)))
+	req.Header.Set(headerContentType, contentTypePkcs10)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+// reenroll is for device tokens only
+func TestEstSimpleReenrollWithUserTokenFails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, "/.well-known/est/simplereenroll",
+		strings.NewReader(newTestCSR(t, key)))
+	req.Header.Set(headerContentType, contentTypePkcs10)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, "Bearer token")
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestEstSimpleEnrollWithJsonFails(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/.well-known/est/hpconnect/simpleenroll",
+		strings.NewReader(`{"csr": ""}`))
+	req.Header.Set(headerContentType, contentTypeJson)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusUnsupportedMediaType, response.Code)
+}
+
+// the first request queues the csr. sending it again polls.
+func TestEstSimpleEnrollPolls(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	bearerToken := getBearerToken()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost,
+			"/.well-known/est/hpconnect/simpleenroll", strings.NewReader(csr))
+		req.Header.Set(headerContentType, contentTypePkcs10)
+		req.Header.Set(headerTokenType, "test")
+		req.Header.Set(headerAuthorization, bearerToken)
+		response := executeTestRequest(req)
+		checkTestResponseCode(t, http.StatusAccepted, response.Code)
+		if response.Header().Get(headerRetryAfter) == "" {
+			t.Errorf("Expected %s header", headerRetryAfter)
+		}
+	}
+}
+
+// the same csr from another tenant is a conflict
+func TestEstSimpleEnrollReusedCsrFails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	codes := []int{http.StatusAccepted, http.StatusConflict}
+	for _, code := range codes {
+		req, _ := http.NewRequest(http.MethodPost,
+			"/.well-known/est/hpconnect/simpleenroll", strings.NewReader(csr))
+		req.Header.Set(headerContentType, contentTypePkcs10)
+		req.Header.Set(headerTokenType, "test")
+		req.Header.Set(headerAuthorization, getBearerToken())
+		response := executeTestRequest(req)
+		checkTestResponseCode(t, code, response.Code)
+	}
+}
+
+// a csr enrolled before csrs were hashed in der form is not enrolled again
+func TestEstSimpleEnrollLegacyCsrHashFails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	csr := newTestCSR(t, key)
+	legacy := sha256.Sum256([]byte(csr))
+	_, err = db.CreateEnrollRecord(uuid.NewString(), uuid.NewString(),
+		fmt.Sprintf("%x\n", legacy), "{}", nil)
+	handleError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost,
+		"/.well-known/est/hpconnect/simpleenroll", strings.NewReader(csr))
+	req.Header.Set(headerContentType, contentTypePkcs10)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusConflict, response.Code)
+}
+
+func TestParseStoredCertificates(t *testing.T) {
+	cert := newTestCertificate(t)
+	stored := []string{
+		base64.StdEncoding.EncodeToString(cert.Raw),
+		base64.StdEncoding.EncodeToString(pem.EncodeToMemory(
+			&pem.Block{Type: pemTypeCertificate, Bytes: cert.Raw})),
+	}
+	for i, s := range stored {
+		certs, err := parseStoredCertificates(s)
+		if err != nil {
+			t.Errorf("%d: expected no error, found: %v", i, err)
+		} else if len(certs) != 1 || !certs[0].Equal(cert) {
+			t.Errorf("%d: certificate does not match", i)
+		}
+	}
+}
+
+func newTestCertificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test ca"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	handleError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	handleError(t, err)
+	return cert
+}
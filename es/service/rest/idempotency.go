@@ -0,0 +1,84 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"go.uber.org/zap"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+)
+
+// get idempotency key and request hash for this request.
+// returns nil if the request does not have an Idempotency-Key header.
+// parts identify the request. a repeat with the same key must match.
+func getIdempotentRequest(r *http.Request, parts ...string) (
+	*structs.IdempotentRequest, error) {
+	key := r.Header.Get(headerIdempotencyKey)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return &structs.IdempotentRequest{
+		Key:         key,
+		RequestHash: fmt.Sprintf("%x", h.Sum(nil)),
+	}, nil
+}
+
+// if the idempotency key was seen before, send the original response.
+// returns true if a response (or error) was produced for the request.
+func replayIdempotentRequest(w http.ResponseWriter, kind, tenantId string,
+	ir *structs.IdempotentRequest, startTime time.Time) (bool, *enrollError) {
+	if ir == nil {
+		return false, nil
+	}
+	prev, err := db.GetIdempotentRequest(kind, tenantId, ir.Key)
+	if err != nil {
+		if db.IsDbErrorNoRows(err) {
+			return false, nil
+		}
+		return true, &enrollError{ErrLookupIdempotencyKey,
+			getHttpCodeForDbError(err)}
+	}
+	if prev.RequestHash != ir.RequestHash {
+		esLogger.Error("Idempotency key reused with a different request",
+			zap.String("key", ir.Key),
+			zap.String("tenant_id", tenantId))
+		return true, &enrollError{ErrIdempotencyKeyMismatch,
+			http.StatusUnprocessableEntity}
+	}
+	esLogger.Info("Replaying idempotent request",
+		zap.String("key", ir.Key),
+		zap.String("id", prev.Id.String()),
+		zap.String("tenant_id", tenantId))
+	return true, sendEnrollResponse(w, &structs.DeviceEntry{
+		Id:        prev.Id,
+		RequestId: prev.RequestId,
+	}, startTime)
+}
+
+// a concurrent request with the same idempotency key won the insert.
+// reply with its result.
+func replayOnIdempotencyConflict(w http.ResponseWriter, kind, tenantId string,
+	ir *structs.IdempotentRequest, err error, startTime time.Time) (bool, *enrollError) {
+	if ir == nil || !db.IsDbErrorUniqueViolation(err) {
+		return false, nil
+	}
+	return replayIdempotentRequest(w, kind, tenantId, ir, startTime)
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/google/uuid"
+)
+
+func TestGetIdempotentRequest(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/enroll", nil)
+	ir, err := getIdempotentRequest(req, "enroll", "payload")
+	if ir != nil || err != nil {
+		t.Errorf("Expected no idempotent request without header, got %v, %v",
+			ir, err)
+	}
+
+	req.Header.Set(headerIdempotencyKey, "key1")
+	ir1, err := getIdempotentRequest(req, "enroll", "payload")
+	handleError(t, err)
+	ir2, err := getIdempotentRequest(req, "enroll", "payload2")
+	handleError(t, err)
+	if ir1.RequestHash == ir2.RequestHash {
+		t.Errorf("Expected different hashes for different requests")
+	}
+
+	req.Header.Set(headerIdempotencyKey,
+		strings.Repeat("k", maxIdempotencyKeyLength+1))
+	if _, err = getIdempotentRequest(req, "enroll"); err != ErrInvalidIdempotencyKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidIdempotencyKey, err)
+	}
+}
+
+// repeat unenroll with the same key returns the original unenroll id
+func TestUnenrollWithIdempotencyKeyReplays(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	unEnrollUrl := fmt.Sprintf("/api/v1/enroll/%s", info.deviceId)
+	req, _ := http.NewRequest(http.MethodDelete, unEnrollUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, bearerToken)
+	req.Header.Set(headerIdempotencyKey, uuid.New().String())
+
+	// record the original request
+	ir, err := getIdempotentRequest(req, requestPayloadTypeUnenroll,
+		info.deviceId)
+	handleError(t, err)
+	de, err := db.Unenroll(info.tenantId, uuid.MustParse(info.deviceId), "", ir)
+	handleError(t, err)
+
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusAccepted, response.Code)
+	var res enrollResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode unenroll response: %v", err)
+	}
+	if res.ID != de.Id {
+		t.Errorf("Expected replayed id %v, got %v", de.Id, res.ID)
+	}
+}
+
+// same key with a different request fails
+func TestUnenrollWithReusedIdempotencyKeyFails(t *testing.T) {
+	info, bearerToken := getDeviceToken()
+	key := uuid.New().String()
+	unEnrollUrl := fmt.Sprintf("/api/v1/enroll/%s", info.deviceId)
+	req, _ := http.NewRequest(http.MethodDelete, unEnrollUrl, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, bearerToken)
+	req.Header.Set(headerIdempotencyKey, key)
+
+	// original request was for another device in the same tenant
+	ir, err := getIdempotentRequest(req, requestPayloadTypeUnenroll,
+		uuid.New().String())
+	handleError(t, err)
+	_, err = db.Unenroll(info.tenantId, uuid.New(), "", ir)
+	handleError(t, err)
+
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusUnprocessableEntity, response.Code)
+}
@@ -0,0 +1,391 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	openApiVersion    = "3.0.3"
+	openApiTitle      = "HP Device Enrollment Service"
+	openApiDocVersion = "1"
+
+	securitySchemeBearer = "bearerAuth"
+	schemaRefPrefix      = "#/components/schemas/"
+
+	// struct tag for schema hints. readonly fields are set by es.
+	tagOpenApi      = "openapi"
+	openApiReadOnly = "readonly"
+)
+
+var (
+	// generated with the router from registered routes
+	openApiDocJson []byte
+
+	typeTime = reflect.TypeOf(time.Time{})
+	typeUUID = reflect.TypeOf(uuid.UUID{})
+	typeRaw  = reflect.TypeOf(json.RawMessage{})
+)
+
+type openApiDoc struct {
+	OpenApi    string                                  `json:"openapi"`
+	Info       openApiInfo                             `json:"info"`
+	Paths      map[string]map[string]*openApiOperation `json:"paths"`
+	Components openApiComponents                       `json:"components"`
+}
+
+type openApiInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openApiComponents struct {
+	Schemas         map[string]*openApiSchema         `json:"schemas"`
+	SecuritySchemes map[string]*openApiSecurityScheme `json:"securitySchemes"`
+}
+
+type openApiSecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type openApiOperation struct {
+	OperationId string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*openApiParameter         `json:"parameters,omitempty"`
+	RequestBody *openApiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openApiResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openApiParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openApiSchema `json:"schema"`
+}
+
+type openApiRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openApiMediaType `json:"content"`
+}
+
+type openApiResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openApiMediaType `json:"content,omitempty"`
+}
+
+type openApiMediaType struct {
+	Schema *openApiSchema `json:"schema"`
+}
+
+type openApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Items                *openApiSchema            `json:"items,omitempty"`
+	Properties           map[string]*openApiSchema `json:"properties,omitempty"`
+	AdditionalProperties *openApiSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*openApiSchema          `json:"oneOf,omitempty"`
+	ReadOnly             bool                      `json:"readOnly,omitempty"`
+}
+
+/*
+	Get /openapi.json
+	OpenAPI 3 description of this service generated from registered routes.
+
+Returns:
+- 200
+  - openapi document as json
+
+Errors:
+- 500
+  - should not be here. yet, here we are.
+*/
+func GetOpenApi(w http.ResponseWriter, r *http.Request) *enrollError {
+	if openApiDocJson == nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypeJson)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openApiDocJson)
+	return nil
+}
+
+func initOpenApiDoc(rs routes) {
+	var err error
+	openApiDocJson, err = json.Marshal(newOpenApiDoc(rs))
+	if err != nil {
+		esLogger.Error("Failed to generate openapi document", zap.Error(err))
+	}
+}
+
+// build document from routes and their docs. routes without docs
+// are listed with only the error response.
+func newOpenApiDoc(rs routes) *openApiDoc {
+	g := schemaGenerator{schemas: map[string]*openApiSchema{}}
+	doc := openApiDoc{
+		OpenApi: openApiVersion,
+		Info: openApiInfo{
+			Title:   openApiTitle,
+			Version: openApiDocVersion,
+		},
+		Paths: map[string]map[string]*openApiOperation{},
+		Components: openApiComponents{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]*openApiSecurityScheme{
+				securitySchemeBearer: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+			},
+		},
+	}
+	errorSchema := g.schemaOf(reflect.TypeOf(ProblemDetails{}))
+	for _, route := range rs {
+		path, params := getOpenApiPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openApiOperation{}
+		}
+		rd := routeDocs[route.Name]
+		op := openApiOperation{
+			OperationId: route.Name,
+			Summary:     rd.Summary,
+			Description: rd.Description,
+			Tags:        rd.Tags,
+			Parameters:  params,
+			Responses: map[string]*openApiResponse{
+				"default": {
+					Description: "error",
+					Content: map[string]*openApiMediaType{
+						contentTypeProblemJson: {Schema: errorSchema},
+					},
+				},
+			},
+		}
+		if len(rd.TokenTypes) > 0 {
+			op.Security = []map[string][]string{{securitySchemeBearer: {}}}
+			op.Parameters = append(op.Parameters, &openApiParameter{
+				Name:     headerTokenType,
+				In:       "header",
+				Required: true,
+				Schema:   &openApiSchema{Type: "string", Enum: rd.TokenTypes},
+			})
+		}
+		for _, h := range rd.Headers {
+			op.Parameters = append(op.Parameters, &openApiParameter{
+				Name:        h.Name,
+				In:          "header",
+				Description: h.Description,
+				Schema:      &openApiSchema{Type: "string"},
+			})
+		}
+		for _, q := range rd.Query {
+			op.Parameters = append(op.Parameters, &openApiParameter{
+				Name:        q.Name,
+				In:          "query",
+				Description: q.Description,
+				Schema:      &openApiSchema{Type: "string"},
+			})
+		}
+		if rd.Request != nil {
+			contentType := rd.RequestContentType
+			if contentType == "" {
+				contentType = contentTypeJson
+			}
+			op.RequestBody = &openApiRequestBody{
+				Required: true,
+				Content: map[string]*openApiMediaType{
+					contentType: {Schema: g.schemaOf(reflect.TypeOf(rd.Request))},
+				},
+			}
+		}
+		for code, res := range rd.Responses {
+			r := openApiResponse{Description: res.Description}
+			if schema := g.oneOf(res.Bodies); schema != nil {
+				contentType := res.ContentType
+				if contentType == "" {
+					contentType = contentTypeJson
+				}
+				r.Content = map[string]*openApiMediaType{
+					contentType: {Schema: schema},
+				}
+			}
+			op.Responses[strconv.Itoa(code)] = &r
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = &op
+	}
+	return &doc
+}
+
+// convert a mux path template to openapi. {name:pattern} becomes {name}.
+// patterns may have braces of their own so they are skipped by depth.
+func getOpenApiPath(muxPath string) (string, []*openApiParameter) {
+	var b strings.Builder
+	var params []*openApiParameter
+	for i := 0; i < len(muxPath); i++ {
+		if muxPath[i] != '{' {
+			b.WriteByte(muxPath[i])
+			continue
+		}
+		depth := 1
+		end := i + 1
+		for ; end < len(muxPath) && depth > 0; end++ {
+			switch muxPath[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		name, pattern, _ := strings.Cut(muxPath[i+1:end-1], ":")
+		schema := &openApiSchema{Type: "string"}
+		if pattern == uuidRegex {
+			schema.Format = "uuid"
+		}
+		params = append(params, &openApiParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+		fmt.Fprintf(&b, "{%s}", name)
+		i = end - 1
+	}
+	return b.String(), params
+}
+
+// generates schemas for go types from their json tags.
+// named structs are added as components and referenced.
+type schemaGenerator struct {
+	schemas map[string]*openApiSchema
+}
+
+func (g *schemaGenerator) oneOf(bodies []interface{}) *openApiSchema {
+	switch len(bodies) {
+	case 0:
+		return nil
+	case 1:
+		return g.schemaOf(reflect.TypeOf(bodies[0]))
+	}
+	schema := openApiSchema{}
+	for _, b := range bodies {
+		schema.OneOf = append(schema.OneOf, g.schemaOf(reflect.TypeOf(b)))
+	}
+	return &schema
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *openApiSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case typeTime:
+		return &openApiSchema{Type: "string", Format: "date-time"}
+	case typeUUID:
+		return &openApiSchema{Type: "string", Format: "uuid"}
+	case typeRaw:
+		return &openApiSchema{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &openApiSchema{Type: "string"}
+	case reflect.Bool:
+		return &openApiSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openApiSchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &openApiSchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openApiSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &openApiSchema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &openApiSchema{
+			Type:                 "object",
+			AdditionalProperties: g.schemaOf(t.Elem()),
+		}
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+	// interface values can be anything
+	return &openApiSchema{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *openApiSchema {
+	name := getSchemaName(t)
+	if name != "" {
+		if _, ok := g.schemas[name]; ok {
+			return &openApiSchema{Ref: schemaRefPrefix + name}
+		}
+		// reserve name before walking fields in case of recursion
+		g.schemas[name] = &openApiSchema{}
+	}
+	schema := openApiSchema{
+		Type:       "object",
+		Properties: map[string]*openApiSchema{},
+	}
+	g.addProperties(&schema, t)
+	if name == "" {
+		return &schema
+	}
+	*g.schemas[name] = schema
+	return &openApiSchema{Ref: schemaRefPrefix + name}
+}
+
+// embedded structs without a json name are flattened as in encoding/json
+func (g *schemaGenerator) addProperties(schema *openApiSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" &&
+			f.Type.Kind() == reflect.Struct {
+			g.addProperties(schema, f.Type)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+		fs := g.schemaOf(f.Type)
+		if f.Tag.Get(tagOpenApi) == openApiReadOnly {
+			fs.ReadOnly = true
+		}
+		schema.Properties[jsonName] = fs
+	}
+}
+
+// component name is the go type name with an upper case first letter
+func getSchemaName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return ""
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,424 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"github.com/HPInc/krypton-es/es/service/attestation"
+	dstsclient "github.com/HPInc/krypton-es/es/service/client/dsts"
+	"github.com/HPInc/krypton-es/es/service/policy"
+	"github.com/HPInc/krypton-es/es/service/structs"
+)
+
+const (
+	tagOps      = "ops"
+	tagDevice   = "device"
+	tagAdmin    = "admin"
+	tagApp      = "app"
+	tagInternal = "internal"
+	tagDocs     = "docs"
+)
+
+// api contract of a route. every registered route must have one.
+// bodies are zero values of the json types sent or received.
+type routeDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// accepted X-HP-Token-Type values. empty if route is not authenticated.
+	TokenTypes []string
+	Headers    []paramDoc
+	Query      []paramDoc
+	Request    interface{}
+	// defaults to json
+	RequestContentType string
+	Responses          map[int]responseDoc
+}
+
+type paramDoc struct {
+	Name        string
+	Description string
+}
+
+type responseDoc struct {
+	Description string
+	// defaults to json
+	ContentType string
+	// more than one body is documented as oneOf
+	Bodies []interface{}
+}
+
+var (
+	// any token with a tenant
+	tenantTokenTypes = []string{"azuread", "device", "enrollment", "test"}
+	userTokenTypes   = []string{"azuread", "test"}
+	deviceTokenTypes = []string{"device"}
+	appTokenTypes    = []string{"app"}
+	// user tokens or apps allowed for the tenant
+	adminTokenTypes = []string{"azuread", "test", "app"}
+
+	idempotencyKeyHeader = paramDoc{
+		Name: headerIdempotencyKey,
+		Description: "optional. a repeat with the same key and payload " +
+			"returns the original response",
+	}
+	pageQuery = []paramDoc{
+		{Name: queryPageSize, Description: "1 - 200. default 50"},
+		{Name: queryPageToken, Description: "next_page_token from a previous response"},
+	}
+	acceptedResponse = responseDoc{
+		Description: "request is accepted and will eventually be processed",
+		Bodies:      []interface{}{enrollResponse{}},
+	}
+	emptyResponse = responseDoc{Description: "success"}
+
+	// EST bodies are base64 der
+	estCertsResponse = responseDoc{
+		Description: "base64 certs-only pkcs7",
+		ContentType: contentTypePkcs7CertsOnly,
+		Bodies:      []interface{}{""},
+	}
+	estEnrollResponses = map[int]responseDoc{
+		200: estCertsResponse,
+		202: {Description: "csr is pending. send it again after Retry-After"},
+	}
+
+	// SCEP bodies are der
+	scepOperationQuery = paramDoc{
+		Name:        queryScepOperation,
+		Description: "GetCACaps, GetCACert or PKIOperation",
+	}
+	scepMessageResponse = responseDoc{
+		Description: "CertRep pki message. pkiStatus is SUCCESS, PENDING " +
+			"or FAILURE",
+		ContentType: contentTypeScepMessage,
+		Bodies:      []interface{}{""},
+	}
+)
+
+var routeDocs = map[string]routeDoc{
+	"GetHealth": {
+		Summary:   "Health check",
+		Tags:      []string{tagOps},
+		Responses: map[int]responseDoc{200: emptyResponse},
+	},
+	"GetMetrics": {
+		Summary: "Prometheus metrics",
+		Tags:    []string{tagOps},
+		Responses: map[int]responseDoc{200: {
+			Description: "metrics in prometheus text format",
+			ContentType: "text/plain",
+			Bodies:      []interface{}{""},
+		}},
+	},
+	"GetOpenApi": {
+		Summary:   "This document",
+		Tags:      []string{tagDocs},
+		Responses: map[int]responseDoc{200: {Description: "openapi document"}},
+	},
+	"EnrollDevice": {
+		Summary:    "Enroll a device",
+		Tags:       []string{tagDevice},
+		TokenTypes: tenantTokenTypes,
+		Headers:    []paramDoc{idempotencyKeyHeader},
+		Request:    enrollPayload{},
+		Responses:  map[int]responseDoc{202: acceptedResponse},
+	},
+	"EnrollDeviceBatch": {
+		Summary: "Enroll many devices",
+		Description: "Items are independent. Each result has the id of " +
+			"the pending enroll or the error a single enroll would return.",
+		Tags:       []string{tagDevice},
+		TokenTypes: tenantTokenTypes,
+		Request:    []enrollPayload{},
+		Responses: map[int]responseDoc{200: {
+			Description: "result of every item in payload order",
+			Bodies:      []interface{}{enrollBatchResponse{}},
+		}},
+	},
+	"GetAttestationChallenge": {
+		Summary: "Get a tpm credential activation challenge",
+		Description: "For a TPM AK without an AK certificate. The EK " +
+			"certificate must be issued by a trusted tpm manufacturer. " +
+			"The secret recovered with TPM2_ActivateCredential is sent " +
+			"with the challenge in the attestation of an enroll before " +
+			"the challenge expires.",
+		Tags:       []string{tagDevice},
+		TokenTypes: tenantTokenTypes,
+		Request:    attestation.ChallengeRequest{},
+		Responses: map[int]responseDoc{200: {
+			Description: "challenge for the AK",
+			Bodies:      []interface{}{attestation.Challenge{}},
+		}},
+	},
+	"GetEnrollmentStatus": {
+		Summary: "Get enroll or unenroll status",
+		Description: "429 with a Retry-After header while the request " +
+			"is pending. A failed status is terminal.",
+		Tags:       []string{tagDevice},
+		TokenTypes: tenantTokenTypes,
+		Headers: []paramDoc{{
+			Name: headerAccept,
+			Description: "optional. format of a completed enroll: " +
+				"application/json (default), application/pem-certificate-chain " +
+				"or application/pkcs7-mime",
+		}},
+		Query: []paramDoc{{
+			Name:        queryWait,
+			Description: "duration (30s) or seconds to wait for a pending status to change",
+		}},
+		Responses: map[int]responseDoc{200: {
+			Description: "enrolled, unenrolled or failed",
+			Bodies: []interface{}{enrollResultResponse{},
+				unenrollStatusResponse{}, failedStatusResponse{}},
+		}},
+	},
+	"GetEnrollmentEvents": {
+		Summary: "Stream enroll or unenroll status transitions",
+		Description: "Server sent events named pending, processing, " +
+			"enrolled, unenrolled or failed. The stream ends after a " +
+			"terminal status.",
+		Tags:       []string{tagDevice},
+		TokenTypes: tenantTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "event stream. data of each event is json",
+			ContentType: contentTypeEventStream,
+			Bodies:      []interface{}{enrollEvent{}},
+		}},
+	},
+	"UnenrollDevice": {
+		Summary:    "Unenroll a device",
+		Tags:       []string{tagDevice},
+		TokenTypes: deviceTokenTypes,
+		Headers:    []paramDoc{idempotencyKeyHeader},
+		Responses:  map[int]responseDoc{202: acceptedResponse},
+	},
+	"ReEnrollDevice": {
+		Summary:    "Renew device certificate",
+		Tags:       []string{tagDevice},
+		TokenTypes: deviceTokenTypes,
+		Headers:    []paramDoc{idempotencyKeyHeader},
+		Request:    enrollPayload{},
+		Responses:  map[int]responseDoc{202: acceptedResponse},
+	},
+	"EstGetCACerts": {
+		Summary:   "EST ca certificates",
+		Tags:      []string{tagDevice},
+		Responses: map[int]responseDoc{200: estCertsResponse},
+	},
+	"EstSimpleEnroll": {
+		Summary: "EST enroll",
+		Description: "The csr is queued like an enroll. Send the same csr " +
+			"again to get the certificate. mgmt_service is " +
+			"server.default_management_service.",
+		Tags:               []string{tagDevice},
+		TokenTypes:         tenantTokenTypes,
+		Request:            "",
+		RequestContentType: contentTypePkcs10,
+		Responses:          estEnrollResponses,
+	},
+	"EstSimpleEnrollWithLabel": {
+		Summary:            "EST enroll with the label as mgmt_service",
+		Tags:               []string{tagDevice},
+		TokenTypes:         tenantTokenTypes,
+		Request:            "",
+		RequestContentType: contentTypePkcs10,
+		Responses:          estEnrollResponses,
+	},
+	"EstSimpleReenroll": {
+		Summary:            "EST renew of device certificate",
+		Tags:               []string{tagDevice},
+		TokenTypes:         deviceTokenTypes,
+		Request:            "",
+		RequestContentType: contentTypePkcs10,
+		Responses:          estEnrollResponses,
+	},
+	"ScepGet": {
+		Summary: "SCEP operations",
+		Description: "PKIOperation takes the base64 pki message in message. " +
+			"The challenge password of the csr is an enrollment token for " +
+			"PKCSReq or a device token for RenewalReq.",
+		Tags: []string{tagDevice},
+		Query: []paramDoc{
+			scepOperationQuery,
+			{Name: queryScepMessage, Description: "base64 pki message"},
+		},
+		Responses: map[int]responseDoc{200: scepMessageResponse},
+	},
+	"ScepPost": {
+		Summary:            "SCEP PKIOperation",
+		Tags:               []string{tagDevice},
+		Query:              []paramDoc{scepOperationQuery},
+		Request:            "",
+		RequestContentType: contentTypeScepMessage,
+		Responses:          map[int]responseDoc{200: scepMessageResponse},
+	},
+	"CreateEnrollToken": {
+		Summary:    "Create bulk enroll token for tenant",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "enroll token",
+			Bodies:      []interface{}{dstsclient.EnrollToken{}},
+		}},
+	},
+	"DeleteEnrollToken": {
+		Summary:    "Delete bulk enroll token of tenant",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Responses:  map[int]responseDoc{200: emptyResponse},
+	},
+	"ListEnrollments": {
+		Summary:    "List enrolls of tenant",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Query: append([]paramDoc{
+			{Name: queryStatus, Description: "pending | enrolled"},
+			{Name: queryCreatedAfter, Description: "RFC3339 timestamp"},
+		}, pageQuery...),
+		Responses: map[int]responseDoc{200: {
+			Description: "page of enrolls",
+			Bodies:      []interface{}{listEnrollsResponse{}},
+		}},
+	},
+	"GetDeviceHistory": {
+		Summary:    "Get enroll history of a device",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "history, oldest first",
+			Bodies:      []interface{}{deviceHistoryResponse{}},
+		}},
+	},
+	"AdminUnenrollDevice": {
+		Summary: "Unenroll a device of a tenant without a device token",
+		Description: "For lost or broken devices. The unenroll is recorded " +
+			"with the user id of the token and can be followed " +
+			"in the device history. App tokens must be for an app " +
+			"allowed for the tenant.",
+		Tags:       []string{tagAdmin},
+		TokenTypes: adminTokenTypes,
+		Headers:    []paramDoc{idempotencyKeyHeader},
+		Responses:  map[int]responseDoc{202: acceptedResponse},
+	},
+	"RetryEnroll": {
+		Summary:    "Retry a failed enroll",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Responses:  map[int]responseDoc{202: acceptedResponse},
+	},
+	"CreateWebhook": {
+		Summary: "Register a webhook for enroll lifecycle events",
+		Description: "Deliveries are signed. X-HP-Webhook-Signature is " +
+			"sha256=<hex hmac-sha256 of \"<X-HP-Webhook-Timestamp>.<body>\">.",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Request:    createWebhookRequest{},
+		Responses: map[int]responseDoc{201: {
+			Description: "webhook with its secret. secret is not returned again.",
+			Bodies:      []interface{}{structs.Webhook{}},
+		}},
+	},
+	"ListWebhooks": {
+		Summary:    "List webhooks of tenant",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "webhooks without secrets",
+			Bodies:      []interface{}{listWebhooksResponse{}},
+		}},
+	},
+	"DeleteWebhook": {
+		Summary:    "Delete a webhook and its delivery log",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Responses:  map[int]responseDoc{200: emptyResponse},
+	},
+	"ListWebhookDeliveries": {
+		Summary:    "Delivery log of a webhook",
+		Tags:       []string{tagAdmin},
+		TokenTypes: userTokenTypes,
+		Query:      pageQuery[:1],
+		Responses: map[int]responseDoc{200: {
+			Description: "deliveries, newest first",
+			Bodies:      []interface{}{listWebhookDeliveriesResponse{}},
+		}},
+	},
+	"CreatePolicy": {
+		Summary:    "Create policy for tenant",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Request:    policy.Policy{},
+		Responses: map[int]responseDoc{
+			200: {
+				Description: "created policy",
+				Bodies:      []interface{}{createPolicyResult{}},
+			},
+			409: {
+				Description: "tenant has a policy. use update",
+				Bodies:      []interface{}{policyExistsResponse{}},
+			},
+		},
+	},
+	"GetPolicyInfo": {
+		Summary:    "Check if tenant has a policy",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "policy id in " + headerPolicyId + " header",
+		}},
+	},
+	"GetPolicy": {
+		Summary:    "Get policy",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "policy",
+			Bodies:      []interface{}{structs.Policy{}},
+		}},
+	},
+	"UpdatePolicy": {
+		Summary:    "Update policy",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Request:    policy.Policy{},
+		Responses:  map[int]responseDoc{200: emptyResponse},
+	},
+	"DeletePolicy": {
+		Summary:    "Delete policy",
+		Tags:       []string{tagAdmin},
+		TokenTypes: tenantTokenTypes,
+		Responses:  map[int]responseDoc{200: emptyResponse},
+	},
+	"GetEnrollToken": {
+		Summary:    "Get bulk enroll token of a tenant",
+		Tags:       []string{tagApp},
+		TokenTypes: appTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "enroll token",
+			Bodies:      []interface{}{dstsclient.EnrollToken{}},
+		}},
+	},
+	"ListSigningKeys": {
+		Summary:    "List token signing keys",
+		Tags:       []string{tagApp},
+		TokenTypes: appTokenTypes,
+		Responses: map[int]responseDoc{200: {
+			Description: "keys by key source, including revoked keys",
+			Bodies:      []interface{}{listSigningKeysResponse{}},
+		}},
+	},
+	"RevokeSigningKey": {
+		Summary: "Revoke a token signing key",
+		Description: "Tokens signed with the key are rejected by all " +
+			"replicas even if its key source still publishes it.",
+		Tags:       []string{tagApp},
+		TokenTypes: appTokenTypes,
+		Responses:  map[int]responseDoc{200: emptyResponse},
+	},
+	"DeleteExpiredEnroll": {
+		Summary:   "Delete expired enrolls",
+		Tags:      []string{tagInternal},
+		Responses: map[int]responseDoc{200: emptyResponse},
+	},
+}
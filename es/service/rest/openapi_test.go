@@ -0,0 +1,82 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// every route must document its contract
+func TestOpenApiDocsCoverRoutes(t *testing.T) {
+	for _, route := range registeredRoutes {
+		if _, ok := routeDocs[route.Name]; !ok {
+			t.Errorf("Route %s has no openapi doc", route.Name)
+		}
+	}
+	for name := range routeDocs {
+		found := false
+		for _, route := range registeredRoutes {
+			found = found || route.Name == name
+		}
+		if !found {
+			t.Errorf("Openapi doc %s has no route", name)
+		}
+	}
+}
+
+func TestOpenApiPath(t *testing.T) {
+	path, params := getOpenApiPath(
+		fmt.Sprintf("/api/v1/enroll/{enroll_id:%s}/events", uuidRegex))
+	if path != "/api/v1/enroll/{enroll_id}/events" {
+		t.Errorf("Unexpected path: %s", path)
+	}
+	if len(params) != 1 || params[0].Name != "enroll_id" ||
+		params[0].In != "path" || params[0].Schema.Format != "uuid" {
+		t.Errorf("Unexpected path params: %+v", params)
+	}
+}
+
+func TestGetOpenApi(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+
+	var doc openApiDoc
+	if err := json.NewDecoder(response.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode openapi document: %v", err)
+	}
+	for _, route := range registeredRoutes {
+		path, _ := getOpenApiPath(route.Path)
+		if doc.Paths[path][strings.ToLower(route.Method)] == nil {
+			t.Errorf("Route %s %s is missing", route.Method, path)
+		}
+	}
+
+	// fields set by es are read only in request schema
+	ep := doc.Components.Schemas["EnrollPayload"]
+	if ep == nil {
+		t.Fatalf("EnrollPayload schema is missing")
+	}
+	if ep.Properties["csr"] == nil || ep.Properties["csr"].ReadOnly {
+		t.Errorf("Expected csr to be writable: %+v", ep.Properties["csr"])
+	}
+	if ep.Properties["id"] == nil || !ep.Properties["id"].ReadOnly {
+		t.Errorf("Expected id to be read only: %+v", ep.Properties["id"])
+	}
+
+	// embedded structs are flattened
+	er := doc.Components.Schemas["EnrollResultResponse"]
+	if er == nil {
+		t.Fatalf("EnrollResultResponse schema is missing")
+	}
+	for _, name := range []string{"certificate", "certificate_info"} {
+		if er.Properties[name] == nil {
+			t.Errorf("Expected %s in EnrollResultResponse", name)
+		}
+	}
+}
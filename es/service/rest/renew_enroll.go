@@ -4,6 +4,7 @@
 package rest
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -30,6 +31,8 @@ Requires:
 - Custom header: X-HP-Token-Type
   - Value: "enrollment"
   - Authorization header: Bearer <Token>
+  - Optional header: Idempotency-Key. A repeat with the same key and
+    payload returns the original response.
   - Payload:
     {
     "csr":"<base64 encoded part of csr without pem headers>"
@@ -43,6 +46,11 @@ Errors:
 - 400
   - Malformed or missing Authorization header
   - Malformed or missing payload
+  - csr is not a pkcs#10 request, its signature does not verify or
+    its key type or size is not supported (rsa 2048 - 8192, ecdsa p-256,
+    p-384, p-521)
+  - csr does not meet a csr requirement in tenant policy. the error
+    names the policy attribute
 
 - 401
   - Could not verify token
@@ -52,6 +60,9 @@ Errors:
 - 405
   - Must be PATCH
 
+- 422
+  - Idempotency-Key was used with a different payload
+
 - 500
   - should not be here. yet, here we are.
 */
@@ -90,9 +101,36 @@ func RenewEnroll(w http.ResponseWriter, r *http.Request) *enrollError {
 	payload.TenantId = ei.TenantId
 	payload.DeviceId = deviceId
 
+	p, eErr := getCSRPolicy(ei.TenantId)
+	if eErr != nil {
+		return eErr
+	}
+	if eErr = checkCSRPolicy(p, payload); eErr != nil {
+		return eErr
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	// a repeat with the same Idempotency-Key gets the original response
+	ir, err := getIdempotentRequest(r, requestPayloadTypeReenroll, string(data))
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if ok, eErr := replayIdempotentRequest(w, db.IdempotencyKindEnroll,
+		ei.TenantId, ir, startTime); ok {
+		return eErr
+	}
+
 	de, err := db.RenewEnroll(
-		ei.TenantId, payload.DeviceId, ei.UserId, payload.CSRHash)
+		ei.TenantId, payload.DeviceId, ei.UserId, payload.CSRHash, string(data), ir)
 	if err != nil {
+		if ok, eErr := replayOnIdempotencyConflict(w, db.IdempotencyKindEnroll,
+			ei.TenantId, ir, err, startTime); ok {
+			return eErr
+		}
 		return &enrollError{ErrRenewEnroll, getHttpCodeForDbError(err)}
 	}
 	payload.ID = de.Id
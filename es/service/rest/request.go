@@ -19,6 +19,7 @@ const (
 	headerRetryAfter         = "Retry-After"
 	headerAuthorization      = "Authorization"
 	headerTokenType          = "X-HP-Token-Type" //#nosec G101
+	headerIdempotencyKey     = "Idempotency-Key"
 	bearerToken              = "Bearer "
 
 	contentTypeFormUrlEncoded = "application/x-www-form-urlencoded"
@@ -27,10 +28,20 @@ const (
 	contentTypeOptionNoSniff  = "nosniff"
 
 	// Request parameters
-	paramTenantID = "tenant_id"
-	paramDeviceID = "device_id"
-	paramEnrollID = "enroll_id"
-	paramPolicyId = "policy_id"
+	paramTenantID  = "tenant_id"
+	paramDeviceID  = "device_id"
+	paramEnrollID  = "enroll_id"
+	paramPolicyId  = "policy_id"
+	paramWebhookId = "webhook_id"
+	paramKeySource = "key_source"
+	paramKid       = "kid"
+
+	// Query parameters
+	queryStatus       = "status"
+	queryCreatedAfter = "created_after"
+	queryPageToken    = "page_token"
+	queryPageSize     = "page_size"
+	queryWait         = "wait"
 
 	requestPayloadTypeEnroll   = "enroll"
 	requestPayloadTypeReenroll = "renew_enroll"
@@ -42,12 +53,12 @@ func getUUIDParam(r *http.Request, name string) (uuid.UUID, *enrollError) {
 	vars := mux.Vars(r)
 	idstring, ok := vars[name]
 	if !ok {
-		err = fmt.Errorf("require %s in path", name)
+		err = fmt.Errorf("%w: %s", ErrMissingPathParam, name)
 		return uuid.Nil, &enrollError{err, http.StatusBadRequest}
 	}
 	id, err := uuid.Parse(idstring)
 	if err != nil {
-		err = fmt.Errorf("%s must be a uuid", name)
+		err = fmt.Errorf("%w: %s", ErrInvalidUUIDParam, name)
 		return uuid.Nil, &enrollError{err, http.StatusBadRequest}
 	}
 	return id, nil
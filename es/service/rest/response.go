@@ -11,7 +11,17 @@ import (
 )
 
 const (
-	headerPolicyId = "x-hp-policy-id"
+	headerPolicyId     = "x-hp-policy-id"
+	headerCacheControl = "Cache-Control"
+
+	contentTypeEventStream = "text/event-stream"
+	contentTypeProblemJson = "application/problem+json"
+	cacheControlNoCache    = "no-cache"
+
+	// problem types are urns named by the error code. they identify
+	// the problem and are not meant to be dereferenced.
+	problemTypeUrnPrefix = "urn:hp:krypton-es:error:"
+	problemTypeBlank     = "about:blank"
 )
 
 type policyExistsResponse struct {
@@ -64,12 +74,12 @@ func sendJsonResponse(w http.ResponseWriter, statusCode int,
 
 // modeling after http.Error source
 // https://go.dev/src/net/http/server.go?s=61907:61959#L2131
-func sendJsonError(w http.ResponseWriter, jsonError *JsonError) {
-	w.Header().Set(headerContentType, contentTypeJsonUtf8)
+func sendProblemDetails(w http.ResponseWriter, problem *ProblemDetails) {
+	w.Header().Set(headerContentType, contentTypeProblemJson)
 	w.Header().Set(headerContentTypeOptions, contentTypeOptionNoSniff)
 
-	w.WriteHeader(jsonError.Code)
-	if err := json.NewEncoder(w).Encode(jsonError); err != nil {
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
 		esLogger.Error("Error encoding response to json", zap.Error(err))
 	}
 }
@@ -0,0 +1,117 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+/*
+	Post /enroll/{enroll_id}/retry
+	Retry a failed enroll in the tenant of the bearer token.
+	The failed enroll is restored as pending with the same id and
+	its original payload is handed off for processing again.
+	Devices polling the enroll id will pick up the result.
+
+Returns:
+- 202 (request is accepted and will eventually be processed)
+  - id of pending enroll. This is the same id as the failed enroll.
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - enroll_id must be a uuid
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 404
+  - No failed enroll found with this id in tenant
+
+- 409
+  - Failed enroll has no payload to retry
+  - csr of failed enroll has since been used by another enroll
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func RetryEnroll(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+
+	if err := validateUserToken(r); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	id, eErr := getUUIDParam(r, paramEnrollID)
+	if eErr != nil {
+		return eErr
+	}
+
+	de, data, err := db.RetryEnrollRecord(id, ei.TenantId)
+	if err != nil {
+		esLogger.Error("RetryEnroll: could not restore failed enroll",
+			zap.String("enroll_id", id.String()),
+			zap.String("tenant_id", ei.TenantId),
+			zap.Error(err))
+		if errors.Is(err, db.ErrNoRetryPayload) ||
+			errors.Is(err, db.ErrRetryCsrInUse) {
+			return &enrollError{err, http.StatusConflict}
+		}
+		return &enrollError{ErrRetryEnroll, getHttpCodeForDbError(err)}
+	}
+
+	var payload enrollPayload
+	if err = json.Unmarshal([]byte(data), &payload); err != nil {
+		failRetriedEnroll(id, err)
+		return &enrollError{ErrRetryEnroll, http.StatusInternalServerError}
+	}
+	payload.ID = de.Id
+	payload.RequestId = de.RequestId
+	payload.TenantId = ei.TenantId
+
+	if err = pushToPendingEnrollQueue(&payload); err != nil {
+		failRetriedEnroll(id, err)
+		return &enrollError{ErrHandoffEnroll, http.StatusInternalServerError}
+	}
+
+	sendEnrollResponse(w, de, startTime)
+	esLogger.Info(
+		"Enroll retry queued",
+		zap.String("ID", id.String()),
+		zap.String("RequestID", de.RequestId),
+		zap.String("TenantID", ei.TenantId),
+		zap.String("Type", payload.Type),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// a restored enroll that could not be handed off would stay pending
+// until expiry. move it back to enroll_error so it can be retried again.
+func failRetriedEnroll(id uuid.UUID, err error) {
+	if fErr := db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     id.String(),
+		ErrorMessage: err.Error(),
+	}); fErr != nil {
+		esLogger.Error("RetryEnroll: could not fail enroll after retry error",
+			zap.String("enroll_id", id.String()),
+			zap.Error(fErr))
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// device tokens cannot retry enrolls
+func TestRetryEnrollWithDeviceTokenFails(t *testing.T) {
+	_, deviceToken := getDeviceToken()
+	path := fmt.Sprintf("/api/v1/enroll/%s/retry", uuid.New())
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, deviceToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestRetryEnrollForUnknownIdFails(t *testing.T) {
+	path := fmt.Sprintf("/api/v1/enroll/%s/retry", uuid.New())
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+// enroll failed without a stored payload cannot be retried
+func TestRetryEnrollWithoutPayloadFails(t *testing.T) {
+	bearerToken := getBearerToken()
+	tenantId := getTenantIdFromBearerToken(t, bearerToken)
+	de, err := db.CreateEnrollRecord(tenantId, "", uuid.New().String(), "", nil)
+	handleError(t, err)
+	handleError(t, db.FailEnrollRecord(&structs.EnrollError{
+		EnrollId:     de.Id.String(),
+		ErrorMessage: "ca unavailable",
+	}))
+
+	path := fmt.Sprintf("/api/v1/enroll/%s/retry", de.Id)
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusConflict, response.Code)
+}
@@ -72,6 +72,7 @@ func initRequestRouter() *mux.Router {
 			Name(route.Name).
 			Handler(handler)
 	}
+	initOpenApiDoc(registeredRoutes)
 	return router
 }
 
@@ -39,6 +39,14 @@ var registeredRoutes = routes{
 		HandlerFunc: promhttp.Handler().(http.HandlerFunc),
 	},
 
+	// OpenAPI description of these routes.
+	Route{
+		Name:        "GetOpenApi",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/openapi.json", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(GetOpenApi),
+	},
+
 	///////////////////////////////////////////////////////////////////////////
 	//                   External API routes (device facing)                 //
 	///////////////////////////////////////////////////////////////////////////
@@ -49,6 +57,20 @@ var registeredRoutes = routes{
 		HandlerFunc: esHandlerFunc(Enroll),
 	},
 
+	Route{
+		Name:        "EnrollDeviceBatch",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/enroll/batch", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(EnrollBatch),
+	},
+
+	Route{
+		Name:        "GetAttestationChallenge",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/attestation/challenge", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(GetAttestationChallenge),
+	},
+
 	Route{
 		Name:        "GetEnrollmentStatus",
 		Method:      http.MethodGet,
@@ -56,6 +78,13 @@ var registeredRoutes = routes{
 		HandlerFunc: esHandlerFunc(EnrollStatus),
 	},
 
+	Route{
+		Name:        "GetEnrollmentEvents",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/enroll/{enroll_id:%s}/events", apiUrlPrefix, uuidRegex),
+		HandlerFunc: esHandlerFunc(EnrollEvents),
+	},
+
 	Route{
 		Name:        "UnenrollDevice",
 		Method:      http.MethodDelete,
@@ -70,6 +99,54 @@ var registeredRoutes = routes{
 		HandlerFunc: esHandlerFunc(RenewEnroll),
 	},
 
+	///////////////////////////////////////////////////////////////////////////
+	//                   EST (RFC 7030) routes (device facing)               //
+	///////////////////////////////////////////////////////////////////////////
+	Route{
+		Name:        "EstGetCACerts",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/cacerts", estUrlPrefix),
+		HandlerFunc: esHandlerFunc(EstCACerts),
+	},
+
+	Route{
+		Name:        "EstSimpleEnroll",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/simpleenroll", estUrlPrefix),
+		HandlerFunc: esHandlerFunc(EstSimpleEnroll),
+	},
+
+	Route{
+		Name:        "EstSimpleEnrollWithLabel",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/{%s}/simpleenroll", estUrlPrefix, paramEstLabel),
+		HandlerFunc: esHandlerFunc(EstSimpleEnroll),
+	},
+
+	Route{
+		Name:        "EstSimpleReenroll",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/simplereenroll", estUrlPrefix),
+		HandlerFunc: esHandlerFunc(EstSimpleReenroll),
+	},
+
+	///////////////////////////////////////////////////////////////////////////
+	//                   SCEP (RFC 8894) routes
+	///////////////////////////////////////////////////////////////////////////
+	Route{
+		Name:        "ScepGet",
+		Method:      http.MethodGet,
+		Path:        scepUrl,
+		HandlerFunc: esHandlerFunc(ScepGet),
+	},
+
+	Route{
+		Name:        "ScepPost",
+		Method:      http.MethodPost,
+		Path:        scepUrl,
+		HandlerFunc: esHandlerFunc(ScepPost),
+	},
+
 	///////////////////////////////////////////////////////////////////////////
 	//                   Admin apis
 	///////////////////////////////////////////////////////////////////////////
@@ -87,6 +164,62 @@ var registeredRoutes = routes{
 		HandlerFunc: esHandlerFunc(DeleteEnrollToken),
 	},
 
+	Route{
+		Name:        "ListEnrollments",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/enroll", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(ListEnrolls),
+	},
+
+	Route{
+		Name:        "GetDeviceHistory",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/enroll/{device_id:%s}/history", apiUrlPrefix, uuidRegex),
+		HandlerFunc: esHandlerFunc(GetDeviceHistory),
+	},
+
+	Route{
+		Name:        "AdminUnenrollDevice",
+		Method:      http.MethodDelete,
+		Path:        fmt.Sprintf("%s/tenant/{tenant_id:%s}/enroll/{device_id:%s}", apiUrlPrefix, uuidRegex, uuidRegex),
+		HandlerFunc: esHandlerFunc(AdminUnenroll),
+	},
+
+	Route{
+		Name:        "RetryEnroll",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/enroll/{enroll_id:%s}/retry", apiUrlPrefix, uuidRegex),
+		HandlerFunc: esHandlerFunc(RetryEnroll),
+	},
+
+	Route{
+		Name:        "CreateWebhook",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/webhook", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(CreateWebhook),
+	},
+
+	Route{
+		Name:        "ListWebhooks",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/webhook", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(ListWebhooks),
+	},
+
+	Route{
+		Name:        "DeleteWebhook",
+		Method:      http.MethodDelete,
+		Path:        fmt.Sprintf("%s/webhook/{webhook_id:%s}", apiUrlPrefix, uuidRegex),
+		HandlerFunc: esHandlerFunc(DeleteWebhook),
+	},
+
+	Route{
+		Name:        "ListWebhookDeliveries",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/webhook/{webhook_id:%s}/deliveries", apiUrlPrefix, uuidRegex),
+		HandlerFunc: esHandlerFunc(ListWebhookDeliveries),
+	},
+
 	Route{
 		Name:        "CreatePolicy",
 		Method:      http.MethodPost,
@@ -132,6 +265,20 @@ var registeredRoutes = routes{
 		HandlerFunc: esHandlerFunc(GetEnrollToken),
 	},
 
+	Route{
+		Name:        "ListSigningKeys",
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("%s/signing_key", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(ListSigningKeys),
+	},
+
+	Route{
+		Name:        "RevokeSigningKey",
+		Method:      http.MethodPost,
+		Path:        fmt.Sprintf("%s/signing_key/{key_source}/{kid}/revoke", apiUrlPrefix),
+		HandlerFunc: esHandlerFunc(RevokeSigningKey),
+	},
+
 	///////////////////////////////////////////////////////////////////////////
 	//                   Internal maintenance API routes                     //
 	///////////////////////////////////////////////////////////////////////////
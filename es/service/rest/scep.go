@@ -0,0 +1,377 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+	"github.com/HPInc/krypton-es/es/service/scep"
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SCEP (RFC 8894) for devices that cannot do EST. the challenge password
+// is the token: an enrollment token for PKCSReq, a device token for
+// RenewalReq. requests are queued like any other enroll and the client
+// polls with CertPoll or by resending its request.
+const (
+	scepUrl = "/scep"
+
+	queryScepOperation = "operation"
+	queryScepMessage   = "message"
+
+	scepOperationGetCACaps    = "GetCACaps"
+	scepOperationGetCACert    = "GetCACert"
+	scepOperationPKIOperation = "PKIOperation"
+
+	contentTypeScepCACaps   = "text/plain"
+	contentTypeScepCARACert = "application/x-x509-ca-ra-cert"
+	contentTypeScepMessage  = "application/x-pki-message"
+
+	// a pki message with an enveloped csr of the largest key we accept
+	maxScepRequestSize = 64 * 1024
+)
+
+var (
+	// capabilities of this server, one per line
+	scepCACaps = strings.Join([]string{
+		"AES",
+		"POSTPKIOperation",
+		"Renewal",
+		"SHA-256",
+		"SCEPStandard",
+	}, "\n")
+
+	// registration authority. requests are enveloped to and responses are
+	// signed by it. loaded once at init.
+	scepRACert *x509.Certificate
+	scepRAKey  *rsa.PrivateKey
+)
+
+/*
+	Get /scep?operation=<GetCACaps|GetCACert|PKIOperation>
+	SCEP operations. PKIOperation takes the base64 pki message in the
+	message query parameter. Not authenticated. PKIOperation requests carry
+	their token in the csr challenge password.
+
+Returns:
+- 200
+  - GetCACaps: capabilities, text/plain
+  - GetCACert: degenerate pkcs7 of the ra and ca certificates
+    (application/x-x509-ca-ra-cert)
+  - PKIOperation: CertRep pki message (application/x-pki-message).
+    pkiStatus is SUCCESS with the device certificate, PENDING while the
+    enroll is queued or FAILURE with a failInfo.
+
+Errors:
+- 400
+  - operation is missing or not supported
+  - message is not a valid pki message
+
+- 404
+  - scep is not configured (server.scep_ra_cert_file)
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ScepGet(w http.ResponseWriter, r *http.Request) *enrollError {
+	if scepRACert == nil {
+		return &enrollError{ErrScepNotConfigured, http.StatusNotFound}
+	}
+	switch r.URL.Query().Get(queryScepOperation) {
+	case scepOperationGetCACaps:
+		w.Header().Set(headerContentType, contentTypeScepCACaps)
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, scepCACaps)
+		return nil
+	case scepOperationGetCACert:
+		return sendScepCACert(w)
+	case scepOperationPKIOperation:
+		// query values decode '+' to ' '. base64 has no spaces.
+		message := strings.ReplaceAll(
+			r.URL.Query().Get(queryScepMessage), " ", "+")
+		der, err := base64.StdEncoding.DecodeString(message)
+		if err != nil {
+			return &enrollError{ErrScepMessage, http.StatusBadRequest}
+		}
+		return scepPKIOperation(w, der)
+	}
+	return &enrollError{ErrScepOperation, http.StatusBadRequest}
+}
+
+/*
+	Post /scep?operation=PKIOperation
+	SCEP PKIOperation with the der pki message as payload. See Get /scep.
+
+Returns:
+- 200
+  - CertRep pki message (application/x-pki-message)
+
+Errors:
+- 400
+  - operation must be PKIOperation
+  - payload is not a valid pki message
+
+- 404
+  - scep is not configured (server.scep_ra_cert_file)
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ScepPost(w http.ResponseWriter, r *http.Request) *enrollError {
+	if scepRACert == nil {
+		return &enrollError{ErrScepNotConfigured, http.StatusNotFound}
+	}
+	if r.URL.Query().Get(queryScepOperation) != scepOperationPKIOperation {
+		return &enrollError{ErrScepOperation, http.StatusBadRequest}
+	}
+	defer r.Body.Close()
+	der, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxScepRequestSize))
+	if err != nil {
+		return &enrollError{ErrPayloadRead, http.StatusBadRequest}
+	}
+	return scepPKIOperation(w, der)
+}
+
+// a known transaction is a poll. anything else is a new request.
+func scepPKIOperation(w http.ResponseWriter, der []byte) *enrollError {
+	startTime := time.Now()
+
+	m, err := scep.ParsePKIMessage(der)
+	if err != nil {
+		esLogger.Error("Failed to parse scep message", zap.Error(err))
+		return &enrollError{ErrScepMessage, http.StatusBadRequest}
+	}
+	if err = m.Verify(); err != nil {
+		esLogger.Error("Failed to verify scep message",
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(err))
+		return sendScepFailure(w, m, scep.FailInfoBadMessageCheck)
+	}
+
+	id, err := db.GetScepTransaction(m.TransactionId, m.SignerKeyId())
+	if err == nil {
+		return sendScepEnrollState(w, m, id)
+	}
+	if !db.IsDbErrorNoRows(err) {
+		return &enrollError{ErrLookupEnroll, getHttpCodeForDbError(err)}
+	}
+	if m.MessageType == scep.MessageTypeCertPoll {
+		return sendScepFailure(w, m, scep.FailInfoBadCertId)
+	}
+	return scepEnroll(w, m, startTime)
+}
+
+// queue the csr of a PKCSReq or RenewalReq
+func scepEnroll(w http.ResponseWriter, m *scep.PKIMessage,
+	startTime time.Time) *enrollError {
+	if err := m.DecryptRequest(scepRACert, scepRAKey); err != nil {
+		esLogger.Error("Failed to decrypt scep request",
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(err))
+		if errors.Is(err, pkcs7.ErrUnsupportedCipher) {
+			return sendScepFailure(w, m, scep.FailInfoBadAlg)
+		}
+		return sendScepFailure(w, m, scep.FailInfoBadMessageCheck)
+	}
+
+	payloadType := requestPayloadTypeEnroll
+	tokenType := tokenmgr.TokenTypeEnrollment
+	if m.MessageType == scep.MessageTypeRenewalReq {
+		payloadType = requestPayloadTypeReenroll
+		tokenType = tokenmgr.TokenTypeDevice
+	}
+	ei, err := getEnrollInfo(string(tokenType), m.ChallengePassword)
+	if err != nil {
+		esLogger.Error("Failed to validate scep challenge password",
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(err))
+		return sendScepFailure(w, m, scep.FailInfoBadRequest)
+	}
+
+	payload := enrollPayload{
+		CSR:      base64.StdEncoding.EncodeToString(m.CSR.Raw),
+		Type:     payloadType,
+		TenantId: ei.TenantId,
+	}
+	if err = payload.loadCSR(); err != nil {
+		return sendScepFailure(w, m, scep.FailInfoBadRequest)
+	}
+	if payloadType == requestPayloadTypeReenroll {
+		if payload.DeviceId, err = uuid.Parse(ei.DeviceId); err != nil {
+			return sendScepFailure(w, m, scep.FailInfoBadRequest)
+		}
+	} else {
+		payload.ManagementService = gServerConfig.DefaultManagementService
+		if err = payload.ValidateManagementService(); err != nil {
+			esLogger.Error("Invalid server.default_management_service for scep",
+				zap.Error(err))
+			return &enrollError{ErrInternal, http.StatusInternalServerError}
+		}
+	}
+
+	p, eErr := getCSRPolicy(ei.TenantId)
+	if eErr != nil {
+		return eErr
+	}
+	if eErr = checkCSRPolicy(p, &payload); eErr != nil {
+		esLogger.Error("Scep csr does not meet tenant policy",
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(eErr.Error))
+		return sendScepFailure(w, m, scep.FailInfoBadRequest)
+	}
+
+	// a csr is only enrolled once, whatever the transaction
+	hasCSRHash, err := payload.isEnrolledCSR()
+	if err != nil {
+		return &enrollError{ErrLookupCsr, getHttpCodeForDbError(err)}
+	}
+	if hasCSRHash {
+		return sendScepFailure(w, m, scep.FailInfoBadRequest)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	de, err := db.CreateScepEnroll(ei.TenantId, payload.DeviceId, ei.UserId,
+		payload.CSRHash, string(data), m.TransactionId, m.SignerKeyId())
+	if err != nil {
+		if payloadType == requestPayloadTypeReenroll {
+			return &enrollError{ErrRenewEnroll, getHttpCodeForDbError(err)}
+		}
+		return &enrollError{ErrCreateEnroll, getHttpCodeForDbError(err)}
+	}
+	payload.ID = de.Id
+	payload.RequestId = de.RequestId
+
+	// a failed transaction answers later GetCertInitial polls with failure
+	if err = pushToPendingEnrollQueue(&payload); err != nil {
+		esLogger.Error("SCEP enroll handoff failed",
+			zap.String("ID", payload.ID.String()),
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(err))
+		failEnroll(payload.ID, ErrHandoffEnroll)
+		return &enrollError{ErrHandoffEnroll, http.StatusInternalServerError}
+	}
+
+	if eErr = sendScepCertRep(w, m, scep.StatusPending, "", nil); eErr != nil {
+		return eErr
+	}
+	esLogger.Info(
+		"SCEP enroll queued",
+		zap.String("ID", payload.ID.String()),
+		zap.String("RequestID", de.RequestId),
+		zap.String("TenantID", ei.TenantId),
+		zap.String("TransactionID", m.TransactionId),
+		zap.String("Type", payload.Type),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// map the status of the enroll of a transaction onto a CertRep
+func sendScepEnrollState(w http.ResponseWriter, m *scep.PKIMessage,
+	id uuid.UUID) *enrollError {
+	entry, err := db.GetEnrollStatus(id)
+	if err != nil {
+		if !db.IsDbErrorNoRows(err) {
+			return &enrollError{ErrLookupEnroll, getHttpCodeForDbError(err)}
+		}
+		// a failed enroll is moved to enroll_error
+		if _, err = db.GetEnrollErrorStatus(id); err == nil {
+			return sendScepFailure(w, m, scep.FailInfoBadRequest)
+		}
+		return sendScepFailure(w, m, scep.FailInfoBadCertId)
+	}
+	switch entry.Status {
+	case ENROLL_STATUS_PENDING:
+		return sendScepCertRep(w, m, scep.StatusPending, "", nil)
+	case ENROLL_STATUS_ENROLLED:
+		dc, err := db.GetEnrollDetailsById(id)
+		if err != nil {
+			return &enrollError{ErrLookupEnroll, getHttpCodeForDbError(err)}
+		}
+		certs, err := parseStoredCertificates(dc.Certificate)
+		if err != nil {
+			esLogger.Error("Failed to parse certificate of enroll",
+				zap.String("ID", id.String()),
+				zap.Error(err))
+			return &enrollError{ErrInternal, http.StatusInternalServerError}
+		}
+		return sendScepCertRep(w, m, scep.StatusSuccess, "", certs)
+	}
+	return sendScepFailure(w, m, scep.FailInfoBadRequest)
+}
+
+func sendScepFailure(w http.ResponseWriter, m *scep.PKIMessage,
+	failInfo scep.FailInfo) *enrollError {
+	return sendScepCertRep(w, m, scep.StatusFailure, failInfo, nil)
+}
+
+// scep reports request failures in a signed CertRep with http 200
+func sendScepCertRep(w http.ResponseWriter, m *scep.PKIMessage,
+	status scep.PKIStatus, failInfo scep.FailInfo,
+	certs []*x509.Certificate) *enrollError {
+	der, err := scep.NewCertRep(m, status, failInfo, certs, scepRACert,
+		scepRAKey)
+	if err != nil {
+		esLogger.Error("Failed to create scep CertRep",
+			zap.String("TransactionID", m.TransactionId),
+			zap.Error(err))
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypeScepMessage)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(der)
+	return nil
+}
+
+// the ra certificate goes first, followed by ca certificates
+func sendScepCACert(w http.ResponseWriter) *enrollError {
+	der, err := pkcs7.DegenerateCertificates(
+		append([]*x509.Certificate{scepRACert}, caCerts...))
+	if err != nil {
+		esLogger.Error("Failed to encode certificates", zap.Error(err))
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	w.Header().Set(headerContentType, contentTypeScepCARACert)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(der)
+	return nil
+}
+
+// load the scep ra certificate and key. an empty cert file disables scep.
+func loadScepRA(certFile, keyFile string) error {
+	if certFile == "" {
+		return nil
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return ErrScepRAKey
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return err
+	}
+	scepRACert, scepRAKey = cert, key
+	esLogger.Info("Loaded scep ra certificate",
+		zap.String("subject", cert.Subject.String()))
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+)
+
+func TestScepNotConfigured(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/scep?operation=GetCACaps", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestScepGetCACaps(t *testing.T) {
+	setTestScepRA(t)
+	req, _ := http.NewRequest(http.MethodGet, "/scep?operation=GetCACaps", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+	if !strings.Contains(response.Body.String(), "POSTPKIOperation") {
+		t.Errorf("Expected POSTPKIOperation in caps, found: %s",
+			response.Body.String())
+	}
+}
+
+func TestScepGetCACert(t *testing.T) {
+	ra := setTestScepRA(t)
+	req, _ := http.NewRequest(http.MethodGet, "/scep?operation=GetCACert", nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusOK, response.Code)
+	if ct := response.Header().Get(headerContentType); ct != contentTypeScepCARACert {
+		t.Errorf("Expected content type %s, found: %s", contentTypeScepCARACert, ct)
+	}
+	certs, err := pkcs7.ParseCertificates(response.Body.Bytes())
+	handleError(t, err)
+	if len(certs) != 1 || !certs[0].Equal(ra) {
+		t.Errorf("Expected ra certificate in response")
+	}
+}
+
+func TestScepInvalidRequestsFail(t *testing.T) {
+	setTestScepRA(t)
+	tests := []struct {
+		method string
+		url    string
+		body   io.Reader
+	}{
+		{http.MethodGet, "/scep", nil},
+		{http.MethodGet, "/scep?operation=GetCRL", nil},
+		{http.MethodGet, "/scep?operation=PKIOperation&message=not-base64", nil},
+		{http.MethodGet, "/scep?operation=PKIOperation&message=bm90IGRlcg==", nil},
+		{http.MethodPost, "/scep?operation=GetCACert", strings.NewReader("")},
+		{http.MethodPost, "/scep?operation=PKIOperation",
+			strings.NewReader("not der")},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, test.body)
+		response := executeTestRequest(req)
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("%d: expected %d, found: %d", i, http.StatusBadRequest,
+				response.Code)
+		}
+	}
+}
+
+// responses are signed with rsa, so the ra key must be rsa
+func TestLoadScepRAWithEcKeyFails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test ra"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	handleError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	handleError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "ra.pem")
+	keyFile := filepath.Join(dir, "ra.key")
+	handleError(t, os.WriteFile(certFile, pem.EncodeToMemory(
+		&pem.Block{Type: pemTypeCertificate, Bytes: der}), 0600))
+	handleError(t, os.WriteFile(keyFile, pem.EncodeToMemory(
+		&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600))
+	if err = loadScepRA(certFile, keyFile); !errors.Is(err, ErrScepRAKey) {
+		t.Errorf("Expected %v, found: %v", ErrScepRAKey, err)
+	}
+}
+
+// configure an rsa ra for the duration of the test
+func setTestScepRA(t *testing.T) *x509.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	handleError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test ra"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	handleError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	handleError(t, err)
+	scepRACert, scepRAKey = cert, key
+	t.Cleanup(func() { scepRACert, scepRAKey = nil, nil })
+	return cert
+}
@@ -52,6 +52,20 @@ func Init(logger *zap.Logger, serverConfig *config.Server) error {
 	debugLogRestRequests = serverConfig.DebugRestRequests
 	gServerConfig = serverConfig
 
+	if err := loadCACerts(serverConfig.CACertsFile); err != nil {
+		esLogger.Error("Failed to load ca certificates",
+			zap.String("file", serverConfig.CACertsFile),
+			zap.Error(err))
+		return err
+	}
+	if err := loadScepRA(serverConfig.ScepRACertFile,
+		serverConfig.ScepRAKeyFile); err != nil {
+		esLogger.Error("Failed to load scep ra certificate",
+			zap.String("file", serverConfig.ScepRACertFile),
+			zap.Error(err))
+		return err
+	}
+
 	errorChannel = make(chan error)
 	interruptChannel = make(chan os.Signal, 1)
 	signal.Notify(interruptChannel, syscall.SIGINT, syscall.SIGTERM)
@@ -61,7 +75,8 @@ func Init(logger *zap.Logger, serverConfig *config.Server) error {
 	gSrv = &http.Server{
 		Addr: addr,
 		// Good practice to set timeouts to avoid Slowloris attacks.
-		WriteTimeout: time.Second * 15,
+		// status long polls must be able to finish writing after waiting.
+		WriteTimeout: max(time.Second*15, getMaxStatusWait()+time.Second*5),
 		ReadTimeout:  time.Second * 15,
 		IdleTimeout:  time.Second * 60,
 		Handler:      router,
@@ -16,6 +16,7 @@ import (
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/HPInc/krypton-es/es/service/policy"
 	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/HPInc/krypton-es/es/service/webhook"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -35,6 +36,8 @@ var (
 	testApiPath    = "api/v1"
 	tokenUrl       = fmt.Sprintf("%s/%s/token", getJwtServer(), testApiPath)
 	deviceTokenUrl = fmt.Sprintf("%s/%s/device_token", getJwtServer(), testApiPath)
+	// seals webhook secrets created by tests
+	testWebhookSecretKey = "dGVzdCB3ZWJob29rIHNlY3JldCBrZXkgMzIgYnl0ZXM="
 )
 
 func getServer() string {
@@ -59,6 +62,7 @@ func TestMain(m *testing.M) {
 	db.InitTestDefault(log)
 	tokenmgr.Init(log, "../config/token_config_test.yaml")
 	policy.Init(log, "../config/default_policy.json")
+	webhook.Init(log, &config.Webhook{SecretKey: testWebhookSecretKey})
 	Init(log, &serverConfig)
 	defer Shutdown()
 	os.Exit(m.Run())
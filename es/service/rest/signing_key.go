@@ -0,0 +1,113 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type listSigningKeysResponse struct {
+	Keys []*structs.PublicKey `json:"keys"`
+}
+
+/*
+	Get /signing_key
+	List token signing keys fetched from the keys url of each token type,
+	including revoked keys.
+
+Returns:
+- 200
+  - {"keys": [...]}
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to app
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ListSigningKeys(w http.ResponseWriter, r *http.Request) *enrollError {
+	if eErr := validateSigningKeyAdmin(r); eErr != nil {
+		return eErr
+	}
+
+	keys, err := db.ListPublicKeys()
+	if err != nil {
+		return &enrollError{ErrListSigningKeys, getHttpCodeForDbError(err)}
+	}
+	res := listSigningKeysResponse{Keys: keys}
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	return nil
+}
+
+/*
+	Post /signing_key/{key_source}/{kid}/revoke
+	Revoke a compromised token signing key. Tokens signed with it are
+	rejected by all replicas even if its key source still publishes it.
+
+Returns:
+- 200
+  - successfully revoked
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to app
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 404
+  - No key with this kid from key source
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func RevokeSigningKey(w http.ResponseWriter, r *http.Request) *enrollError {
+	if eErr := validateSigningKeyAdmin(r); eErr != nil {
+		return eErr
+	}
+
+	vars := mux.Vars(r)
+	source, kid := vars[paramKeySource], vars[paramKid]
+	if source == "" || kid == "" {
+		err := fmt.Errorf("%w: %s, %s", ErrMissingPathParam, paramKeySource,
+			paramKid)
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	if err := tokenmgr.RevokeKey(source, kid); err != nil {
+		return &enrollError{ErrRevokeSigningKey, getHttpCodeForDbError(err)}
+	}
+
+	esLogger.Info(
+		"RevokeSigningKey",
+		zap.String("key_source", source),
+		zap.String("kid", kid))
+	return nil
+}
+
+// signing keys are shared by all tenants. only apps manage them.
+func validateSigningKeyAdmin(r *http.Request) *enrollError {
+	if err := validateAppToken(r, tokenmgr.TokenTypeApp); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if _, err := GetEnrollInfoFromToken(r); err != nil {
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+	return nil
+}
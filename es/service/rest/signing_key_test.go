@@ -0,0 +1,51 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+)
+
+const (
+	signingKeyUrl       = "/api/v1/signing_key"
+	revokeSigningKeyUrl = signingKeyUrl + "/test/kid1/revoke"
+)
+
+// signing keys are shared by tenants. user tokens cannot manage them.
+func TestSigningKeyWithUserTokenTypeFails(t *testing.T) {
+	for _, test := range []struct {
+		method string
+		url    string
+	}{
+		{http.MethodGet, signingKeyUrl},
+		{http.MethodPost, revokeSigningKeyUrl},
+	} {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		req.Header.Set(headerTokenType, string(tokenmgr.TokenTypeAzureAD))
+		response := executeTestRequest(req)
+		checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+	}
+}
+
+func TestSigningKeyWithMissingTypeHeaderFails(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, signingKeyUrl, nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestRevokeSigningKeyWithoutBearerFails(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, revokeSigningKeyUrl, nil)
+	req.Header.Set(headerTokenType, string(tokenmgr.TokenTypeApp))
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestRevokeSigningKeyWithGetMethodFails(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, revokeSigningKeyUrl, nil)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusMethodNotAllowed, response.Code)
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +22,8 @@ Requires:
 - Custom header: X-HP-Token-Type
   - Value: "enrollment"
   - Authorization header: Bearer <Token>
+  - Optional header: Idempotency-Key. A repeat with the same key
+    returns the original response.
 
 Returns:
 - 202 (request is accepted and will eventually be processed)
@@ -37,6 +41,9 @@ Errors:
 - 405
   - Must be DELETE
 
+- 422
+  - Idempotency-Key was used with a different device
+
 - 500
   - should not be here. yet, here we are.
 */
@@ -68,9 +75,25 @@ func Unenroll(w http.ResponseWriter, r *http.Request) *enrollError {
 		}
 	}
 
-	// create an unenroll record in db
-	de, err := db.Unenroll(ei.TenantId, deviceId)
+	// a repeat with the same Idempotency-Key gets the original response
+	ir, err := getIdempotentRequest(r, requestPayloadTypeUnenroll,
+		deviceId.String())
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if ok, eErr := replayIdempotentRequest(w, db.IdempotencyKindUnenroll,
+		ei.TenantId, ir, startTime); ok {
+		return eErr
+	}
+
+	// create an unenroll record in db. device initiated unenrolls
+	// are not recorded with a user id.
+	de, err := db.Unenroll(ei.TenantId, deviceId, "", ir)
 	if err != nil {
+		if ok, eErr := replayOnIdempotencyConflict(w, db.IdempotencyKindUnenroll,
+			ei.TenantId, ir, err, startTime); ok {
+			return eErr
+		}
 		return &enrollError{ErrUnenroll, getHttpCodeForDbError(err)}
 	}
 
@@ -85,7 +108,8 @@ func Unenroll(w http.ResponseWriter, r *http.Request) *enrollError {
 	}
 
 	if err = pushToPendingEnrollQueue(ep); err != nil {
-		return &enrollError{err, http.StatusInternalServerError}
+		failUnenroll(de.Id, err)
+		return &enrollError{ErrHandoffEnroll, http.StatusInternalServerError}
 	}
 
 	sendEnrollResponse(w, de, startTime)
@@ -99,3 +123,16 @@ func Unenroll(w http.ResponseWriter, r *http.Request) *enrollError {
 		zap.String("Elapsed", time.Since(startTime).String()))
 	return nil
 }
+
+// an unenroll that could not be handed off would stay pending until
+// expiry. move it to unenroll_error so the device can unenroll again.
+func failUnenroll(id uuid.UUID, err error) {
+	if fErr := db.FailUnenrollRecord(&structs.EnrollError{
+		EnrollId:     id.String(),
+		ErrorMessage: err.Error(),
+	}); fErr != nil {
+		esLogger.Error("Unenroll: could not fail unenroll after handoff error",
+			zap.String("unenroll_id", id.String()),
+			zap.Error(fErr))
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"go.uber.org/zap"
+)
+
+/*
+	Delete /tenant/{tenant_id}/enroll/{device_id}
+	Unenroll a device of a tenant without a device token. For devices
+	that are lost, stolen or can no longer unenroll themselves.
+	The unenroll is handed off exactly like a device initiated unenroll
+	and is recorded with the user id (or app id) from the bearer token.
+	Follow up with Get /enroll/{device_id}/history.
+
+Requires:
+- Custom header: X-HP-Token-Type
+  - Value: a user token type (tenant admin) or "app"
+  - Authorization header: Bearer <Token>
+  - user tokens must be issued for tenant_id
+  - app tokens must be for an app listed for tenant_id in app_tenants
+    of the token configuration
+  - Optional header: Idempotency-Key. A repeat with the same key
+    returns the original response.
+
+Returns:
+- 202 (request is accepted and will eventually be processed)
+  - id of pending unenroll.
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to a user token type or app
+  - tenant_id and device_id must be uuids
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 403
+  - tenant_id does not match tenant in user token
+  - app is not allowed for tenant_id
+
+- 404
+  - device is not enrolled in tenant
+
+- 422
+  - Idempotency-Key was used with a different device
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func AdminUnenroll(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+	requestID := r.Header.Get(headerRequestID)
+
+	if err := validateAdminToken(r); err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return &enrollError{err, http.StatusBadRequest}
+		}
+		return &enrollError{err, http.StatusUnauthorized}
+	}
+
+	tenantId, eErr := getUUIDParam(r, paramTenantID)
+	if eErr != nil {
+		return eErr
+	}
+	deviceId, eErr := getUUIDParam(r, paramDeviceID)
+	if eErr != nil {
+		return eErr
+	}
+
+	if eErr = authorizeTenant(r.Header.Get(headerTokenType), ei,
+		tenantId.String()); eErr != nil {
+		return eErr
+	}
+
+	// the device must be enrolled in the tenant
+	history, err := db.GetDeviceHistory(tenantId.String(), deviceId)
+	if err != nil {
+		return &enrollError{ErrUnenroll, getHttpCodeForDbError(err)}
+	}
+	if !isDeviceEnrolled(history) {
+		return &enrollError{ErrDeviceNotEnrolled, http.StatusNotFound}
+	}
+
+	ir, err := getIdempotentRequest(r, requestPayloadTypeUnenroll,
+		deviceId.String())
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+	if ok, eErr := replayIdempotentRequest(w, db.IdempotencyKindUnenroll,
+		tenantId.String(), ir, startTime); ok {
+		return eErr
+	}
+
+	de, err := db.Unenroll(tenantId.String(), deviceId, ei.UserId, ir)
+	if err != nil {
+		if ok, eErr := replayOnIdempotencyConflict(w, db.IdempotencyKindUnenroll,
+			tenantId.String(), ir, err, startTime); ok {
+			return eErr
+		}
+		return &enrollError{ErrUnenroll, getHttpCodeForDbError(err)}
+	}
+
+	ep := &enrollPayload{
+		ID:        de.Id,
+		RequestId: de.RequestId,
+		TenantId:  tenantId.String(),
+		DeviceId:  deviceId,
+		Type:      requestPayloadTypeUnenroll,
+	}
+	if err = pushToPendingEnrollQueue(ep); err != nil {
+		failUnenroll(de.Id, err)
+		return &enrollError{ErrHandoffEnroll, http.StatusInternalServerError}
+	}
+
+	sendEnrollResponse(w, de, startTime)
+
+	esLogger.Info(
+		"Admin unenroll queued",
+		zap.String("Request ID:", requestID),
+		zap.String("unenroll_id", de.Id.String()),
+		zap.String("tenant_id", tenantId.String()),
+		zap.String("device_id", deviceId.String()),
+		zap.String("user_id", ei.UserId),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+// device is enrolled if its last completed enroll or renew is not
+// followed by a completed unenroll. history is oldest first.
+func isDeviceEnrolled(history []*structs.DeviceHistoryEntry) bool {
+	enrolled := false
+	for _, e := range history {
+		if e.Status != db.HistoryStatusCompleted {
+			continue
+		}
+		enrolled = e.Operation != db.HistoryOperationUnenroll
+	}
+	return enrolled
+}
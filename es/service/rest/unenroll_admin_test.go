@@ -0,0 +1,152 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+)
+
+// device tokens use the device unenroll
+func TestAdminUnenrollWithDeviceTokenFails(t *testing.T) {
+	info, deviceToken := getDeviceToken()
+	path := fmt.Sprintf("/api/v1/tenant/%s/enroll/%s", info.tenantId, info.deviceId)
+	req, _ := http.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(headerTokenType, "device")
+	req.Header.Set(headerAuthorization, deviceToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+// a user token sent as an app token does not validate
+func TestAdminUnenrollWithInvalidAppTokenFails(t *testing.T) {
+	path := fmt.Sprintf("/api/v1/tenant/%s/enroll/%s", uuid.New(), uuid.New())
+	req, _ := http.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(headerTokenType, "app")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusUnauthorized, response.Code)
+}
+
+// apps are only allowed for the tenants listed for them in app_tenants
+// of token_config_test.yaml. user tokens only for their own tenant.
+func TestAuthorizeTenant(t *testing.T) {
+	appId := "0d9b2a6e-3f4c-4f55-9d8e-6a1f8d5b7c21"
+	appTenantId := "5a1e0f1c-8b7d-4f5e-a2c3-9d4b6e8f0a12"
+	userTenantId := uuid.NewString()
+	tests := []struct {
+		tokenType string
+		ei        EnrollInfo
+		tenantId  string
+		err       error
+	}{
+		{"app", EnrollInfo{UserId: appId}, appTenantId, nil},
+		{"app", EnrollInfo{UserId: appId}, userTenantId,
+			ErrAppNotAllowedForTenant},
+		{"app", EnrollInfo{UserId: uuid.NewString()}, appTenantId,
+			ErrAppNotAllowedForTenant},
+		// a tenant claim does not make an app token tenant bound
+		{"app", EnrollInfo{UserId: uuid.NewString(), TenantId: appTenantId},
+			appTenantId, ErrAppNotAllowedForTenant},
+		{"test", EnrollInfo{TenantId: userTenantId}, userTenantId, nil},
+		{"test", EnrollInfo{TenantId: userTenantId}, appTenantId,
+			ErrTenantIdMismatch},
+	}
+	for i, test := range tests {
+		eErr := authorizeTenant(test.tokenType, &test.ei, test.tenantId)
+		if test.err == nil && eErr != nil {
+			t.Errorf("%d: expected no error, found: %v", i, eErr.Error)
+		}
+		if test.err != nil && (eErr == nil || eErr.Error != test.err ||
+			eErr.Code != http.StatusForbidden) {
+			t.Errorf("%d: expected %v, found: %+v", i, test.err, eErr)
+		}
+	}
+}
+
+func TestAdminUnenrollOtherTenantFails(t *testing.T) {
+	path := fmt.Sprintf("/api/v1/tenant/%s/enroll/%s", uuid.New(), uuid.New())
+	req, _ := http.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, getBearerToken())
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusForbidden, response.Code)
+}
+
+func TestAdminUnenrollNotEnrolledDeviceFails(t *testing.T) {
+	bearerToken := getBearerToken()
+	tenantId := getTenantIdFromBearerToken(t, bearerToken)
+	path := fmt.Sprintf("/api/v1/tenant/%s/enroll/%s", tenantId, uuid.New())
+	req, _ := http.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+// unenroll is recorded with the admin user id
+func TestAdminUnenroll(t *testing.T) {
+	bearerToken := getBearerToken()
+	info := &testTokenInfo{
+		tenantId: getTenantIdFromBearerToken(t, bearerToken),
+		deviceId: uuid.NewString(),
+		userId:   uuid.NewString(),
+	}
+	_, err := updateEnroll(info)
+	handleError(t, err)
+
+	path := fmt.Sprintf("/api/v1/tenant/%s/enroll/%s", info.tenantId, info.deviceId)
+	req, _ := http.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set(headerTokenType, "test")
+	req.Header.Set(headerAuthorization, bearerToken)
+	response := executeTestRequest(req)
+	checkTestResponseCode(t, http.StatusAccepted, response.Code)
+
+	var res enrollResponse
+	if err = json.NewDecoder(response.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode unenroll response: %v", err)
+	}
+	history, err := db.GetDeviceHistory(info.tenantId, uuid.MustParse(info.deviceId))
+	handleError(t, err)
+	for _, e := range history {
+		if e.Id == res.ID && e.UserId == "" {
+			t.Errorf("Expected unenroll to record user id")
+		}
+	}
+}
+
+func TestIsDeviceEnrolled(t *testing.T) {
+	entry := func(operation, status string) *structs.DeviceHistoryEntry {
+		return &structs.DeviceHistoryEntry{Operation: operation, Status: status}
+	}
+	tests := []struct {
+		history  []*structs.DeviceHistoryEntry
+		enrolled bool
+	}{
+		{nil, false},
+		{[]*structs.DeviceHistoryEntry{
+			entry(db.HistoryOperationEnroll, db.HistoryStatusPending)}, false},
+		{[]*structs.DeviceHistoryEntry{
+			entry(db.HistoryOperationEnroll, db.HistoryStatusCompleted),
+			entry(db.HistoryOperationUnenroll, db.HistoryStatusFailed)}, true},
+		{[]*structs.DeviceHistoryEntry{
+			entry(db.HistoryOperationEnroll, db.HistoryStatusCompleted),
+			entry(db.HistoryOperationUnenroll, db.HistoryStatusCompleted)}, false},
+		{[]*structs.DeviceHistoryEntry{
+			entry(db.HistoryOperationEnroll, db.HistoryStatusCompleted),
+			entry(db.HistoryOperationUnenroll, db.HistoryStatusCompleted),
+			entry(db.HistoryOperationRenewEnroll, db.HistoryStatusCompleted)}, true},
+	}
+	for i, test := range tests {
+		if isDeviceEnrolled(test.history) != test.enrolled {
+			t.Errorf("%d: expected enrolled to be %v", i, test.enrolled)
+		}
+	}
+}
@@ -14,16 +14,23 @@ import (
 	"go.uber.org/zap"
 )
 
-func getUnenrollStatus(w http.ResponseWriter, id uuid.UUID, ei *EnrollInfo) *enrollError {
+// resolve state of an unenroll id as visible to the device token
+func getUnenrollState(id uuid.UUID, ei *EnrollInfo) (*enrollState, *enrollError) {
 	var entry *structs.UnenrollStatus
 	var err error
 	entry, err = db.GetUnenrollStatus(id)
+	// a failed unenroll is moved to unenroll_error. report terminal failure.
+	if err != nil && db.IsDbErrorNoRows(err) {
+		if ee, eErr := db.GetUnenrollErrorStatus(id); eErr == nil {
+			return getFailedUnenroll(id, ee, ei)
+		}
+	}
 	// if there is a lookup error or if entry does not match token details, dont go further.
 	if err != nil {
 		esLogger.Error("Could not find unenroll id",
 			zap.Error(err))
-		return &enrollError{
-			fmt.Errorf("id: %s is not found", id),
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
 			http.StatusNotFound,
 		}
 	}
@@ -33,37 +40,52 @@ func getUnenrollStatus(w http.ResponseWriter, id uuid.UUID, ei *EnrollInfo) *enr
 			zap.String("token_device_id", ei.DeviceId),
 			zap.String("tenant_id", entry.TenantId),
 			zap.String("device_id", entry.DeviceId))
-		return &enrollError{
-			fmt.Errorf("id: %s is not found", id),
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
 			http.StatusNotFound,
 		}
 	}
 	switch entry.Status {
-	case 0:
-		writeRetryAfter(w, getRetryAfterHint())
-		return &enrollError{
-			ErrRequestInProgress,
-			http.StatusTooManyRequests,
-		}
-	case 1:
-		return getCompletedUnenroll(w, entry, id)
+	case ENROLL_STATUS_PENDING:
+		return &enrollState{status: statusPending}, nil
+	case ENROLL_STATUS_ENROLLED:
+		return &enrollState{status: statusUnenrolled}, nil
 	default:
-		return &enrollError{
-			fmt.Errorf("id: %s is not found", id),
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
 			http.StatusNotFound,
 		}
 	}
 }
 
-func getCompletedUnenroll(
-	w http.ResponseWriter,
-	entry *structs.UnenrollStatus,
-	id uuid.UUID) *enrollError {
-	type unenrollStatus struct {
-		Id     uuid.UUID `json:"id"`
-		Status string    `json:"status"`
+func getFailedUnenroll(id uuid.UUID, ee *structs.EnrollErrorStatus,
+	ei *EnrollInfo) (*enrollState, *enrollError) {
+	if ee.TenantId != ei.TenantId || ee.DeviceId.String() != ei.DeviceId {
+		esLogger.Error("Could not find unenroll error id",
+			zap.String("token_tenant_id", ei.TenantId),
+			zap.String("token_device_id", ei.DeviceId),
+			zap.String("tenant_id", ee.TenantId),
+			zap.String("device_id", ee.DeviceId.String()))
+		return nil, &enrollError{
+			fmt.Errorf("%w: %s", ErrIdNotFound, id),
+			http.StatusNotFound,
+		}
 	}
-	jsonstring, err := json.Marshal(&unenrollStatus{
+	return &enrollState{
+		status:  statusFailed,
+		reason:  failureReasonUnenroll,
+		failure: ee,
+	}, nil
+}
+
+// completed unenroll status
+type unenrollStatusResponse struct {
+	Id     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func getCompletedUnenroll(w http.ResponseWriter, id uuid.UUID) *enrollError {
+	jsonstring, err := json.Marshal(&unenrollStatusResponse{
 		Id:     id,
 		Status: "success",
 	})
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/HPInc/krypton-es/es/service/tokenmgr"
+	"go.uber.org/zap"
 )
 
 type EnrollInfo struct {
@@ -28,7 +29,12 @@ func GetEnrollInfoFromToken(r *http.Request) (*EnrollInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	return getEnrollInfo(tokenType, token)
+}
 
+// validate token of token type and return its enroll details.
+// scep passes the token as the challenge password instead of a header.
+func getEnrollInfo(tokenType, token string) (*EnrollInfo, error) {
 	// Invoke the token manager to validate the access token.
 	// specific claims like deviceid are validated by the
 	// corresponding validators
@@ -50,6 +56,54 @@ func GetEnrollInfoFromToken(r *http.Request) (*EnrollInfo, error) {
 	}, nil
 }
 
+// tenant admin apis require a user token
+func validateUserToken(r *http.Request) error {
+	tokenType := r.Header.Get(headerTokenType)
+	if tokenType == "" {
+		esLogger.Error(ErrTokenTypeHeaderNotFound.Error())
+		return ErrTokenTypeHeaderNotFound
+	}
+
+	if !tokenmgr.IsUserToken(tokenType) {
+		return ErrUserTokenNotProvided
+	}
+	return nil
+}
+
+// tenant device admin apis accept user tokens and app tokens. apps
+// must also be allowed for the tenant, see authorizeTenant.
+func validateAdminToken(r *http.Request) error {
+	tokenType := r.Header.Get(headerTokenType)
+	if tokenType == "" {
+		esLogger.Error(ErrTokenTypeHeaderNotFound.Error())
+		return ErrTokenTypeHeaderNotFound
+	}
+
+	if !tokenmgr.IsUserToken(tokenType) && !tokenmgr.IsAppToken(tokenType) {
+		return ErrAdminTokenNotProvided
+	}
+	return nil
+}
+
+// user tokens are issued for a tenant. app tokens are not, so the app
+// id must be listed for the tenant in the token configuration.
+func authorizeTenant(tokenType string, ei *EnrollInfo,
+	tenantId string) *enrollError {
+	if tokenmgr.IsAppToken(tokenType) {
+		if !tokenmgr.IsAppAllowedForTenant(tokenType, ei.UserId, tenantId) {
+			esLogger.Error("App is not allowed for tenant",
+				zap.String("app_id", ei.UserId),
+				zap.String("tenant_id", tenantId))
+			return &enrollError{ErrAppNotAllowedForTenant, http.StatusForbidden}
+		}
+		return nil
+	}
+	if ei.TenantId != tenantId {
+		return &enrollError{ErrTenantIdMismatch, http.StatusForbidden}
+	}
+	return nil
+}
+
 func extractTokenFromAuthorizationHeader(r *http.Request) (string, error) {
 	tokenString := r.Header.Get(headerAuthorization)
 	if tokenString == "" {
@@ -0,0 +1,387 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/HPInc/krypton-es/es/service/webhook"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	maxWebhooksPerTenant  = 10
+	minWebhookSecretLen   = 16
+	maxWebhookSecretLen   = 256
+	generatedSecretLen    = 32
+	maxWebhookRequestSize = 4096
+
+	schemeHttps = "https"
+)
+
+// delivery status names in delivery log
+var webhookDeliveryStatus = map[int]string{
+	db.WebhookDeliveryPending:   "pending",
+	db.WebhookDeliveryDelivered: "delivered",
+	db.WebhookDeliveryFailed:    "failed",
+}
+
+type createWebhookRequest struct {
+	Url string `json:"url"`
+	// optional. generated if not specified.
+	Secret string `json:"secret"`
+}
+
+type listWebhooksResponse struct {
+	Webhooks []*structs.Webhook `json:"webhooks"`
+}
+
+type webhookDelivery struct {
+	Id            uuid.UUID       `json:"id"`
+	EventType     string          `json:"event_type"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_time,omitempty"`
+	Event         json.RawMessage `json:"event"`
+	CreatedAt     time.Time       `json:"created_time"`
+	UpdatedAt     *time.Time      `json:"updated_time,omitempty"`
+}
+
+type listWebhookDeliveriesResponse struct {
+	WebhookId  uuid.UUID          `json:"webhook_id"`
+	Deliveries []*webhookDelivery `json:"deliveries"`
+}
+
+/*
+	Post /webhook
+	Register a webhook for the tenant in the bearer token.
+	enroll.completed, enroll.failed, unenroll.completed and unenroll.failed
+	events are posted as json to the url. Each delivery has headers
+	X-HP-Webhook-Timestamp and X-HP-Webhook-Signature: sha256=<hex> where
+	the signature is hmac-sha256 of "<timestamp>.<body>" with the secret.
+
+Payload:
+  - {"url": <https url>, "secret": <optional. 16 - 256 characters>}
+
+Returns:
+- 201
+  - {"id": <uuid>, "url": <url>, "secret": <secret>, ...}
+  - secret is generated if not specified. It is not returned again.
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - url must be an absolute https url of a public host
+  - secret must be 16 to 256 characters
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 409
+  - Tenant has reached the maximum number of webhooks
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func CreateWebhook(w http.ResponseWriter, r *http.Request) *enrollError {
+	startTime := time.Now()
+	requestID := r.Header.Get(headerRequestID)
+
+	tenantId, eErr := getWebhookTenantId(r)
+	if eErr != nil {
+		return eErr
+	}
+
+	req, err := getCreateWebhookRequest(r)
+	if err != nil {
+		return &enrollError{err, http.StatusBadRequest}
+	}
+
+	webhooks, err := db.ListWebhooks(tenantId)
+	if err != nil {
+		return &enrollError{ErrCreateWebhook, getHttpCodeForDbError(err)}
+	}
+	if len(webhooks) >= maxWebhooksPerTenant {
+		return &enrollError{ErrTooManyWebhooks, http.StatusConflict}
+	}
+
+	sealed, err := webhook.SealSecret(tenantId, req.Secret)
+	if err != nil {
+		esLogger.Error("CreateWebhook: failed to seal secret",
+			zap.String("Request ID:", requestID),
+			zap.Error(err))
+		return &enrollError{ErrCreateWebhook, http.StatusInternalServerError}
+	}
+
+	wh, err := db.CreateWebhook(tenantId, req.Url, sealed)
+	if err != nil {
+		esLogger.Error("CreateWebhook: db error",
+			zap.String("Request ID:", requestID),
+			zap.String("TenantId", tenantId),
+			zap.Error(err))
+		return &enrollError{ErrCreateWebhook, getHttpCodeForDbError(err)}
+	}
+	// only the sealed secret is stored. return it this once.
+	wh.Secret = req.Secret
+	if err = sendJsonResponse(w, http.StatusCreated, wh); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+
+	esLogger.Info(
+		"CreateWebhook",
+		zap.String("TenantID", tenantId),
+		zap.String("WebhookID", wh.Id.String()),
+		zap.String("Elapsed", time.Since(startTime).String()))
+	return nil
+}
+
+/*
+	Get /webhook
+	List webhooks of the tenant in the bearer token. Secrets are not returned.
+
+Returns:
+- 200
+  - {"webhooks": [...]}
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ListWebhooks(w http.ResponseWriter, r *http.Request) *enrollError {
+	tenantId, eErr := getWebhookTenantId(r)
+	if eErr != nil {
+		return eErr
+	}
+
+	webhooks, err := db.ListWebhooks(tenantId)
+	if err != nil {
+		return &enrollError{ErrListWebhooks, getHttpCodeForDbError(err)}
+	}
+	res := listWebhooksResponse{Webhooks: webhooks}
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	return nil
+}
+
+/*
+	Delete /webhook/{webhook_id}
+	Delete a webhook of the tenant in the bearer token and its delivery log.
+	Pending deliveries are dropped.
+
+Returns:
+- 200
+  - successfully deleted
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - webhook_id must be a uuid
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 404
+  - No webhook with this id in tenant
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) *enrollError {
+	tenantId, eErr := getWebhookTenantId(r)
+	if eErr != nil {
+		return eErr
+	}
+
+	id, eErr := getUUIDParam(r, paramWebhookId)
+	if eErr != nil {
+		return eErr
+	}
+
+	if err := db.DeleteWebhook(id, tenantId); err != nil {
+		return &enrollError{ErrDeleteWebhook, getHttpCodeForDbError(err)}
+	}
+
+	esLogger.Info(
+		"DeleteWebhook",
+		zap.String("TenantID", tenantId),
+		zap.String("WebhookID", id.String()))
+	return nil
+}
+
+/*
+	Get /webhook/{webhook_id}/deliveries
+	Delivery log of a webhook, newest first.
+
+Query:
+  - page_size: 1 - 200. default 50
+
+Returns:
+- 200
+  - {"webhook_id": <uuid>, "deliveries": [...]}
+  - status is one of pending, delivered, failed
+
+Errors:
+- 400
+  - X-HP-TokenType header must be present and set to one of the user token types
+  - webhook_id must be a uuid
+  - Invalid query parameters
+
+- 401
+  - Could not verify token
+  - Token expired or not yet valid
+
+- 500
+  - should not be here. yet, here we are.
+*/
+func ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) *enrollError {
+	tenantId, eErr := getWebhookTenantId(r)
+	if eErr != nil {
+		return eErr
+	}
+
+	id, eErr := getUUIDParam(r, paramWebhookId)
+	if eErr != nil {
+		return eErr
+	}
+
+	limit := defaultListPageSize
+	if s := r.URL.Query().Get(queryPageSize); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 {
+			return &enrollError{ErrInvalidPageSize, http.StatusBadRequest}
+		}
+		limit = min(size, maxListPageSize)
+	}
+
+	deliveries, err := db.ListWebhookDeliveries(id, tenantId, limit)
+	if err != nil {
+		return &enrollError{ErrListWebhookDeliveries,
+			getHttpCodeForDbError(err)}
+	}
+
+	res := listWebhookDeliveriesResponse{
+		WebhookId:  id,
+		Deliveries: make([]*webhookDelivery, 0, len(deliveries)),
+	}
+	for _, d := range deliveries {
+		res.Deliveries = append(res.Deliveries, newWebhookDelivery(d))
+	}
+	if err = sendJsonResponse(w, http.StatusOK, &res); err != nil {
+		return &enrollError{ErrInternal, http.StatusInternalServerError}
+	}
+	return nil
+}
+
+// webhooks are managed with user tokens in the tenant of the token
+func getWebhookTenantId(r *http.Request) (string, *enrollError) {
+	if err := validateUserToken(r); err != nil {
+		return "", &enrollError{err, http.StatusBadRequest}
+	}
+	ei, err := GetEnrollInfoFromToken(r)
+	if err != nil {
+		if IsTokenTypeHeaderError(err) {
+			return "", &enrollError{err, http.StatusBadRequest}
+		}
+		return "", &enrollError{err, http.StatusUnauthorized}
+	}
+	return ei.TenantId, nil
+}
+
+func getCreateWebhookRequest(r *http.Request) (*createWebhookRequest, error) {
+	if r.ContentLength == 0 {
+		return nil, ErrPayloadMissing
+	}
+	defer r.Body.Close()
+
+	var req createWebhookRequest
+	if err := json.NewDecoder(
+		http.MaxBytesReader(nil, r.Body, maxWebhookRequestSize)).Decode(
+		&req); err != nil {
+		return nil, ErrPayloadRead
+	}
+	if !isValidWebhookUrl(req.Url) {
+		return nil, ErrInvalidWebhookUrl
+	}
+	if req.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		req.Secret = secret
+	} else if len(req.Secret) < minWebhookSecretLen ||
+		len(req.Secret) > maxWebhookSecretLen {
+		return nil, ErrInvalidWebhookSecret
+	}
+	return &req, nil
+}
+
+// events carry device details. only send them over https.
+// hosts that are internal addresses are rejected here. names are
+// checked again after resolution when a delivery connects.
+func isValidWebhookUrl(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	if u.Scheme != schemeHttps || u.Host == "" || u.User != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return webhook.IsPublicAddress(ip)
+	}
+	return host != ""
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, generatedSecretLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func newWebhookDelivery(d *structs.WebhookDelivery) *webhookDelivery {
+	wd := webhookDelivery{
+		Id:           d.Id,
+		EventType:    d.EventType,
+		Status:       webhookDeliveryStatus[d.Status],
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		LastError:    d.LastError,
+		Event:        json.RawMessage(d.Payload),
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+	}
+	if d.Status == db.WebhookDeliveryPending {
+		wd.NextAttemptAt = &d.NextAttemptAt
+	}
+	return &wd
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+// Package scep parses SCEP (RFC 8894) pki messages and builds CertRep
+// responses. Requests are pkcs7 signed data with the pkcs#10 request
+// enveloped to the registration authority (ra) certificate.
+package scep
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+)
+
+type MessageType string
+
+const (
+	MessageTypeCertRep    MessageType = "3"
+	MessageTypeRenewalReq MessageType = "17"
+	MessageTypePKCSReq    MessageType = "19"
+	MessageTypeCertPoll   MessageType = "20"
+)
+
+type PKIStatus string
+
+const (
+	StatusSuccess PKIStatus = "0"
+	StatusFailure PKIStatus = "2"
+	StatusPending PKIStatus = "3"
+)
+
+type FailInfo string
+
+const (
+	FailInfoBadAlg          FailInfo = "0"
+	FailInfoBadMessageCheck FailInfo = "1"
+	FailInfoBadRequest      FailInfo = "2"
+	FailInfoBadTime         FailInfo = "3"
+	FailInfoBadCertId       FailInfo = "4"
+)
+
+const nonceSize = 16
+
+var (
+	oidMessageType       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	oidPKIStatus         = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	oidFailInfo          = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	oidSenderNonce       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	oidRecipientNonce    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	oidTransactionId     = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+
+	ErrInvalidMessage         = errors.New("invalid scep pki message")
+	ErrUnsupportedMessageType = errors.New("unsupported scep message type")
+	ErrNoRequest              = errors.New("scep message type has no certificate request")
+	ErrInvalidRequest         = errors.New("invalid certificate request in scep message")
+	ErrSignerKeyMismatch      = errors.New("PKCSReq must be signed with the key of its certificate request")
+)
+
+// a parsed scep request
+type PKIMessage struct {
+	MessageType   MessageType
+	TransactionId string
+	SenderNonce   []byte
+	// self signed for PKCSReq. the current certificate for RenewalReq.
+	Signer *x509.Certificate
+
+	// set by DecryptRequest
+	CSR               *x509.CertificateRequest
+	ChallengePassword string
+
+	signedData *pkcs7.SignedData
+}
+
+type csrAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type tbsCertificateRequest struct {
+	Version       int
+	Subject       asn1.RawValue
+	PublicKey     asn1.RawValue
+	RawAttributes []asn1.RawValue `asn1:"tag:0"`
+}
+
+// parse a der pki message. call Verify before trusting its content.
+func ParsePKIMessage(der []byte) (*PKIMessage, error) {
+	sd, err := pkcs7.ParseSignedData(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	m := PKIMessage{Signer: sd.Signer, signedData: sd}
+	var messageType string
+	if err = sd.GetAttribute(oidMessageType, &messageType); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	m.MessageType = MessageType(messageType)
+	switch m.MessageType {
+	case MessageTypePKCSReq, MessageTypeRenewalReq, MessageTypeCertPoll:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMessageType, messageType)
+	}
+	if err = sd.GetAttribute(oidTransactionId, &m.TransactionId); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if err = sd.GetAttribute(oidSenderNonce, &m.SenderNonce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if m.TransactionId == "" {
+		return nil, fmt.Errorf("%w: empty transaction id", ErrInvalidMessage)
+	}
+	return &m, nil
+}
+
+// verify the message is signed by its signer certificate
+func (m *PKIMessage) Verify() error {
+	return m.signedData.Verify()
+}
+
+// transaction ids are client chosen. pair them with the signer key.
+func (m *PKIMessage) SignerKeyId() string {
+	sum := sha256.Sum256(m.Signer.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// decrypt the pkcs#10 request of a PKCSReq or RenewalReq and read its
+// challenge password. a PKCSReq is signed with the requested key
+// (RFC 8894 3.3.1), a RenewalReq with the key of the current certificate.
+func (m *PKIMessage) DecryptRequest(ra *x509.Certificate,
+	key *rsa.PrivateKey) error {
+	if m.MessageType != MessageTypePKCSReq &&
+		m.MessageType != MessageTypeRenewalReq {
+		return ErrNoRequest
+	}
+	der, err := pkcs7.Decrypt(m.signedData.Content, ra, key)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if m.MessageType == MessageTypePKCSReq &&
+		!bytes.Equal(m.Signer.RawSubjectPublicKeyInfo,
+			csr.RawSubjectPublicKeyInfo) {
+		return ErrSignerKeyMismatch
+	}
+	password, err := getChallengePassword(csr)
+	if err != nil {
+		return err
+	}
+	m.CSR = csr
+	m.ChallengePassword = password
+	return nil
+}
+
+// build a CertRep for request. certs are sent, enveloped to the
+// request signer, only on success. failInfo is sent only on failure.
+func NewCertRep(req *PKIMessage, status PKIStatus, failInfo FailInfo,
+	certs []*x509.Certificate, ra *x509.Certificate,
+	key *rsa.PrivateKey) ([]byte, error) {
+	senderNonce := make([]byte, nonceSize)
+	if _, err := rand.Read(senderNonce); err != nil {
+		return nil, err
+	}
+	attributes := []pkcs7.Attribute{
+		{Type: oidMessageType, Value: string(MessageTypeCertRep)},
+		{Type: oidPKIStatus, Value: string(status)},
+		{Type: oidTransactionId, Value: req.TransactionId},
+		{Type: oidSenderNonce, Value: senderNonce},
+		{Type: oidRecipientNonce, Value: req.SenderNonce},
+	}
+	var content []byte
+	switch status {
+	case StatusFailure:
+		attributes = append(attributes,
+			pkcs7.Attribute{Type: oidFailInfo, Value: string(failInfo)})
+	case StatusSuccess:
+		degenerate, err := pkcs7.DegenerateCertificates(certs)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = pkcs7.Encrypt(degenerate, req.Signer); err != nil {
+			return nil, err
+		}
+	}
+	return pkcs7.Sign(content, attributes, ra, key)
+}
+
+// challenge password is a csr attribute that x509 does not expose
+func getChallengePassword(csr *x509.CertificateRequest) (string, error) {
+	var tbs tbsCertificateRequest
+	if _, err := asn1.Unmarshal(csr.RawTBSCertificateRequest, &tbs); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	for _, raw := range tbs.RawAttributes {
+		var a csrAttribute
+		if _, err := asn1.Unmarshal(raw.FullBytes, &a); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		if !a.Type.Equal(oidChallengePassword) {
+			continue
+		}
+		var password string
+		if _, err := asn1.Unmarshal(a.Values.Bytes, &password); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		return password, nil
+	}
+	return "", nil
+}
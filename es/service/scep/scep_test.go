@@ -0,0 +1,253 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package scep
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/pkcs7"
+)
+
+const testChallengePassword = "enrollment token"
+
+func TestPKIMessageRoundTrip(t *testing.T) {
+	ra, raKey := newTestCertificate(t, "ra")
+	device, deviceKey := newTestCertificate(t, "device")
+	der := newTestPKIMessage(t, MessageTypePKCSReq, ra, device, deviceKey)
+
+	m, err := ParsePKIMessage(der)
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if m.MessageType != MessageTypePKCSReq || m.TransactionId != "1234" {
+		t.Errorf("Unexpected message type %s or transaction id %s",
+			m.MessageType, m.TransactionId)
+	}
+	if err = m.Verify(); err != nil {
+		t.Fatalf("Failed to verify message: %v", err)
+	}
+	if err = m.DecryptRequest(ra, raKey); err != nil {
+		t.Fatalf("Failed to decrypt request: %v", err)
+	}
+	if m.ChallengePassword != testChallengePassword {
+		t.Errorf("Expected challenge password %q, found: %q",
+			testChallengePassword, m.ChallengePassword)
+	}
+	if m.CSR.Subject.CommonName != "device" {
+		t.Errorf("Expected csr for device, found: %s", m.CSR.Subject.CommonName)
+	}
+
+	rep, err := NewCertRep(m, StatusSuccess, "",
+		[]*x509.Certificate{device}, ra, raKey)
+	if err != nil {
+		t.Fatalf("Failed to create CertRep: %v", err)
+	}
+	sd, err := pkcs7.ParseSignedData(rep)
+	if err != nil {
+		t.Fatalf("Failed to parse CertRep: %v", err)
+	}
+	if err = sd.Verify(); err != nil {
+		t.Fatalf("Failed to verify CertRep: %v", err)
+	}
+	var status string
+	var recipientNonce []byte
+	if err = sd.GetAttribute(oidPKIStatus, &status); err != nil ||
+		PKIStatus(status) != StatusSuccess {
+		t.Errorf("Expected status %s, found: %s (%v)", StatusSuccess, status, err)
+	}
+	if err = sd.GetAttribute(oidRecipientNonce, &recipientNonce); err != nil ||
+		!bytes.Equal(recipientNonce, m.SenderNonce) {
+		t.Errorf("Expected recipient nonce to match sender nonce (%v)", err)
+	}
+	degenerate, err := pkcs7.Decrypt(sd.Content, device, deviceKey)
+	if err != nil {
+		t.Fatalf("Failed to decrypt CertRep: %v", err)
+	}
+	certs, err := pkcs7.ParseCertificates(degenerate)
+	if err != nil || len(certs) != 1 || !certs[0].Equal(device) {
+		t.Errorf("Expected device certificate in CertRep (%v)", err)
+	}
+}
+
+func TestCertRepFailure(t *testing.T) {
+	ra, raKey := newTestCertificate(t, "ra")
+	device, deviceKey := newTestCertificate(t, "device")
+	m, err := ParsePKIMessage(
+		newTestPKIMessage(t, MessageTypeCertPoll, ra, device, deviceKey))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	if err = m.DecryptRequest(ra, raKey); !errors.Is(err, ErrNoRequest) {
+		t.Errorf("Expected %v, found: %v", ErrNoRequest, err)
+	}
+	rep, err := NewCertRep(m, StatusFailure, FailInfoBadCertId, nil, ra, raKey)
+	if err != nil {
+		t.Fatalf("Failed to create CertRep: %v", err)
+	}
+	sd, err := pkcs7.ParseSignedData(rep)
+	if err != nil {
+		t.Fatalf("Failed to parse CertRep: %v", err)
+	}
+	var failInfo string
+	if err = sd.GetAttribute(oidFailInfo, &failInfo); err != nil ||
+		FailInfo(failInfo) != FailInfoBadCertId {
+		t.Errorf("Expected fail info %s, found: %s (%v)",
+			FailInfoBadCertId, failInfo, err)
+	}
+	if len(sd.Content) != 0 {
+		t.Errorf("Expected no content in failed CertRep")
+	}
+}
+
+// the csr key must sign a PKCSReq. a RenewalReq is signed with the
+// current certificate.
+func TestDecryptRequestChecksSignerKey(t *testing.T) {
+	ra, raKey := newTestCertificate(t, "ra")
+	device, deviceKey := newTestCertificate(t, "device")
+	_, otherKey := newTestCertificate(t, "other")
+	csr := newTestCSR(t, otherKey)
+
+	tests := map[MessageType]error{
+		MessageTypePKCSReq:    ErrSignerKeyMismatch,
+		MessageTypeRenewalReq: nil,
+	}
+	for messageType, expected := range tests {
+		m, err := ParsePKIMessage(newTestPKIMessageWithCSR(t, messageType,
+			ra, device, deviceKey, csr))
+		if err != nil {
+			t.Fatalf("Failed to parse message: %v", err)
+		}
+		if err = m.DecryptRequest(ra, raKey); !errors.Is(err, expected) {
+			t.Errorf("%s: expected %v, found: %v", messageType, expected, err)
+		}
+	}
+}
+
+func TestParsePKIMessageUnsupportedTypeFails(t *testing.T) {
+	ra, _ := newTestCertificate(t, "ra")
+	device, deviceKey := newTestCertificate(t, "device")
+	der := newTestPKIMessage(t, MessageTypeCertRep, ra, device, deviceKey)
+	if _, err := ParsePKIMessage(der); !errors.Is(err, ErrUnsupportedMessageType) {
+		t.Errorf("Expected %v, found: %v", ErrUnsupportedMessageType, err)
+	}
+}
+
+// build a request the way a scep client does
+func newTestPKIMessage(t *testing.T, messageType MessageType,
+	ra, signer *x509.Certificate, key *rsa.PrivateKey) []byte {
+	return newTestPKIMessageWithCSR(t, messageType, ra, signer, key,
+		newTestCSR(t, key))
+}
+
+func newTestPKIMessageWithCSR(t *testing.T, messageType MessageType,
+	ra, signer *x509.Certificate, key *rsa.PrivateKey, csr []byte) []byte {
+	envelope, err := pkcs7.Encrypt(csr, ra)
+	if err != nil {
+		t.Fatalf("Failed to encrypt csr: %v", err)
+	}
+	der, err := pkcs7.Sign(envelope, []pkcs7.Attribute{
+		{Type: oidMessageType, Value: string(messageType)},
+		{Type: oidTransactionId, Value: "1234"},
+		{Type: oidSenderNonce, Value: []byte("0123456789abcdef")},
+	}, signer, key)
+	if err != nil {
+		t.Fatalf("Failed to sign message: %v", err)
+	}
+	return der
+}
+
+// x509 cannot add a challenge password, so the request is built by hand
+func newTestCSR(t *testing.T, key *rsa.PrivateKey) []byte {
+	subject, err := asn1.Marshal(pkix.Name{CommonName: "device"}.ToRDNSequence())
+	if err != nil {
+		t.Fatalf("Failed to marshal subject: %v", err)
+	}
+	password, err := asn1.Marshal(csrAttribute{
+		Type:   oidChallengePassword,
+		Values: asn1.RawValue{FullBytes: mustMarshalSet(t, testChallengePassword)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal challenge password: %v", err)
+	}
+	tbs, err := asn1.Marshal(tbsCertificateRequest{
+		Subject:       asn1.RawValue{FullBytes: subject},
+		PublicKey:     asn1.RawValue{FullBytes: mustMarshalPublicKey(t, key)},
+		RawAttributes: []asn1.RawValue{{FullBytes: password}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal csr: %v", err)
+	}
+	digest := sha256.Sum256(tbs)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign csr: %v", err)
+	}
+	csr, err := asn1.Marshal(struct {
+		TBS                asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		TBS: asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11},
+			Parameters: asn1.NullRawValue,
+		},
+		Signature: asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal csr: %v", err)
+	}
+	return csr
+}
+
+func mustMarshalSet(t *testing.T, value string) []byte {
+	der, err := asn1.MarshalWithParams([]string{value}, "set")
+	if err != nil {
+		t.Fatalf("Failed to marshal set: %v", err)
+	}
+	return der
+}
+
+func mustMarshalPublicKey(t *testing.T, key *rsa.PrivateKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	return der
+}
+
+func newTestCertificate(t *testing.T, cn string) (*x509.Certificate,
+	*rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template,
+		key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
@@ -73,3 +73,108 @@ type Policy struct {
 	CreatedAt time.Time `json:"created_time"`
 	UpdatedAt time.Time `json:"updated_time,omitempty"`
 }
+
+// enroll record as listed for a tenant
+type EnrollRecord struct {
+	Id        uuid.UUID  `json:"id"`
+	RequestId string     `json:"request_id"`
+	TenantId  string     `json:"tenant_id"`
+	UserId    string     `json:"user_id"`
+	DeviceId  uuid.UUID  `json:"device_id"`
+	Status    int        `json:"status"`
+	CreatedAt time.Time  `json:"created_time"`
+	UpdatedAt *time.Time `json:"updated_time,omitempty"`
+}
+
+// filter for listing enroll records of a tenant
+type EnrollListFilter struct {
+	// match status if specified
+	Status *int
+	// only records created after this time if specified
+	CreatedAfter *time.Time
+	// keyset cursor. records after (AfterCreatedAt, AfterId)
+	AfterCreatedAt *time.Time
+	AfterId        uuid.UUID
+	// max records to return
+	Limit int
+}
+
+// single entry in the enroll history of a device
+type DeviceHistoryEntry struct {
+	Id        uuid.UUID `json:"id"`
+	RequestId string    `json:"request_id"`
+	// enroll, renew_enroll or unenroll
+	Operation string `json:"operation"`
+	// pending, completed or failed
+	Status    string `json:"status"`
+	UserId    string `json:"user_id,omitempty"`
+	ErrorCode int    `json:"error_code,omitempty"`
+	ErrorText string `json:"error_text,omitempty"`
+	// table the entry was read from
+	Source    string     `json:"source"`
+	CreatedAt time.Time  `json:"created_time"`
+	UpdatedAt *time.Time `json:"updated_time,omitempty"`
+}
+
+// failure details of an enroll or unenroll moved to an error table
+type EnrollErrorStatus struct {
+	TenantId  string    `json:"tenant_id"`
+	UserId    string    `json:"user_id"`
+	DeviceId  uuid.UUID `json:"device_id"`
+	ErrorCode int       `json:"error_code"`
+	ErrorText string    `json:"error_text"`
+}
+
+// request made with an Idempotency-Key header
+type IdempotentRequest struct {
+	Key string `json:"key"`
+	// hash of the request. a repeat must match
+	RequestHash string `json:"request_hash"`
+	// enroll or unenroll entry created by the original request
+	Id        uuid.UUID `json:"id"`
+	RequestId string    `json:"request_id"`
+}
+
+// token signing key fetched from the keys url of a token_types entry
+type PublicKey struct {
+	// name of the token_types entry
+	KeySource string `json:"key_source"`
+	Kid       string `json:"kid"`
+	// jwk signing algorithm, or key type (RSA, EC or OKP) if the jwk had none
+	Alg       string    `json:"alg"`
+	FirstSeen time.Time `json:"first_seen"`
+	// last time the source published the key
+	LastSeen  time.Time  `json:"last_seen"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// tenant callback for enroll lifecycle events
+type Webhook struct {
+	Id       uuid.UUID `json:"id"`
+	TenantId string    `json:"tenant_id"`
+	Url      string    `json:"url"`
+	// hmac signing secret. only returned on create.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_time"`
+}
+
+// an event queued for a webhook and its delivery state
+type WebhookDelivery struct {
+	Id        uuid.UUID
+	WebhookId uuid.UUID
+	TenantId  string
+	EventType string
+	// signed json event body
+	Payload string
+	// 0 pending, 1 delivered, -1 failed
+	Status        int
+	Attempts      int
+	ResponseCode  int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+	// webhook details, set when claimed for delivery
+	Url    string
+	Secret string
+}
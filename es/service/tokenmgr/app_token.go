@@ -3,11 +3,7 @@
 
 package tokenmgr
 
-import (
-	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
-)
+import "github.com/golang-jwt/jwt/v4"
 
 type AppTokenValidator struct {
 	settings *TokenIssuerSettings
@@ -34,26 +30,17 @@ func newAppTokenValidator(tokenSettings *TokenIssuerSettings) *AppTokenValidator
 func (v AppTokenValidator) ValidateToken(tokenStr string) (*EnrollClaims, error) {
 	var claims AppTokenClaims
 
-	token, err := jwt.ParseWithClaims(tokenStr, &claims, getPublicKeyForJwt)
-	if err != nil {
+	if err := v.settings.parseToken(tokenStr, &claims); err != nil {
 		return nil, err
-	} else if !token.Valid {
-		return nil, ErrInvalidToken
-	}
-
-	if token.Header["alg"] == nil {
-		return nil, ErrInvalidTokenHeaderSigningAlg
 	}
 	if claims.Type != appType {
 		return nil, ErrInvalidTypeClaim
 	}
-	if !strings.HasPrefix(claims.Issuer, v.settings.Issuer) {
-		return nil, ErrInvalidIssuerClaim
-	}
 	if !v.hasAppIdSubject(claims.Subject) {
 		return nil, ErrInvalidSubjectClaim
 	}
-	return &EnrollClaims{}, nil
+	// app tokens are not issued for a tenant. subject is the app id.
+	return &EnrollClaims{UserId: claims.Subject}, nil
 }
 
 // app token subjects should match one of the registered apps
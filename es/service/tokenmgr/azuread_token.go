@@ -3,11 +3,7 @@
 
 package tokenmgr
 
-import (
-	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
-)
+import "github.com/golang-jwt/jwt/v4"
 
 type AzureADTokenValidator struct {
 	settings *TokenIssuerSettings
@@ -29,25 +25,8 @@ func newAzureADTokenValidator(tokenSettings *TokenIssuerSettings) *AzureADTokenV
 func (v AzureADTokenValidator) ValidateToken(tokenString string) (*EnrollClaims, error) {
 	var claims AzureADTokenClaims
 
-	token, err := jwt.ParseWithClaims(tokenString, &claims,
-		getPublicKeyForJwt)
-	if err != nil {
+	if err := v.settings.parseToken(tokenString, &claims); err != nil {
 		return nil, err
-	} else if !token.Valid {
-		return nil, ErrInvalidToken
-	}
-
-	if token.Header["alg"] == nil {
-		return nil, ErrInvalidTokenHeaderSigningAlg
-	}
-
-	if v.settings.Audience != "" &&
-		!token.Claims.(*AzureADTokenClaims).VerifyAudience(v.settings.Audience, true) {
-		return nil, ErrInvalidAudienceClaim
-	}
-
-	if !strings.HasPrefix(claims.Issuer, v.settings.Issuer) {
-		return nil, ErrInvalidIssuerClaim
 	}
 
 	return &EnrollClaims{TenantId: claims.TenantId, UserId: claims.Subject}, nil
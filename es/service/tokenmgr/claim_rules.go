@@ -0,0 +1,177 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// claim rules in the token rejection metric
+const (
+	ruleSigningAlg     = "signing_alg"
+	ruleIssuer         = "issuer"
+	ruleAudience       = "audience"
+	ruleExpired        = "expired"
+	ruleNotYetValid    = "not_yet_valid"
+	ruleIssuedInFuture = "issued_in_future"
+	ruleTooOld         = "too_old"
+)
+
+// errors of the claim rules a token can fail
+var ruleErrors = []struct {
+	err  error
+	rule string
+}{
+	{ErrSigningAlgNotAllowed, ruleSigningAlg},
+	{ErrInvalidIssuerClaim, ruleIssuer},
+	{ErrInvalidAudienceClaim, ruleAudience},
+	{ErrTokenExpired, ruleExpired},
+	{ErrTokenNotYetValid, ruleNotYetValid},
+	{ErrTokenIssuedInFuture, ruleIssuedInFuture},
+	{ErrTokenTooOld, ruleTooOld},
+}
+
+// parse tokenString into claims and apply the claim rules of s. every
+// validator of a signed token parses with this so rules apply the same
+// way for all token types.
+func (s *TokenIssuerSettings) parseToken(tokenString string,
+	claims jwt.Claims) error {
+	// time claims are verified with leeway below
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, claims,
+		s.getPublicKeyForJwt)
+	if err == nil && !token.Valid {
+		err = ErrInvalidToken
+	}
+	if err == nil {
+		err = s.verifyClaims(token, time.Now())
+	}
+	if err != nil {
+		s.reportRejection(err)
+	}
+	return err
+}
+
+func (s *TokenIssuerSettings) verifyClaims(token *jwt.Token, now time.Time) error {
+	claims, err := getRegisteredClaims(token)
+	if err != nil {
+		return err
+	}
+	if !s.isIssuerAllowed(claims.Issuer) {
+		return ErrInvalidIssuerClaim
+	}
+	if !s.isAudienceAllowed(claims.Audience) {
+		return ErrInvalidAudienceClaim
+	}
+
+	leeway := time.Second * time.Duration(s.Leeway)
+	if claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(leeway).Before(claims.NotBefore.Time) {
+		return ErrTokenNotYetValid
+	}
+	if claims.IssuedAt != nil && now.Add(leeway).Before(claims.IssuedAt.Time) {
+		return ErrTokenIssuedInFuture
+	}
+	if s.MaxTokenAge > 0 {
+		maxAge := time.Second * time.Duration(s.MaxTokenAge)
+		if claims.IssuedAt == nil ||
+			now.Sub(claims.IssuedAt.Time) > maxAge+leeway {
+			return ErrTokenTooOld
+		}
+	}
+	return nil
+}
+
+// issuers lists exact issuers. discovered issuers must match exactly.
+// issuer is otherwise a deprecated prefix, eg: azuread issuers end in
+// tenant ids.
+func (s *TokenIssuerSettings) isIssuerAllowed(issuer string) bool {
+	if len(s.Issuers) > 0 {
+		return slices.Contains(s.Issuers, issuer)
+	}
+	if s.usesDiscovery() {
+		return issuer == s.issuer()
+	}
+	return isIssuerPrefix(s.issuer(), issuer)
+}
+
+// prefix only matches whole path segments so https://sts.windows.net
+// does not match https://sts.windows.net.example.test
+func isIssuerPrefix(prefix, issuer string) bool {
+	if prefix == "" {
+		return false
+	}
+	if issuer == prefix {
+		return true
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return strings.HasPrefix(issuer, prefix)
+}
+
+// tokens must be for one of audiences, or audience if set
+func (s *TokenIssuerSettings) isAudienceAllowed(audience jwt.ClaimStrings) bool {
+	allowed := s.Audiences
+	if len(allowed) == 0 && s.Audience != "" {
+		allowed = []string{s.Audience}
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range audience {
+		if slices.Contains(allowed, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed_algs if set, otherwise algorithms of a discovered issuer.
+// any algorithm the key allows is accepted if neither is known.
+func (s *TokenIssuerSettings) isSigningAlgAllowed(alg string) bool {
+	if len(s.AllowedAlgs) > 0 {
+		return slices.Contains(s.AllowedAlgs, alg)
+	}
+	return s.isSigningAlgSupported(alg)
+}
+
+func (s *TokenIssuerSettings) reportRejection(err error) {
+	for _, r := range ruleErrors {
+		if errors.Is(err, r.err) {
+			esLogger.Error("Token rejected",
+				zap.String("token_type", s.Name),
+				zap.String("reason", r.rule))
+			metrics.ReportTokenRejection(s.Name, r.rule)
+			return
+		}
+	}
+}
+
+// registered claims of any claims type
+func getRegisteredClaims(token *jwt.Token) (*jwt.RegisteredClaims, error) {
+	parts := strings.Split(token.Raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	payload, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims jwt.RegisteredClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestParseTokenClaimRules(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:      t.Name(),
+		KeysURL:   testOIDCIssuer + "/keys",
+		Issuers:   []string{testOIDCIssuer, "https://other.example.test"},
+		Audiences: []string{"api://other", testOIDCAudience},
+		Leeway:    60,
+	}
+	sign := newTestOIDCSigner(t, s)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		err    error
+	}{
+		{"valid", func(c jwt.MapClaims) {}, nil},
+		{"audience list", func(c jwt.MapClaims) {
+			c["aud"] = []string{"api://unknown", "api://other"}
+		}, nil},
+		{"expired within leeway", func(c jwt.MapClaims) {
+			c["exp"] = now.Add(-time.Second * 30).Unix()
+		}, nil},
+		{"not before within leeway", func(c jwt.MapClaims) {
+			c["nbf"] = now.Add(time.Second * 30).Unix()
+		}, nil},
+		{"issuer prefix", func(c jwt.MapClaims) {
+			c["iss"] = testOIDCIssuer + "/tenant"
+		}, ErrInvalidIssuerClaim},
+		{"audience", func(c jwt.MapClaims) { c["aud"] = "api://unknown" },
+			ErrInvalidAudienceClaim},
+		{"no audience", func(c jwt.MapClaims) { delete(c, "aud") },
+			ErrInvalidAudienceClaim},
+		{"expired", func(c jwt.MapClaims) {
+			c["exp"] = now.Add(-time.Minute * 2).Unix()
+		}, ErrTokenExpired},
+		{"not before", func(c jwt.MapClaims) {
+			c["nbf"] = now.Add(time.Minute * 2).Unix()
+		}, ErrTokenNotYetValid},
+		{"issued in future", func(c jwt.MapClaims) {
+			c["iat"] = now.Add(time.Minute * 2).Unix()
+		}, ErrTokenIssuedInFuture},
+	}
+	for _, test := range tests {
+		claims := newTestOIDCClaims()
+		test.modify(claims)
+		err := s.parseToken(sign(claims), jwt.MapClaims{})
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, Got %v\n", test.name, test.err, err)
+		}
+	}
+}
+
+func TestParseTokenMaxTokenAge(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:        t.Name(),
+		KeysURL:     testOIDCIssuer + "/keys",
+		Issuer:      testOIDCIssuer,
+		MaxTokenAge: 300,
+	}
+	sign := newTestOIDCSigner(t, s)
+
+	claims := newTestOIDCClaims()
+	claims["iat"] = time.Now().Add(-time.Minute).Unix()
+	if err := s.parseToken(sign(claims), jwt.MapClaims{}); err != nil {
+		t.Errorf("Expected token within max age, Got %v\n", err)
+	}
+	claims["iat"] = time.Now().Add(-time.Minute * 10).Unix()
+	if err := s.parseToken(sign(claims), jwt.MapClaims{}); !errors.Is(
+		err, ErrTokenTooOld) {
+		t.Errorf("Expected %v, Got %v\n", ErrTokenTooOld, err)
+	}
+	// age cannot be checked without iat
+	delete(claims, "iat")
+	if err := s.parseToken(sign(claims), jwt.MapClaims{}); !errors.Is(
+		err, ErrTokenTooOld) {
+		t.Errorf("Expected %v without iat, Got %v\n", ErrTokenTooOld, err)
+	}
+}
+
+func TestParseTokenAllowedAlgs(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:        t.Name(),
+		KeysURL:     testOIDCIssuer + "/keys",
+		Issuer:      testOIDCIssuer,
+		AllowedAlgs: []string{"RS256"},
+	}
+	sign := newTestOIDCSigner(t, s)
+	err := s.parseToken(sign(newTestOIDCClaims()), jwt.MapClaims{})
+	if !errors.Is(err, ErrSigningAlgNotAllowed) {
+		t.Errorf("Expected %v, Got %v\n", ErrSigningAlgNotAllowed, err)
+	}
+	s.AllowedAlgs = []string{"RS256", "ES256"}
+	if err = s.parseToken(sign(newTestOIDCClaims()), jwt.MapClaims{}); err != nil {
+		t.Errorf("Expected allowed signing algorithm, Got %v\n", err)
+	}
+}
+
+// rules apply to every validator, not only oidc
+func TestClaimRulesApplyToAllValidators(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:            t.Name(),
+		KeysURL:         testOIDCIssuer + "/keys",
+		Issuer:          testOIDCIssuer,
+		Issuers:         []string{"https://other.example.test"},
+		AllowedAppIds:   []string{"user1"},
+		DefaultTenantId: "tenant1",
+	}
+	sign := newTestOIDCSigner(t, s)
+	claims := newTestOIDCClaims()
+	claims["typ"] = appType
+	token := sign(claims)
+
+	validators := map[string]TokenValidator{
+		"azuread": newAzureADTokenValidator(s),
+		"device":  newDstsDeviceTokenValidator(s),
+		"test":    newTestTokenValidator(s),
+		"app":     newAppTokenValidator(s),
+		"oidc":    newOIDCTokenValidator(s),
+	}
+	for name, v := range validators {
+		if _, err := v.ValidateToken(token); !errors.Is(err, ErrInvalidIssuerClaim) {
+			t.Errorf("%s: expected %v, Got %v\n", name, ErrInvalidIssuerClaim, err)
+		}
+	}
+}
+
+// the deprecated issuer prefix only matches whole path segments
+func TestIsIssuerPrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		issuer   string
+		expected bool
+	}{
+		{"https://sts.windows.net", "https://sts.windows.net/tenant1/", true},
+		{"https://sts.windows.net/", "https://sts.windows.net/tenant1/", true},
+		{"https://sts.windows.net", "https://sts.windows.net", true},
+		{"https://sts.windows.net", "https://sts.windows.net.evil.test/", false},
+		{"https://sts.windows.net", "https://sts.windows.netevil/", false},
+		{"HP Device Token Service", "HP Device Token Service", true},
+		{"HP Device Token Service", "HP Device Token Service2", false},
+		{"", "https://sts.windows.net/tenant1/", false},
+	}
+	for _, test := range tests {
+		if found := isIssuerPrefix(test.prefix, test.issuer); found != test.expected {
+			t.Errorf("%s with prefix %s: expected %v, Got %v\n", test.issuer,
+				test.prefix, test.expected, found)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// ktyEC is the key type (kty) in the JWT header for ECDSA.
+	ktyEC = "EC"
+)
+
+// curves (crv) for ES256, ES384 and ES512 signing keys
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// ECDSA parses a jsonWebKey and turns it into an ECDSA public key.
+func (j *jsonWebKey) ECDSA() (*ecdsa.PublicKey, error) {
+	if j.Curve == "" || j.X == "" || j.Y == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAssets, ktyEC)
+	}
+	curve, ok := jwkCurves[j.Curve]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, j.Curve)
+	}
+
+	// x and y are base64url encoded, big endian coordinates of the
+	// full size of the curve. RFC 7518 Section 6.2.1
+	x, err := base64urlTrailingPadding(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64urlTrailingPadding(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("%w: %s coordinates must be %d bytes",
+			ErrInvalidKey, j.Curve, size)
+	}
+
+	publicKey := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, fmt.Errorf("%w: point is not on %s", ErrInvalidKey,
+			j.Curve)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+const (
+	// ktyOKP is the key type (kty) in the JWT header for EdDSA.
+	ktyOKP = "OKP"
+
+	curveEd25519 = "Ed25519"
+)
+
+// Ed25519 parses a jsonWebKey and turns it into an Ed25519 public key.
+// RFC 8037 Section 2
+func (j *jsonWebKey) Ed25519() (ed25519.PublicKey, error) {
+	if j.Curve == "" || j.X == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAssets, ktyOKP)
+	}
+	if j.Curve != curveEd25519 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurve, j.Curve)
+	}
+	x, err := base64urlTrailingPadding(j.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: %s key must be %d bytes", ErrInvalidKey,
+			curveEd25519, ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(x), nil
+}
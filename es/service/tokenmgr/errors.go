@@ -9,7 +9,17 @@ var (
 	ErrTokenConfigurationInitFailure = errors.New("failed to initialize token configuration")
 	ErrMissingKeySources             = errors.New("no JWKs sources found in token configuration")
 	ErrKIDNotFound                   = errors.New("the given key ID was not found in the JWKS")
+	ErrKeysFetchFailed               = errors.New("failed to fetch keys from keys url")
+	ErrDiscoveryFetchFailed          = errors.New("failed to fetch openid configuration")
+	ErrInvalidDiscoveryDocument      = errors.New("invalid openid configuration")
+	ErrDiscoveryIssuerMismatch       = errors.New("discovered issuer does not match configured issuer")
+	ErrKeyRefreshRateLimited         = errors.New("keys were refreshed too recently")
 	ErrMissingAssets                 = errors.New("required assets are missing to create a public key")
+	ErrUnsupportedKeyType            = errors.New("unsupported key type")
+	ErrUnsupportedCurve              = errors.New("unsupported key curve")
+	ErrInvalidKey                    = errors.New("invalid public key")
+	ErrUnsupportedKeyAlg             = errors.New("unsupported key algorithm")
+	ErrKeyAlgMismatch                = errors.New("key algorithm does not match key type")
 	ErrValidatorNotImplemented       = errors.New("the requested token validator is not implemented")
 	ErrUnsupportedTokenType          = errors.New("X-HP-Token-Type header contains an unsupported token type")
 	ErrInvalidToken                  = errors.New("invalid token provided")
@@ -18,5 +28,12 @@ var (
 	ErrInvalidIssuerClaim            = errors.New("specified token contains an invalid issuer claim")
 	ErrInvalidAudienceClaim          = errors.New("specified token contains an invalid audience claim")
 	ErrInvalidTypeClaim              = errors.New("specified token contains an invalid typ claim")
+	ErrRequiredClaim                 = errors.New("specified token does not have a required claim value")
+	ErrMappedClaim                   = errors.New("specified token does not have a mapped enroll claim")
+	ErrSigningAlgNotAllowed          = errors.New("token signing algorithm is not allowed for the token type")
+	ErrTokenExpired                  = errors.New("specified token is expired")
+	ErrTokenNotYetValid              = errors.New("specified token is not valid yet")
+	ErrTokenIssuedInFuture           = errors.New("specified token has an iat claim in the future")
+	ErrTokenTooOld                   = errors.New("specified token is older than the maximum token age")
 	ErrInvalidSubjectClaim           = errors.New("specified token contains an invalid sub claim")
 )
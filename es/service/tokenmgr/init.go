@@ -6,6 +6,7 @@ package tokenmgr
 import (
 	"context"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +29,8 @@ func Init(logger *zap.Logger, tokenConfigFile string) error {
 		return ErrTokenConfigurationInitFailure
 	}
 
+	cache.SubscribeKeyRevoked(onKeyRevoked)
+
 	refreshKeysStopChannel = make(chan bool)
 	return startJwksRefresher()
 }
@@ -0,0 +1,91 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// tokens with a kid not yet stored refresh the keys of their key
+	// source at most once in this interval by default. it bounds calls
+	// to the keys url when tokens with made up kids are presented.
+	defaultMinRefreshInterval = time.Second * 30
+
+	// refresh triggers and results for the refresh outcome metric
+	refreshTriggerScheduled  = "scheduled"
+	refreshTriggerUnknownKid = "unknown_kid"
+
+	refreshResultSuccess        = "success"
+	refreshResultDiscoveryError = "discovery_error"
+	refreshResultFetchError     = "fetch_error"
+	refreshResultParseError     = "parse_error"
+	refreshResultRateLimited    = "rate_limited"
+)
+
+var (
+	keyRefresher = newOnDemandRefresher(refreshKeySource)
+)
+
+// refreshes keys of a key source when a token is signed with a key that
+// was published after the last scheduled refresh, eg: after a key
+// rollover. concurrent refreshes of a key source share one fetch.
+type onDemandRefresher struct {
+	sync.Mutex
+	group singleflight.Group
+	// start of the last refresh of each key source
+	lastRefresh map[string]time.Time
+	refresh     func(*TokenIssuerSettings, string) error
+}
+
+func newOnDemandRefresher(
+	refresh func(*TokenIssuerSettings, string) error) *onDemandRefresher {
+	return &onDemandRefresher{
+		lastRefresh: map[string]time.Time{},
+		refresh:     refresh,
+	}
+}
+
+// refresh keys of keySource unless they were refreshed on demand within
+// its min refresh interval. callers that arrive during a refresh wait
+// for it and share its result.
+func (r *onDemandRefresher) refreshKeys(keySource *TokenIssuerSettings) error {
+	_, err, _ := r.group.Do(keySource.Name, func() (interface{}, error) {
+		if !r.allow(keySource) {
+			metrics.ReportJwksRefresh(keySource.Name,
+				refreshTriggerUnknownKid, refreshResultRateLimited)
+			return nil, ErrKeyRefreshRateLimited
+		}
+		esLogger.Info("Refreshing keys for unknown kid",
+			zap.String("source", keySource.Name))
+		return nil, r.refresh(keySource, refreshTriggerUnknownKid)
+	})
+	return err
+}
+
+// failed refreshes count towards the interval so an unreachable keys
+// url is not called for every token.
+func (r *onDemandRefresher) allow(keySource *TokenIssuerSettings) bool {
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	if last, ok := r.lastRefresh[keySource.Name]; ok &&
+		now.Sub(last) < keySource.minRefreshInterval() {
+		return false
+	}
+	r.lastRefresh[keySource.Name] = now
+	return true
+}
+
+func (s *TokenIssuerSettings) minRefreshInterval() time.Duration {
+	if s.MinRefreshInterval > 0 {
+		return time.Second * time.Duration(s.MinRefreshInterval)
+	}
+	return defaultMinRefreshInterval
+}
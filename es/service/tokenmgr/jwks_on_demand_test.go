@@ -0,0 +1,94 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// concurrent refreshes of a key source share one fetch
+func TestOnDemandRefreshIsSingleFlight(t *testing.T) {
+	esLogger = zap.NewNop()
+	var calls int32
+	release := make(chan struct{})
+	r := newOnDemandRefresher(func(*TokenIssuerSettings, string) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	})
+	s := &TokenIssuerSettings{Name: "test"}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- r.refreshKeys(s)
+		}()
+	}
+	// let callers join the refresh in flight
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	if calls != 1 {
+		t.Errorf("Expected 1 refresh, Got %d\n", calls)
+	}
+	for err := range errs {
+		if err != nil && !errors.Is(err, ErrKeyRefreshRateLimited) {
+			t.Errorf("Expected refresh to succeed, Got %v\n", err)
+		}
+	}
+}
+
+func TestOnDemandRefreshIsRateLimited(t *testing.T) {
+	esLogger = zap.NewNop()
+	var calls int32
+	r := newOnDemandRefresher(func(*TokenIssuerSettings, string) error {
+		atomic.AddInt32(&calls, 1)
+		return ErrKeysFetchFailed
+	})
+	s := &TokenIssuerSettings{Name: "test", MinRefreshInterval: 60}
+
+	if err := r.refreshKeys(s); !errors.Is(err, ErrKeysFetchFailed) {
+		t.Errorf("Expected %v, Got %v\n", ErrKeysFetchFailed, err)
+	}
+	// failed refreshes are rate limited too
+	if err := r.refreshKeys(s); !errors.Is(err, ErrKeyRefreshRateLimited) {
+		t.Errorf("Expected %v, Got %v\n", ErrKeyRefreshRateLimited, err)
+	}
+	// other key sources are not affected
+	if err := r.refreshKeys(&TokenIssuerSettings{Name: "azuread"}); errors.Is(
+		err, ErrKeyRefreshRateLimited) {
+		t.Errorf("Expected refresh of other key source, Got %v\n", err)
+	}
+	// refresh is allowed again after the interval
+	r.lastRefresh[s.Name] = time.Now().Add(-time.Minute * 2)
+	if err := r.refreshKeys(s); errors.Is(err, ErrKeyRefreshRateLimited) {
+		t.Errorf("Expected refresh after interval, Got %v\n", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 refreshes, Got %d\n", calls)
+	}
+}
+
+func TestMinRefreshIntervalDefault(t *testing.T) {
+	s := TokenIssuerSettings{}
+	if s.minRefreshInterval() != defaultMinRefreshInterval {
+		t.Errorf("Expected %v, Got %v\n", defaultMinRefreshInterval,
+			s.minRefreshInterval())
+	}
+	s.MinRefreshInterval = 5
+	if s.minRefreshInterval() != time.Second*5 {
+		t.Errorf("Expected 5s, Got %v\n", s.minRefreshInterval())
+	}
+}
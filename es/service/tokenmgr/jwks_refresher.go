@@ -5,21 +5,40 @@ package tokenmgr
 
 import (
 	"context"
+	"crypto"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 )
 
 const (
 	// timeout for jwks http calls
 	timeoutJwksGet = time.Second * time.Duration(5)
+
+	// keys no longer published expire after this by default. it covers
+	// tokens signed just before a key is rotated out.
+	defaultKeyGracePeriod = time.Hour * 24
+
+	// public key use (use) for signature keys
+	useSignature = "sig"
+
+	// pem block types of stored keys
+	pemTypeRsaPublicKey = "RSA PUBLIC KEY"
+	pemTypePublicKey    = "PUBLIC KEY"
 )
 
 // jsonWebKey represents a JSON Web Key inside a JWKS.
 type jsonWebKey struct {
+	Alg      string `json:"alg"`
 	Curve    string `json:"crv"`
 	Exponent string `json:"e"`
 	K        string `json:"k"`
@@ -40,10 +59,10 @@ func startJwksRefresher() error {
 	esLogger.Info("Starting JWKs refresher worker")
 	refreshed := 0
 	for k, v := range tokenConfig.TokenTypes {
-		if v.KeysURL == "" {
+		if v.KeysURL == "" && !v.usesDiscovery() {
 			esLogger.Info("Skipping refresh",
 				zap.String("name", string(k)),
-				zap.String("reason", "empty keys url"))
+				zap.String("reason", "empty keys url and no issuer url"))
 			continue
 		}
 		esLogger.Info("Starting worker", zap.String("name:", string(k)))
@@ -59,38 +78,99 @@ func startJwksRefresher() error {
 func doRefresh(keySource TokenIssuerSettings) {
 	esLogger.Info("Keys source",
 		zap.String("name:", keySource.KeysURL),
+		zap.Bool("discovery:", keySource.usesDiscovery()),
 		zap.Int("refresh_interval:", keySource.RefreshInterval))
 	ticker := time.NewTicker(time.Second * time.Duration(keySource.RefreshInterval))
 	go func() {
 		// do an immediate refresh
-		processJWKS(keySource.KeysURL)
+		_ = refreshKeySource(&keySource, refreshTriggerScheduled)
 		for {
 			select {
 			case <-refreshKeysStopChannel:
 				break
 			case <-ticker.C:
-				processJWKS(keySource.KeysURL)
+				_ = refreshKeySource(&keySource, refreshTriggerScheduled)
 			}
 		}
 	}()
 }
 
-func processJWKS(url string) {
+// discovery is refreshed with the keys so issuers can move their keys
+// url. if it fails, keys are refreshed from the last discovered url.
+func refreshKeySource(keySource *TokenIssuerSettings, trigger string) error {
+	if keySource.usesDiscovery() {
+		if err := discoverIssuer(keySource); err != nil {
+			esLogger.Error("Error discovering issuer.",
+				zap.String("issuer:", keySource.Issuer),
+				zap.Error(err))
+			metrics.ReportJwksRefresh(keySource.Name, trigger,
+				refreshResultDiscoveryError)
+			if keySource.keysURL() == "" {
+				return err
+			}
+		}
+	}
+	return processJWKS(keySource, trigger)
+}
+
+// keys are stored for the token_types entry of keySource. keys it no
+// longer publishes are expired only after a successful refresh so an
+// unreachable keys url does not expire keys. trigger labels the
+// refresh outcome metric.
+func processJWKS(keySource *TokenIssuerSettings, trigger string) error {
+	url := keySource.keysURL()
 	bytes, err := GetKeysFromServer(url)
 	if err != nil {
 		esLogger.Error("Error fetching keys.",
 			zap.String("url:", url),
 			zap.Error(err))
-		return
+		metrics.ReportJwksRefresh(keySource.Name, trigger,
+			refreshResultFetchError)
+		return err
 	}
-	if err = parseJWKS(bytes); err != nil {
+	if err = parseJWKS(keySource.Name, bytes); err != nil {
 		esLogger.Error("Error parsing keys.",
 			zap.String("url:", url),
 			zap.Error(err))
+		metrics.ReportJwksRefresh(keySource.Name, trigger,
+			refreshResultParseError)
+		return err
+	}
+	metrics.ReportJwksRefresh(keySource.Name, trigger, refreshResultSuccess)
+	expireUnseenKeys(keySource)
+	reportNewestKey(keySource)
+	return nil
+}
+
+func expireUnseenKeys(keySource *TokenIssuerSettings) {
+	gracePeriod := defaultKeyGracePeriod
+	if keySource.KeyGracePeriod > 0 {
+		gracePeriod = time.Second * time.Duration(keySource.KeyGracePeriod)
+	}
+	count, err := db.DeleteUnseenKeys(keySource.Name,
+		time.Now().Add(-gracePeriod))
+	if err != nil {
+		esLogger.Error("Error expiring keys.",
+			zap.String("source", keySource.Name),
+			zap.Error(err))
+		return
+	}
+	if count > 0 {
+		esLogger.Info("Expired keys no longer published",
+			zap.String("source", keySource.Name),
+			zap.Int64("count", count))
 	}
 }
 
-func parseJWKS(jwksBytes json.RawMessage) (err error) {
+func reportNewestKey(keySource *TokenIssuerSettings) {
+	firstSeen, err := db.GetNewestKeyTime(keySource.Name)
+	if err != nil {
+		return
+	}
+	metrics.ReportNewestSigningKey(keySource.Name, firstSeen)
+}
+
+func parseJWKS(source string, jwksBytes json.RawMessage) (err error) {
 	var rawKS rawJWKS
 	err = json.Unmarshal(jwksBytes, &rawKS)
 	if err != nil {
@@ -100,16 +180,25 @@ func parseJWKS(jwksBytes json.RawMessage) (err error) {
 	}
 	for _, key := range rawKS.Keys {
 		switch keyType := key.Type; keyType {
-		case ktyRSA:
+		case ktyRSA, ktyEC, ktyOKP:
+			// keys for encryption cannot verify tokens
+			if key.Use != "" && key.Use != useSignature {
+				continue
+			}
 			str, err := key.Pem()
+			if err == nil {
+				err = key.checkAlg()
+			}
 			if err != nil {
-				esLogger.Error("Error parsing rsa key",
+				esLogger.Error("Error parsing key",
 					zap.String("type:", key.Type),
+					zap.String("alg:", key.Alg),
 					zap.String("kid:", key.ID),
 					zap.Error(err))
 				continue
 			}
-			if err = setPublicKey(key.ID, key.Type, str); err != nil {
+			if err = setPublicKey(source, key.ID, key.storedAlg(),
+				str); err != nil {
 				return err
 			}
 		default:
@@ -120,15 +209,86 @@ func parseJWKS(jwksBytes json.RawMessage) (err error) {
 	return nil
 }
 
+// PublicKey parses a jsonWebKey of any supported key type.
+func (j *jsonWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch j.Type {
+	case ktyRSA:
+		return j.RSA()
+	case ktyEC:
+		return j.ECDSA()
+	case ktyOKP:
+		return j.Ed25519()
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, j.Type)
+}
+
+// the alg of a jwk is optional. if set, it must be a signing algorithm
+// for the key.
+func (j *jsonWebKey) checkAlg() error {
+	if j.Alg == "" {
+		return nil
+	}
+	method := jwt.GetSigningMethod(j.Alg)
+	if method == nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedKeyAlg, j.Alg)
+	}
+	publicKey, err := j.PublicKey()
+	if err != nil {
+		return err
+	}
+	if !isKeyForSigningMethod(method, publicKey) {
+		return fmt.Errorf("%w: %s", ErrKeyAlgMismatch, j.Alg)
+	}
+	return nil
+}
+
+// tokens verified with the key are pinned to the stored alg. jwks
+// without alg are stored with their kty and are not pinned.
+func (j *jsonWebKey) storedAlg() string {
+	if j.Alg != "" {
+		return j.Alg
+	}
+	return j.Type
+}
+
+// pem encoded pkix public key for storage. rsa keys keep the block type
+// of keys stored before other key types were supported.
+func (j *jsonWebKey) Pem() (string, error) {
+	publicKey, err := j.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	pubkey_bytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	blockType := pemTypePublicKey
+	if j.Type == ktyRSA {
+		blockType = pemTypeRsaPublicKey
+	}
+	pubkey_pem := pem.EncodeToMemory(
+		&pem.Block{
+			Type:  blockType,
+			Bytes: pubkey_bytes,
+		},
+	)
+	return string(pubkey_pem), nil
+}
+
 // jwks requests
 // adds a default timeout for http calls
 func GetKeysFromServer(url string) (keys []byte, err error) {
+	return getFromServer(url)
+}
+
+// get url with a default timeout. fails on any status other than 200.
+func getFromServer(url string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(gCtx, timeoutJwksGet)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		esLogger.Error("Error creating request for keys",
+		esLogger.Error("Error creating request",
 			zap.String("url", url),
 			zap.Error(err))
 		return nil, err
@@ -136,18 +296,18 @@ func GetKeysFromServer(url string) (keys []byte, err error) {
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		esLogger.Error("Error fetching keys",
+		esLogger.Error("Error fetching url",
 			zap.String("url", url),
 			zap.Error(err))
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		esLogger.Error("Get public keys failed",
+		esLogger.Error("Get url failed",
 			zap.String("url", url),
-			zap.Int("status", resp.StatusCode),
-			zap.Error(err))
-		return nil, err
+			zap.Int("status", resp.StatusCode))
+		return nil, fmt.Errorf("%w: status %d", ErrKeysFetchFailed,
+			resp.StatusCode)
 	}
 	return io.ReadAll(resp.Body)
 }
@@ -5,8 +5,16 @@ package tokenmgr
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -37,3 +45,102 @@ func TestJwksRefreshTimeout(t *testing.T) {
 		t.Errorf("Expected error %v, Got %v\n", expected, err)
 	}
 }
+
+// jwks keys of each supported type survive pem storage
+func TestJwkPemRoundTrip(t *testing.T) {
+	esLogger, _ = zap.NewProduction(zap.AddCaller())
+	defer esLogger.Sync()
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	encode := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		jwk jsonWebKey
+		key crypto.PublicKey
+	}{
+		{jsonWebKey{Type: ktyRSA, Modulus: encode(rsaKey.N.Bytes()),
+			Exponent: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			&rsaKey.PublicKey},
+		{jsonWebKey{Type: ktyEC, Curve: "P-384",
+			X: encode(ecKey.X.FillBytes(make([]byte, 48))),
+			Y: encode(ecKey.Y.FillBytes(make([]byte, 48)))},
+			&ecKey.PublicKey},
+		{jsonWebKey{Type: ktyOKP, Curve: curveEd25519, X: encode(edKey)},
+			edKey},
+	}
+	for _, test := range tests {
+		str, err := test.jwk.Pem()
+		if err != nil {
+			t.Errorf("%s: expected no error, Got %v\n", test.jwk.Type, err)
+			continue
+		}
+		pubkey, err := makePublicKey(str)
+		if err != nil {
+			t.Errorf("%s: expected no error, Got %v\n", test.jwk.Type, err)
+			continue
+		}
+		if !test.key.(interface{ Equal(crypto.PublicKey) bool }).Equal(pubkey) {
+			t.Errorf("%s: expected stored key to match jwk\n", test.jwk.Type)
+		}
+	}
+}
+
+func TestJwkInvalidKeysFail(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := base64.RawURLEncoding.EncodeToString
+	x := encode(ecKey.X.FillBytes(make([]byte, 32)))
+	y := encode(ecKey.Y.FillBytes(make([]byte, 32)))
+
+	tests := []struct {
+		jwk jsonWebKey
+		err error
+	}{
+		{jsonWebKey{Type: "oct", K: x}, ErrUnsupportedKeyType},
+		{jsonWebKey{Type: ktyEC, Curve: "P-256", X: x}, ErrMissingAssets},
+		{jsonWebKey{Type: ktyEC, Curve: "secp256k1", X: x, Y: y},
+			ErrUnsupportedCurve},
+		{jsonWebKey{Type: ktyEC, Curve: "P-384", X: x, Y: y}, ErrInvalidKey},
+		{jsonWebKey{Type: ktyEC, Curve: "P-256", X: x, Y: x}, ErrInvalidKey},
+		{jsonWebKey{Type: ktyOKP, Curve: "X25519", X: x}, ErrUnsupportedCurve},
+		{jsonWebKey{Type: ktyOKP, Curve: curveEd25519, X: encode([]byte{1})},
+			ErrInvalidKey},
+	}
+	for i, test := range tests {
+		if _, err := test.jwk.PublicKey(); !errors.Is(err, test.err) {
+			t.Errorf("%d: expected error %v, Got %v\n", i, test.err, err)
+		}
+	}
+}
+
+// the optional jwk alg must be a signing algorithm for the key
+func TestJwkCheckAlg(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	encode := base64.RawURLEncoding.EncodeToString
+	jwk := jsonWebKey{Type: ktyEC, Curve: "P-384",
+		X: encode(ecKey.X.FillBytes(make([]byte, 48))),
+		Y: encode(ecKey.Y.FillBytes(make([]byte, 48)))}
+
+	tests := []struct {
+		alg    string
+		stored string
+		err    error
+	}{
+		{"", ktyEC, nil},
+		{"ES384", "ES384", nil},
+		{"ES256", "ES256", ErrKeyAlgMismatch},
+		{"RS256", "RS256", ErrKeyAlgMismatch},
+		{"ES999", "ES999", ErrUnsupportedKeyAlg},
+	}
+	for _, test := range tests {
+		jwk.Alg = test.alg
+		if err := jwk.checkAlg(); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, Got %v\n", test.alg, test.err, err)
+		}
+		if stored := jwk.storedAlg(); stored != test.stored {
+			t.Errorf("%s: expected stored alg %s, Got %s\n", test.alg,
+				test.stored, stored)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"crypto"
+	"sync"
+	"time"
+)
+
+const (
+	// keys are loaded from db again after this long so keys expired or
+	// revoked by another replica stop verifying tokens even when the
+	// revocation is not published through the cache.
+	keyStoreTTL = time.Minute
+)
+
+// kids are only unique within the keys of a key source
+type publicKeyId struct {
+	source string
+	kid    string
+}
+
+// public key with the alg stored for it. alg is the jwk alg, or the
+// kty for jwks without alg and keys stored before alg was kept.
+type signingKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+type storedKey struct {
+	signingKey
+	loadedAt time.Time
+}
+
+// in memory index of public keys loaded from db. safe for concurrent use.
+type keyStore struct {
+	sync.RWMutex
+	keys map[publicKeyId]storedKey
+	ttl  time.Duration
+}
+
+func newKeyStore(ttl time.Duration) *keyStore {
+	return &keyStore{keys: map[publicKeyId]storedKey{}, ttl: ttl}
+}
+
+// ok is false if key is not loaded or was loaded longer than ttl ago
+func (s *keyStore) get(id publicKeyId) (*signingKey, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	sk, ok := s.keys[id]
+	if !ok || time.Since(sk.loadedAt) > s.ttl {
+		return nil, false
+	}
+	return &sk.signingKey, true
+}
+
+func (s *keyStore) set(id publicKeyId, key *signingKey) {
+	s.Lock()
+	defer s.Unlock()
+	s.keys[id] = storedKey{signingKey: *key, loadedAt: time.Now()}
+}
+
+func (s *keyStore) remove(id publicKeyId) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.keys, id)
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyStore(t *testing.T) {
+	key, _, _ := ed25519.GenerateKey(rand.Reader)
+	s := newKeyStore(time.Minute)
+	id := publicKeyId{source: "test", kid: "kid1"}
+
+	if _, ok := s.get(id); ok {
+		t.Errorf("Expected no key before set\n")
+	}
+	s.set(id, &signingKey{key: key, alg: "EdDSA"})
+	if found, ok := s.get(id); !ok || !key.Equal(found.key) ||
+		found.alg != "EdDSA" {
+		t.Errorf("Expected key after set, Got %v\n", found)
+	}
+	if _, ok := s.get(publicKeyId{source: "azuread", kid: "kid1"}); ok {
+		t.Errorf("Expected no key for other source\n")
+	}
+	s.remove(id)
+	if _, ok := s.get(id); ok {
+		t.Errorf("Expected no key after remove\n")
+	}
+}
+
+// keys are loaded again after ttl to pick up revocations and expiry
+func TestKeyStoreEntriesExpire(t *testing.T) {
+	key, _, _ := ed25519.GenerateKey(rand.Reader)
+	s := newKeyStore(time.Millisecond)
+	id := publicKeyId{source: "test", kid: "kid1"}
+	s.set(id, &signingKey{key: key})
+	time.Sleep(time.Millisecond * 5)
+	if _, ok := s.get(id); ok {
+		t.Errorf("Expected key to expire after ttl\n")
+	}
+}
+
+func TestKeyStoreConcurrentUse(t *testing.T) {
+	key, _, _ := ed25519.GenerateKey(rand.Reader)
+	s := newKeyStore(time.Minute)
+	id := publicKeyId{source: "test", kid: "kid1"}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.set(id, &signingKey{key: key})
+				s.get(id)
+				s.remove(id)
+			}
+		}()
+	}
+	wg.Wait()
+}
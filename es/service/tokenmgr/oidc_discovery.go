@@ -0,0 +1,142 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// discovery document path relative to the issuer url
+	pathOpenIDConfiguration = "/.well-known/openid-configuration"
+)
+
+var (
+	discoveredIssuers = newDiscoveryStore()
+)
+
+// fields of an openid provider configuration used to validate tokens
+type openIDConfiguration struct {
+	Issuer      string   `json:"issuer"`
+	JwksURI     string   `json:"jwks_uri"`
+	SigningAlgs []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// last discovered configuration of each key source. settings are copied
+// per token so discovery results are kept here. safe for concurrent use.
+type discoveryStore struct {
+	sync.RWMutex
+	configs map[string]*openIDConfiguration
+}
+
+func newDiscoveryStore() *discoveryStore {
+	return &discoveryStore{configs: map[string]*openIDConfiguration{}}
+}
+
+func (d *discoveryStore) get(source string) (*openIDConfiguration, bool) {
+	d.RLock()
+	defer d.RUnlock()
+	c, ok := d.configs[source]
+	return c, ok
+}
+
+func (d *discoveryStore) set(source string, c *openIDConfiguration) {
+	d.Lock()
+	defer d.Unlock()
+	d.configs[source] = c
+}
+
+// entries with an issuer url and no keys url resolve them from the
+// openid configuration of the issuer
+func (s *TokenIssuerSettings) usesDiscovery() bool {
+	return s.KeysURL == "" && isHttpURL(s.Issuer)
+}
+
+// configured keys url or, for discovery, the discovered jwks_uri.
+// empty until the first successful discovery.
+func (s *TokenIssuerSettings) keysURL() string {
+	if s.KeysURL != "" || !s.usesDiscovery() {
+		return s.KeysURL
+	}
+	if c, ok := discoveredIssuers.get(s.Name); ok {
+		return c.JwksURI
+	}
+	return ""
+}
+
+// issuer string tokens are checked against
+func (s *TokenIssuerSettings) issuer() string {
+	if s.usesDiscovery() {
+		if c, ok := discoveredIssuers.get(s.Name); ok {
+			return c.Issuer
+		}
+	}
+	return s.Issuer
+}
+
+// discovered issuers only sign tokens with the algorithms they list.
+// any algorithm the key allows is accepted otherwise.
+func (s *TokenIssuerSettings) isSigningAlgSupported(alg string) bool {
+	if !s.usesDiscovery() {
+		return true
+	}
+	c, ok := discoveredIssuers.get(s.Name)
+	if !ok || len(c.SigningAlgs) == 0 {
+		return true
+	}
+	for _, a := range c.SigningAlgs {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch the openid configuration of the issuer of keySource. the last
+// discovered configuration is kept if this fails.
+func discoverIssuer(keySource *TokenIssuerSettings) error {
+	configURL := strings.TrimSuffix(keySource.Issuer, "/") +
+		pathOpenIDConfiguration
+	bytes, err := getFromServer(configURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDiscoveryFetchFailed, err)
+	}
+	var c openIDConfiguration
+	if err = json.Unmarshal(bytes, &c); err != nil {
+		esLogger.Error("Error unmarshalling openid configuration",
+			zap.String("url", configURL),
+			zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrInvalidDiscoveryDocument, err)
+	}
+	// issuer must be the one discovery was done for. trailing slashes
+	// are not significant in configuration.
+	if strings.TrimSuffix(c.Issuer, "/") !=
+		strings.TrimSuffix(keySource.Issuer, "/") {
+		esLogger.Error("Discovered issuer does not match configuration",
+			zap.String("source", keySource.Name),
+			zap.String("issuer", c.Issuer))
+		return ErrDiscoveryIssuerMismatch
+	}
+	if !isHttpURL(c.JwksURI) {
+		return fmt.Errorf("%w: invalid jwks_uri", ErrInvalidDiscoveryDocument)
+	}
+	discoveredIssuers.set(keySource.Name, &c)
+	esLogger.Info("Discovered openid configuration",
+		zap.String("source", keySource.Name),
+		zap.String("jwks_uri", c.JwksURI),
+		zap.Strings("signing_algs", c.SigningAlgs))
+	return nil
+}
+
+func isHttpURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") &&
+		u.Host != ""
+}
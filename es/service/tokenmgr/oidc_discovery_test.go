@@ -0,0 +1,118 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// issuer that serves an openid configuration. config is called with the
+// issuer url to build the served document.
+func newTestIssuer(t *testing.T,
+	config func(issuer string) *openIDConfiguration) *httptest.Server {
+	esLogger = zap.NewNop()
+	gCtx = context.Background()
+	var svr *httptest.Server
+	svr = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != pathOpenIDConfiguration {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(config(svr.URL))
+		}))
+	t.Cleanup(svr.Close)
+	return svr
+}
+
+func TestDiscoverIssuer(t *testing.T) {
+	svr := newTestIssuer(t, func(issuer string) *openIDConfiguration {
+		return &openIDConfiguration{
+			Issuer:      issuer + "/",
+			JwksURI:     issuer + "/keys",
+			SigningAlgs: []string{"RS256", "ES256"},
+		}
+	})
+	s := &TokenIssuerSettings{Name: t.Name(), Issuer: svr.URL}
+	if !s.usesDiscovery() {
+		t.Fatalf("Expected discovery for issuer url without keys url\n")
+	}
+	if s.keysURL() != "" {
+		t.Errorf("Expected no keys url before discovery, Got %s\n", s.keysURL())
+	}
+	if err := discoverIssuer(s); err != nil {
+		t.Fatalf("Expected discovery to succeed, Got %v\n", err)
+	}
+	if s.keysURL() != svr.URL+"/keys" {
+		t.Errorf("Expected discovered keys url, Got %s\n", s.keysURL())
+	}
+	if s.issuer() != svr.URL+"/" {
+		t.Errorf("Expected discovered issuer, Got %s\n", s.issuer())
+	}
+	if !s.isSigningAlgSupported("ES256") || s.isSigningAlgSupported("HS256") {
+		t.Errorf("Expected only discovered signing algorithms\n")
+	}
+}
+
+// a failed discovery keeps the last discovered configuration
+func TestDiscoverIssuerFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(issuer string) *openIDConfiguration
+		err    error
+	}{
+		{"issuer mismatch", func(issuer string) *openIDConfiguration {
+			return &openIDConfiguration{
+				Issuer:  "https://other.issuer.test",
+				JwksURI: issuer + "/keys",
+			}
+		}, ErrDiscoveryIssuerMismatch},
+		{"missing jwks_uri", func(issuer string) *openIDConfiguration {
+			return &openIDConfiguration{Issuer: issuer}
+		}, ErrInvalidDiscoveryDocument},
+	}
+	for _, test := range tests {
+		svr := newTestIssuer(t, test.config)
+		s := &TokenIssuerSettings{Name: t.Name(), Issuer: svr.URL}
+		if err := discoverIssuer(s); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, Got %v\n", test.name, test.err, err)
+		}
+		if s.keysURL() != "" {
+			t.Errorf("%s: expected no keys url, Got %s\n", test.name,
+				s.keysURL())
+		}
+	}
+
+	s := &TokenIssuerSettings{Name: t.Name(), Issuer: "http://127.0.0.1:1"}
+	if err := discoverIssuer(s); !errors.Is(err, ErrDiscoveryFetchFailed) {
+		t.Errorf("Expected %v, Got %v\n", ErrDiscoveryFetchFailed, err)
+	}
+}
+
+// entries with a keys url or without an issuer url are not discovered
+func TestNoDiscoveryWithoutIssuerURL(t *testing.T) {
+	tests := []TokenIssuerSettings{
+		{Name: "device", Issuer: "HP Device Token Service"},
+		{Name: "azuread", Issuer: "https://sts.windows.net",
+			KeysURL: "https://login.microsoftonline.com/common/discovery/keys"},
+	}
+	for _, s := range tests {
+		if s.usesDiscovery() {
+			t.Errorf("%s: expected no discovery\n", s.Name)
+		}
+		if s.keysURL() != s.KeysURL || s.issuer() != s.Issuer {
+			t.Errorf("%s: expected configured keys url and issuer\n", s.Name)
+		}
+		if !s.isSigningAlgSupported("RS256") {
+			t.Errorf("%s: expected any signing algorithm\n", s.Name)
+		}
+	}
+}
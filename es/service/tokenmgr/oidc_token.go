@@ -0,0 +1,115 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// user id claim if the entry does not map one
+	defaultUserIdClaim = "sub"
+)
+
+// user tokens of any openid provider. claim names are configured per
+// entry instead of in code, eg: hd for google workspace tenants.
+type OIDCTokenValidator struct {
+	settings *TokenIssuerSettings
+}
+
+func newOIDCTokenValidator(tokenSettings *TokenIssuerSettings) *OIDCTokenValidator {
+	return &OIDCTokenValidator{settings: tokenSettings}
+}
+
+func (v OIDCTokenValidator) ValidateToken(tokenString string) (*EnrollClaims, error) {
+	claims := jwt.MapClaims{}
+
+	if err := v.settings.parseToken(tokenString, claims); err != nil {
+		return nil, err
+	}
+
+	for name, value := range v.settings.RequiredClaims {
+		if !hasClaimValue(claims, name, value) {
+			esLogger.Error("Token does not have required claim",
+				zap.String("source", v.settings.Name),
+				zap.String("claim", name))
+			return nil, fmt.Errorf("%w: %s", ErrRequiredClaim, name)
+		}
+	}
+
+	return v.mapClaims(claims)
+}
+
+// tenant and user ids are required. tenant id is default_tenant_id if
+// the entry does not map a claim to it.
+func (v OIDCTokenValidator) mapClaims(claims jwt.MapClaims) (*EnrollClaims, error) {
+	mapping := v.settings.Claims
+	enrollClaims := EnrollClaims{TenantId: v.settings.DefaultTenantId}
+	if mapping.TenantId != "" {
+		enrollClaims.TenantId, _ = getClaimString(claims, mapping.TenantId)
+	}
+	userIdClaim := mapping.UserId
+	if userIdClaim == "" {
+		userIdClaim = defaultUserIdClaim
+	}
+	enrollClaims.UserId, _ = getClaimString(claims, userIdClaim)
+	if mapping.DeviceId != "" {
+		enrollClaims.DeviceId, _ = getClaimString(claims, mapping.DeviceId)
+	}
+
+	if enrollClaims.TenantId == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMappedClaim, mapping.TenantId)
+	}
+	if enrollClaims.UserId == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMappedClaim, userIdClaim)
+	}
+	return &enrollClaims, nil
+}
+
+// an empty value only requires the claim to be present. array claims,
+// eg: groups, must contain value.
+func hasClaimValue(claims jwt.MapClaims, name, value string) bool {
+	claim, ok := claims[name]
+	if !ok {
+		return false
+	}
+	if value == "" {
+		return true
+	}
+	if values, ok := claim.([]interface{}); ok {
+		for _, v := range values {
+			if s, ok := claimToString(v); ok && s == value {
+				return true
+			}
+		}
+		return false
+	}
+	s, ok := claimToString(claim)
+	return ok && s == value
+}
+
+func getClaimString(claims jwt.MapClaims, name string) (string, bool) {
+	claim, ok := claims[name]
+	if !ok {
+		return "", false
+	}
+	return claimToString(claim)
+}
+
+// string, bool and number claims compare as strings
+func claimToString(claim interface{}) (string, bool) {
+	switch c := claim.(type) {
+	case string:
+		return c, true
+	case bool:
+		return strconv.FormatBool(c), true
+	case float64:
+		return strconv.FormatFloat(c, 'f', -1, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package tokenmgr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	testOIDCIssuer   = "https://idp.example.test"
+	testOIDCAudience = "api://krypton"
+	testOIDCKid      = "oidc-kid"
+)
+
+// signs tokens with a key loaded in the key store for settings
+func newTestOIDCSigner(t *testing.T, s *TokenIssuerSettings) func(jwt.MapClaims) string {
+	esLogger = zap.NewNop()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := publicKeyId{source: s.Name, kid: testOIDCKid}
+	publicKeys.set(id, &signingKey{key: &key.PublicKey, alg: "ES256"})
+	t.Cleanup(func() { publicKeys.remove(id) })
+	return func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = testOIDCKid
+		str, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+}
+
+func newTestOIDCClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            testOIDCIssuer,
+		"aud":            testOIDCAudience,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"sub":            "user1",
+		"org_id":         "tenant1",
+		"email_verified": true,
+		"groups":         []string{"users", "device-admins"},
+	}
+}
+
+func TestOIDCTokenValidator(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:     t.Name(),
+		Type:     string(TokenTypeOIDC),
+		KeysURL:  testOIDCIssuer + "/keys",
+		Issuer:   testOIDCIssuer,
+		Audience: testOIDCAudience,
+		Claims:   ClaimMapping{TenantId: "org_id", DeviceId: "device_id"},
+		RequiredClaims: map[string]string{
+			"email_verified": "true",
+			"groups":         "device-admins",
+			"org_id":         "",
+		},
+	}
+	sign := newTestOIDCSigner(t, s)
+
+	claims := newTestOIDCClaims()
+	claims["device_id"] = "device1"
+	enrollClaims, err := newOIDCTokenValidator(s).ValidateToken(sign(claims))
+	if err != nil {
+		t.Fatalf("Expected token to validate, Got %v\n", err)
+	}
+	expected := EnrollClaims{
+		TenantId: "tenant1", UserId: "user1", DeviceId: "device1"}
+	if *enrollClaims != expected {
+		t.Errorf("Expected %+v, Got %+v\n", expected, *enrollClaims)
+	}
+}
+
+func TestOIDCTokenValidatorDefaultTenant(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:            t.Name(),
+		KeysURL:         testOIDCIssuer + "/keys",
+		Issuer:          testOIDCIssuer,
+		DefaultTenantId: "default-tenant",
+		Claims:          ClaimMapping{UserId: "email"},
+	}
+	sign := newTestOIDCSigner(t, s)
+
+	claims := newTestOIDCClaims()
+	claims["email"] = "user1@example.test"
+	enrollClaims, err := newOIDCTokenValidator(s).ValidateToken(sign(claims))
+	if err != nil {
+		t.Fatalf("Expected token to validate, Got %v\n", err)
+	}
+	if enrollClaims.TenantId != "default-tenant" ||
+		enrollClaims.UserId != "user1@example.test" {
+		t.Errorf("Unexpected enroll claims: %+v\n", *enrollClaims)
+	}
+}
+
+func TestOIDCTokenValidatorFails(t *testing.T) {
+	s := &TokenIssuerSettings{
+		Name:     t.Name(),
+		KeysURL:  testOIDCIssuer + "/keys",
+		Issuer:   testOIDCIssuer,
+		Audience: testOIDCAudience,
+		Claims:   ClaimMapping{TenantId: "org_id"},
+		RequiredClaims: map[string]string{
+			"email_verified": "true",
+			"groups":         "device-admins",
+		},
+	}
+	sign := newTestOIDCSigner(t, s)
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		err    error
+	}{
+		{"audience", func(c jwt.MapClaims) { c["aud"] = "other" },
+			ErrInvalidAudienceClaim},
+		{"issuer", func(c jwt.MapClaims) { c["iss"] = "https://other.test" },
+			ErrInvalidIssuerClaim},
+		{"required claim value", func(c jwt.MapClaims) {
+			c["email_verified"] = false
+		}, ErrRequiredClaim},
+		{"required claim missing", func(c jwt.MapClaims) {
+			delete(c, "email_verified")
+		}, ErrRequiredClaim},
+		{"required array claim", func(c jwt.MapClaims) {
+			c["groups"] = []string{"users"}
+		}, ErrRequiredClaim},
+		{"tenant claim missing", func(c jwt.MapClaims) {
+			delete(c, "org_id")
+		}, ErrMappedClaim},
+		{"user claim missing", func(c jwt.MapClaims) {
+			delete(c, "sub")
+		}, ErrMappedClaim},
+	}
+	for _, test := range tests {
+		claims := newTestOIDCClaims()
+		test.modify(claims)
+		_, err := newOIDCTokenValidator(s).ValidateToken(sign(claims))
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, Got %v\n", test.name, test.err, err)
+		}
+	}
+}
+
+// oidc entries must map a tenant claim or set a default tenant
+func TestIsValidTokenSettings(t *testing.T) {
+	esLogger = zap.NewNop()
+	tests := []struct {
+		settings TokenIssuerSettings
+		expected bool
+	}{
+		{TokenIssuerSettings{Type: string(TokenTypeOIDC)}, false},
+		{TokenIssuerSettings{Type: string(TokenTypeOIDC),
+			Claims: ClaimMapping{TenantId: "tid"}}, true},
+		{TokenIssuerSettings{Type: string(TokenTypeOIDC),
+			DefaultTenantId: "tenant1"}, true},
+		{TokenIssuerSettings{Type: string(TokenTypeAzureAD)}, true},
+	}
+	for _, test := range tests {
+		if found := isValidTokenSettings(&test.settings); found != test.expected {
+			t.Errorf("%+v: expected %v, Got %v\n", test.settings,
+				test.expected, found)
+		}
+	}
+}
+
+func TestIsUserTokenForOIDC(t *testing.T) {
+	saved := tokenConfig
+	t.Cleanup(func() { tokenConfig = saved })
+	tokenConfig = Config{TokenTypes: map[TokenType]TokenIssuerSettings{
+		"okta": {Name: "okta", Type: string(TokenTypeOIDC)},
+	}}
+	if !IsUserToken("okta") {
+		t.Errorf("Expected oidc tokens to be user tokens\n")
+	}
+}
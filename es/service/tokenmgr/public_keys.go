@@ -5,66 +5,100 @@
 package tokenmgr
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 
+	"github.com/HPInc/krypton-es/es/service/cache"
 	"github.com/HPInc/krypton-es/es/service/db"
 	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 )
 
 var (
-	publicKeys = make(map[string]interface{})
+	publicKeys = newKeyStore(keyStoreTTL)
 )
 
-// get saved public key for kid
+// get saved public key for kid from key source
 // first check in cache, return if found
 // if not found in cache, check in db
 // - if found in db, add to cache, return
-// - if not found in db, return error
-func getPublicKey(kid string) (interface{}, error) {
-	var pubkey interface{}
+// - if not found in db, return ErrKIDNotFound
+func getPublicKey(source, kid string) (*signingKey, error) {
+	var pubkey *signingKey
 	var ok bool
 	var err error
-	if pubkey, ok = publicKeys[kid]; !ok {
-		if pubkey, err = makePublicKeyWithDbData(kid); err != nil {
+	id := publicKeyId{source: source, kid: kid}
+	if pubkey, ok = publicKeys.get(id); !ok {
+		if pubkey, err = makePublicKeyWithDbData(source, kid); err != nil {
 			esLogger.Error("Could not find public key",
+				zap.String("source", source),
 				zap.String("kid", kid),
 				zap.Error(err))
+			if db.IsDbErrorNoRows(err) {
+				return nil, fmt.Errorf("%w: %s", ErrKIDNotFound, kid)
+			}
 			return nil, fmt.Errorf(
 				"No public key to validate kid: %s", kid)
 		}
-		publicKeys[kid] = pubkey
+		publicKeys.set(id, pubkey)
 	}
 	return pubkey, nil
 }
 
+// revoke a compromised key of source. replicas drop it from their key
+// store through the cache or, if cache is disabled, within keyStoreTTL.
+func RevokeKey(source, kid string) error {
+	if err := db.RevokeKey(source, kid); err != nil {
+		return err
+	}
+	publicKeys.remove(publicKeyId{source: source, kid: kid})
+	cache.PublishKeyRevoked(source, kid)
+	esLogger.Info("Revoked public key",
+		zap.String("source", source),
+		zap.String("kid", kid))
+	return nil
+}
+
+// drop a key revoked on any replica from the key store
+func onKeyRevoked(source, kid string) {
+	publicKeys.remove(publicKeyId{source: source, kid: kid})
+}
+
 // set public key in db
 // do not add to cache at this time as there is no
 // guarantee for use. better to add to cache on first use
-func setPublicKey(kid, keyType, keyString string) error {
-	return db.AddKey(kid, keyType, keyString)
+func setPublicKey(source, kid, alg, keyString string) error {
+	return db.AddKey(source, kid, alg, keyString)
 }
 
 // helper method
-// look up in db by kid, return parsed public key
-func makePublicKeyWithDbData(kid string) (*rsa.PublicKey, error) {
-	keystring, err := db.GetPublicKey(kid)
+// look up in db by source and kid, return parsed public key
+func makePublicKeyWithDbData(source, kid string) (*signingKey, error) {
+	keystring, alg, err := db.GetPublicKey(source, kid)
 	if err != nil {
 		return nil, err
 	}
-	return makePublicKey(keystring)
+	pubkey, err := makePublicKey(keystring)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{key: pubkey, alg: alg}, nil
 }
 
 // helper method
-// make public key from string data
-func makePublicKey(keystring string) (*rsa.PublicKey, error) {
+// make public key from string data. rsa, ecdsa and ed25519 keys
+// are supported.
+func makePublicKey(keystring string) (crypto.PublicKey, error) {
 	// parse pem bytes to make public key
 	block, _ := pem.Decode([]byte(keystring))
-	if block == nil || block.Type != "RSA PUBLIC KEY" {
+	if block == nil || (block.Type != pemTypeRsaPublicKey &&
+		block.Type != pemTypePublicKey) {
 		esLogger.Error("Failed to decode PEM block")
 		if block != nil {
 			esLogger.Error("Block type not supported.",
@@ -78,15 +112,74 @@ func makePublicKey(keystring string) (*rsa.PublicKey, error) {
 		esLogger.Error("Error parsing public key", zap.Error(err))
 		return nil, err
 	}
-	return pub.(*rsa.PublicKey), nil
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return pub, nil
+	}
+	esLogger.Error("Public key type not supported.",
+		zap.String("type", fmt.Sprintf("%T", pub)))
+	return nil, ErrUnsupportedKeyType
 }
 
-// get the kid from jwt and use it to fetch public key
-// from in memory cache
-func getPublicKeyForJwt(token *jwt.Token) (interface{}, error) {
+// get the kid from jwt and use it to fetch public key of the key source
+// of token settings s from in memory cache. the key must be of the type
+// the signing algorithm in the token header expects, and if the jwk of
+// the key has an alg, the token must be signed with it.
+// an unknown kid may be of a key published since the last refresh. keys
+// are refreshed on demand and the lookup retried once.
+func (s *TokenIssuerSettings) getPublicKeyForJwt(token *jwt.Token) (interface{}, error) {
 	kid, ok := token.Header["kid"].(string)
 	if !ok {
 		return nil, ErrInvalidTokenHeaderKid
 	}
-	return getPublicKey(kid)
+	if !s.isSigningAlgAllowed(token.Method.Alg()) {
+		esLogger.Error("Token signing algorithm not allowed",
+			zap.String("source", s.Name),
+			zap.String("alg", token.Method.Alg()))
+		return nil, ErrSigningAlgNotAllowed
+	}
+	pubkey, err := getPublicKey(s.Name, kid)
+	if errors.Is(err, ErrKIDNotFound) &&
+		(s.KeysURL != "" || s.usesDiscovery()) {
+		if refreshErr := keyRefresher.refreshKeys(s); refreshErr == nil {
+			pubkey, err = getPublicKey(s.Name, kid)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !pubkey.allowsAlg(token.Method.Alg()) ||
+		!isKeyForSigningMethod(token.Method, pubkey.key) {
+		esLogger.Error("Token signing algorithm does not match key",
+			zap.String("kid", kid),
+			zap.String("alg", token.Method.Alg()),
+			zap.String("key_alg", pubkey.alg))
+		return nil, ErrInvalidTokenHeaderSigningAlg
+	}
+	return pubkey.key, nil
+}
+
+// keys stored with a kty and not a signing alg are not pinned to an alg
+func (k *signingKey) allowsAlg(alg string) bool {
+	if jwt.GetSigningMethod(k.alg) == nil {
+		return true
+	}
+	return k.alg == alg
+}
+
+// ecdsa keys must also be on the curve of the algorithm.
+// ES256 is P-256, ES384 is P-384 and ES512 is P-521.
+func isKeyForSigningMethod(method jwt.SigningMethod, pubkey crypto.PublicKey) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := pubkey.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		key, ok := pubkey.(*ecdsa.PublicKey)
+		return ok && key.Curve.Params().BitSize == m.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := pubkey.(ed25519.PublicKey)
+		return ok
+	}
+	return false
 }
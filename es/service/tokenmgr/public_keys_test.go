@@ -4,8 +4,16 @@
 package tokenmgr
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
 	"testing"
 
+	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
 )
 
@@ -53,3 +61,83 @@ func TestMakePublicKeyWithValidString(t *testing.T) {
 		t.Errorf("Expected valid public key, Got nil\n")
 	}
 }
+
+// a token can only be verified with a key of the type its alg expects
+func TestIsKeyForSigningMethod(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		method   jwt.SigningMethod
+		key      crypto.PublicKey
+		expected bool
+	}{
+		{jwt.SigningMethodRS256, &rsaKey.PublicKey, true},
+		{jwt.SigningMethodPS256, &rsaKey.PublicKey, true},
+		{jwt.SigningMethodRS256, &p256Key.PublicKey, false},
+		{jwt.SigningMethodES256, &p256Key.PublicKey, true},
+		{jwt.SigningMethodES384, &p384Key.PublicKey, true},
+		{jwt.SigningMethodES256, &p384Key.PublicKey, false},
+		{jwt.SigningMethodES256, &rsaKey.PublicKey, false},
+		{jwt.SigningMethodEdDSA, edKey, true},
+		{jwt.SigningMethodEdDSA, &p256Key.PublicKey, false},
+		{jwt.SigningMethodHS256, &rsaKey.PublicKey, false},
+	}
+	for _, test := range tests {
+		if found := isKeyForSigningMethod(test.method, test.key); found != test.expected {
+			t.Errorf("%s with %T: expected %v, Got %v\n", test.method.Alg(),
+				test.key, test.expected, found)
+		}
+	}
+}
+
+// keys are pinned to the alg of their jwk. keys stored with a kty are not.
+func TestSigningKeyAllowsAlg(t *testing.T) {
+	tests := []struct {
+		keyAlg   string
+		alg      string
+		expected bool
+	}{
+		{"PS256", "PS256", true},
+		{"PS256", "RS256", false},
+		{"ES256", "ES256", true},
+		{ktyRSA, "RS256", true},
+		{ktyRSA, "PS512", true},
+		{"", "EdDSA", true},
+	}
+	for _, test := range tests {
+		k := &signingKey{alg: test.keyAlg}
+		if found := k.allowsAlg(test.alg); found != test.expected {
+			t.Errorf("%s key with %s: expected %v, Got %v\n", test.keyAlg,
+				test.alg, test.expected, found)
+		}
+	}
+}
+
+// a token signed with another alg than the jwk of its key is rejected
+func TestGetPublicKeyForJwtPinsKeyAlg(t *testing.T) {
+	esLogger = zap.NewNop()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	s := &TokenIssuerSettings{Name: t.Name()}
+	id := publicKeyId{source: s.Name, kid: "kid1"}
+	publicKeys.set(id, &signingKey{key: &rsaKey.PublicKey, alg: "PS256"})
+	t.Cleanup(func() { publicKeys.remove(id) })
+
+	tests := []struct {
+		method jwt.SigningMethod
+		err    error
+	}{
+		{jwt.SigningMethodPS256, nil},
+		{jwt.SigningMethodRS256, ErrInvalidTokenHeaderSigningAlg},
+	}
+	for _, test := range tests {
+		token := jwt.New(test.method)
+		token.Header["kid"] = "kid1"
+		if _, err := s.getPublicKeyForJwt(token); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, Got %v\n", test.method.Alg(), test.err,
+				err)
+		}
+	}
+}
@@ -10,9 +10,7 @@ package tokenmgr
 
 import (
 	"crypto/rsa"
-	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
 	"math/big"
 	"strings"
@@ -54,24 +52,6 @@ func (j *jsonWebKey) RSA() (publicKey *rsa.PublicKey, err error) {
 	return publicKey, nil
 }
 
-func (j *jsonWebKey) Pem() (string, error) {
-	publicKey, err := j.RSA()
-	if err != nil {
-		return "", err
-	}
-	pubkey_bytes, err := x509.MarshalPKIXPublicKey(publicKey)
-	if err != nil {
-		return "", err
-	}
-	pubkey_pem := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
-			Bytes: pubkey_bytes,
-		},
-	)
-	return string(pubkey_pem), nil
-}
-
 // base64urlTrailingPadding removes trailing padding before decoding a string from base64url. Some non-RFC compliant
 // JWKS contain padding at the end values for base64url encoded public keys.
 //
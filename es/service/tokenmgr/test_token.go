@@ -3,11 +3,7 @@
 
 package tokenmgr
 
-import (
-	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
-)
+import "github.com/golang-jwt/jwt/v4"
 
 type TestTokenValidator struct {
 	settings *TokenIssuerSettings
@@ -27,25 +23,8 @@ func newTestTokenValidator(tokenSettings *TokenIssuerSettings) *TestTokenValidat
 func (v TestTokenValidator) ValidateToken(tokenString string) (*EnrollClaims, error) {
 	var claims TestTokenClaims
 
-	token, err := jwt.ParseWithClaims(tokenString, &claims,
-		getPublicKeyForJwt)
-	if err != nil {
+	if err := v.settings.parseToken(tokenString, &claims); err != nil {
 		return nil, err
-	} else if !token.Valid {
-		return nil, ErrInvalidToken
-	}
-
-	if token.Header["alg"] == nil {
-		return nil, ErrInvalidTokenHeaderSigningAlg
-	}
-
-	if v.settings.Audience != "" &&
-		!token.Claims.(*TestTokenClaims).VerifyAudience(v.settings.Audience, true) {
-		return nil, ErrInvalidAudienceClaim
-	}
-
-	if !strings.HasPrefix(claims.Issuer, v.settings.Issuer) {
-		return nil, ErrInvalidIssuerClaim
 	}
 
 	return &EnrollClaims{TenantId: claims.TenantId}, nil
@@ -15,14 +15,52 @@ type TokenType string
 
 // token attributes
 type TokenIssuerSettings struct {
+	// name of the entry in token_types. keys from the keys url are
+	// stored for and only verify tokens of this entry.
+	Name            string `yaml:"-"`
 	Type            string `yaml:"type"`
 	KeysURL         string `yaml:"keys"`
 	Audience        string `yaml:"audience"`
 	Issuer          string `yaml:"issuer"`
 	RefreshInterval int    `yaml:"refresh_interval"`
-	DefaultTenantId string `yaml:"default_tenant_id"`
+	// seconds a key no longer published by keys url stays valid.
+	// defaults to defaultKeyGracePeriod.
+	KeyGracePeriod int `yaml:"key_grace_period"`
+	// minimum seconds between refreshes triggered by tokens with a kid
+	// not yet stored. defaults to defaultMinRefreshInterval.
+	MinRefreshInterval int    `yaml:"min_refresh_interval"`
+	DefaultTenantId    string `yaml:"default_tenant_id"`
 	// app token auth details
 	AllowedAppIds []string `yaml:"allowed_app_ids"`
+	// tenant ids each app id may manage devices of. app tokens are not
+	// issued for a tenant, so apps not listed cannot use tenant apis.
+	AppTenants map[string][]string `yaml:"app_tenants"`
+	// exact issuers allowed. issuer is a prefix if not set, which is
+	// deprecated.
+	Issuers []string `yaml:"issuers"`
+	// tokens must be for one of these audiences. overrides audience.
+	Audiences []string `yaml:"audiences"`
+	// token signing algorithms allowed, eg: RS256. any algorithm the key
+	// allows is accepted if not set.
+	AllowedAlgs []string `yaml:"allowed_algs"`
+	// seconds of clock skew allowed for exp, nbf and iat
+	Leeway int `yaml:"leeway"`
+	// maximum seconds since iat. tokens need iat if set.
+	MaxTokenAge int `yaml:"max_token_age"`
+	// oidc token claims mapped to enroll claims
+	Claims ClaimMapping `yaml:"claims"`
+	// claims oidc tokens must have with these values. an empty value
+	// only requires the claim.
+	RequiredClaims map[string]string `yaml:"required_claims"`
+}
+
+// names of the token claims that hold enroll claims
+type ClaimMapping struct {
+	// tenant id is default_tenant_id if not set
+	TenantId string `yaml:"tenant_id"`
+	// user id is sub if not set
+	UserId   string `yaml:"user_id"`
+	DeviceId string `yaml:"device_id"`
 }
 
 type Config struct {
@@ -52,8 +90,39 @@ func loadTokenConfiguration(tokenConfigFile string) bool {
 		return false
 	}
 
+	for k, v := range tokenConfig.TokenTypes {
+		v.Name = string(k)
+		tokenConfig.TokenTypes[k] = v
+		if !isValidTokenSettings(&v) {
+			return false
+		}
+	}
+
 	esLogger.Info("Parsed configuration from the token configuration file!",
 		zap.String("Configuration file:", tokenConfigFile),
 	)
 	return true
 }
+
+// oidc tokens have no fixed tenant claim. entries must map one or set
+// a default tenant. entries that match issuer as a prefix or do not
+// check the audience are logged.
+func isValidTokenSettings(s *TokenIssuerSettings) bool {
+	if TokenType(s.Type) == TokenTypeOIDC &&
+		s.Claims.TenantId == "" && s.DefaultTenantId == "" {
+		esLogger.Error("Token type needs claims.tenant_id or default_tenant_id",
+			zap.String("name", s.Name),
+			zap.String("type", s.Type))
+		return false
+	}
+	if len(s.Issuers) == 0 && !s.usesDiscovery() {
+		esLogger.Warn("Issuer prefix match is deprecated, set issuers",
+			zap.String("name", s.Name),
+			zap.String("issuer", s.Issuer))
+	}
+	if len(s.Audiences) == 0 && s.Audience == "" {
+		esLogger.Warn("No audiences set, token audience is not checked",
+			zap.String("name", s.Name))
+	}
+	return true
+}
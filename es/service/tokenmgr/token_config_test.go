@@ -14,3 +14,86 @@ func TestLoadConfiguration(t *testing.T) {
 	defer esLogger.Sync()
 	loadTokenConfiguration("../config/token_config.yaml")
 }
+
+func TestIsUserToken(t *testing.T) {
+	esLogger, _ = zap.NewProduction(zap.AddCaller())
+	defer esLogger.Sync()
+	if !loadTokenConfiguration("../config/token_config.yaml") {
+		t.Fatal("failed to load token configuration")
+	}
+	tests := map[string]bool{
+		"azuread":    true,
+		"AzureAD":    true,
+		"device":     false,
+		"enrollment": false,
+		"app":        false,
+		"unknown":    false,
+	}
+	for tokenType, expected := range tests {
+		if IsUserToken(tokenType) != expected {
+			t.Errorf("IsUserToken(%s): expected %v", tokenType, expected)
+		}
+	}
+}
+
+// keys are scoped to the name of the entry they are fetched for
+func TestLoadConfigurationSetsEntryName(t *testing.T) {
+	esLogger, _ = zap.NewProduction(zap.AddCaller())
+	defer esLogger.Sync()
+	if !loadTokenConfiguration("../config/token_config.yaml") {
+		t.Fatal("failed to load token configuration")
+	}
+	for k, v := range tokenConfig.TokenTypes {
+		if v.Name != string(k) {
+			t.Errorf("Expected name %s, Got %s", k, v.Name)
+		}
+	}
+}
+
+// shipped configurations do not rely on issuer prefixes or skip the
+// audience check
+func TestShippedConfigsSetIssuersAndAudiences(t *testing.T) {
+	esLogger = zap.NewNop()
+	for _, file := range []string{"../config/token_config.yaml",
+		"../config/token_config_local.yaml"} {
+		tokenConfig = Config{}
+		if !loadTokenConfiguration(file) {
+			t.Fatalf("%s: failed to load token configuration", file)
+		}
+		for k, v := range tokenConfig.TokenTypes {
+			if len(v.Issuers) == 0 || len(v.Audiences) == 0 {
+				t.Errorf("%s: expected issuers and audiences for %s", file, k)
+			}
+		}
+	}
+}
+
+func TestIsAppAllowedForTenant(t *testing.T) {
+	esLogger = zap.NewNop()
+	tokenConfig = Config{TokenTypes: map[TokenType]TokenIssuerSettings{
+		"app": {Type: string(TokenTypeApp),
+			AppTenants: map[string][]string{"app1": {"tenant1"}}},
+		"test": {Type: string(TokenTypeTest),
+			AppTenants: map[string][]string{"app1": {"tenant1"}}},
+	}}
+	t.Cleanup(func() { tokenConfig = Config{} })
+	tests := []struct {
+		tokenType string
+		appId     string
+		tenantId  string
+		expected  bool
+	}{
+		{"app", "app1", "tenant1", true},
+		{"app", "app1", "tenant2", false},
+		{"app", "app2", "tenant1", false},
+		{"test", "app1", "tenant1", false},
+		{"unknown", "app1", "tenant1", false},
+	}
+	for _, test := range tests {
+		if found := IsAppAllowedForTenant(test.tokenType, test.appId,
+			test.tenantId); found != test.expected {
+			t.Errorf("%s %s %s: expected %v, Got %v", test.tokenType,
+				test.appId, test.tenantId, test.expected, found)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package tokenmgr
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -14,6 +15,7 @@ const (
 	TokenTypeEnrollment TokenType = "enrollment"
 	TokenTypeTest       TokenType = "test"
 	TokenTypeApp        TokenType = "app"
+	TokenTypeOIDC       TokenType = "oidc"
 )
 
 type EnrollClaims struct {
@@ -54,6 +56,9 @@ func ValidateToken(tokenType string, tokenString string) (*EnrollClaims, error)
 	case TokenTypeApp:
 		validator = newAppTokenValidator(&tokenSettings)
 
+	case TokenTypeOIDC:
+		validator = newOIDCTokenValidator(&tokenSettings)
+
 	default:
 		return nil, fmt.Errorf("invalid token type: %s",
 			tokenSettings.Type)
@@ -65,3 +70,27 @@ func ValidateToken(tokenType string, tokenString string) (*EnrollClaims, error)
 func IsAppToken(tokenType string) bool {
 	return tokenType == string(TokenTypeApp)
 }
+
+// app tokens are not issued for a tenant. an app may only manage
+// devices of the tenants listed for it in app_tenants.
+func IsAppAllowedForTenant(tokenType, appId, tenantId string) bool {
+	tokenSettings, ok := tokenConfig.TokenTypes[TokenType(strings.ToLower(tokenType))]
+	if !ok || TokenType(tokenSettings.Type) != TokenTypeApp {
+		return false
+	}
+	return slices.Contains(tokenSettings.AppTenants[appId], tenantId)
+}
+
+// user tokens are issued to tenant users (admins) as opposed to
+// devices, enrollment tokens or apps. tenant admin apis require these.
+func IsUserToken(tokenType string) bool {
+	tokenSettings, ok := tokenConfig.TokenTypes[TokenType(strings.ToLower(tokenType))]
+	if !ok {
+		return false
+	}
+	switch TokenType(tokenSettings.Type) {
+	case TokenTypeAzureAD, TokenTypeTest, TokenTypeOIDC:
+		return true
+	}
+	return false
+}
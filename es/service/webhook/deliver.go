@@ -0,0 +1,197 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/metrics"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderDeliveryId = "X-HP-Webhook-Id"
+	HeaderEventType  = "X-HP-Webhook-Event"
+	HeaderTimestamp  = "X-HP-Webhook-Timestamp"
+	HeaderSignature  = "X-HP-Webhook-Signature"
+
+	// signature header value is prefixed with the algorithm
+	signaturePrefix = "sha256="
+
+	contentTypeJson = "application/json"
+	userAgent       = "HP-Enroll-Service-Webhook"
+
+	// response body of receivers is drained, never stored
+	maxResponseBodySize = 1024
+
+	deliveryResultDelivered = "delivered"
+	deliveryResultRetry     = "retry"
+	deliveryResultFailed    = "failed"
+)
+
+// hex hmac-sha256 of "<timestamp>.<body>" keyed with the webhook secret.
+// receivers recompute it over the raw body and compare with
+// X-HP-Webhook-Signature to verify a delivery.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// poll for due deliveries until shutdown
+func dispatch() {
+	defer dispatcherDone.Done()
+	ticker := time.NewTicker(
+		time.Duration(webhookSettings.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		deliverDue()
+		select {
+		case <-ticker.C:
+		case <-wakeup:
+		case <-gCtx.Done():
+			esLogger.Info("Shutting down webhook dispatcher.")
+			return
+		}
+	}
+}
+
+// claim and deliver batches until no more are due
+func deliverDue() {
+	for gCtx.Err() == nil {
+		deliveries, err := db.ClaimWebhookDeliveries(
+			webhookSettings.BatchSize, getClaimLease())
+		if err != nil {
+			esLogger.Error("Failed to claim webhook deliveries",
+				zap.Error(err))
+			return
+		}
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			wg.Add(1)
+			go func(d *structs.WebhookDelivery) {
+				defer wg.Done()
+				deliver(d)
+			}(d)
+		}
+		wg.Wait()
+		if len(deliveries) < webhookSettings.BatchSize {
+			return
+		}
+	}
+}
+
+// an attempt outlasting this is assumed lost and is retried
+func getClaimLease() time.Duration {
+	return 2 * time.Duration(webhookSettings.TimeoutSeconds) * time.Second
+}
+
+// attempt delivery and record the result
+func deliver(d *structs.WebhookDelivery) {
+	var retryIn time.Duration
+	code, err := post(d)
+	d.ResponseCode = code
+	if err == nil {
+		d.Status = db.WebhookDeliveryDelivered
+		d.LastError = ""
+		metrics.ReportWebhookDelivery(deliveryResultDelivered)
+	} else {
+		d.LastError = getLastError(err).Error()
+		if d.Attempts >= webhookSettings.MaxAttempts {
+			d.Status = db.WebhookDeliveryFailed
+			metrics.ReportWebhookDelivery(deliveryResultFailed)
+		} else {
+			d.Status = db.WebhookDeliveryPending
+			retryIn = getBackoff(d.Attempts)
+			metrics.ReportWebhookDelivery(deliveryResultRetry)
+		}
+		esLogger.Error("Webhook delivery attempt failed",
+			zap.String("delivery_id", d.Id.String()),
+			zap.String("webhook_id", d.WebhookId.String()),
+			zap.String("event_type", d.EventType),
+			zap.Int("attempt", d.Attempts),
+			zap.Duration("retry_in", retryIn),
+			zap.Error(err))
+	}
+	if err = db.UpdateWebhookDelivery(d, retryIn); err != nil {
+		esLogger.Error("Failed to record webhook delivery result",
+			zap.String("delivery_id", d.Id.String()),
+			zap.Error(err))
+	}
+}
+
+// post signed event. any 2xx response is a successful delivery.
+func post(d *structs.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	timestamp := time.Now().Unix()
+
+	secret, err := openSecret(d.TenantId, d.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(gCtx, http.MethodPost, d.Url,
+		strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentTypeJson)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryId, d.Id.String())
+	req.Header.Set(HeaderEventType, d.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature,
+		signaturePrefix+Sign(secret, timestamp, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w: %d",
+			ErrUnexpectedResponse, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// last error is returned to tenants. keep it to a fixed description
+// so receiver responses and internal network details are not exposed.
+func getLastError(err error) error {
+	for _, e := range []error{ErrUnexpectedResponse, ErrAddressNotAllowed,
+		ErrSecretKeyNotSet, ErrInvalidSealedSecret} {
+		if errors.Is(err, e) {
+			return e
+		}
+	}
+	return ErrRequestFailed
+}
+
+// delay before next attempt. doubles from initial backoff after
+// each failed attempt and is capped at max backoff.
+func getBackoff(attempts int) time.Duration {
+	backoff := time.Duration(webhookSettings.InitialBackoffSeconds) * time.Second
+	maxBackoff := time.Duration(webhookSettings.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
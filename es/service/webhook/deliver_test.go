@@ -0,0 +1,197 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/config"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	esLogger = zap.NewNop()
+	gCtx = context.Background()
+	webhookSettings = &config.Webhook{
+		InitialBackoffSeconds: 30,
+		MaxBackoffSeconds:     3600,
+	}
+	key := make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	if err := initSecretKey(base64.StdEncoding.EncodeToString(key)); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestGetBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  60 * time.Second,
+		3:  120 * time.Second,
+		8:  3600 * time.Second,
+		64: 3600 * time.Second,
+	}
+	for attempts, expected := range tests {
+		if backoff := getBackoff(attempts); backoff != expected {
+			t.Errorf("attempts=%d: expected %v, got %v",
+				attempts, expected, backoff)
+		}
+	}
+}
+
+func TestSignDependsOnTimestampAndSecret(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	s := Sign("secret", 1, body)
+	if s == Sign("secret", 2, body) || s == Sign("other", 1, body) {
+		t.Errorf("Expected signature to change with timestamp and secret")
+	}
+}
+
+// receiver verifies signature over timestamp and raw body
+func TestPostSignsDelivery(t *testing.T) {
+	d := newTestDelivery()
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			expected := signaturePrefix + Sign(testSecret, ts, body)
+			if !hmac.Equal([]byte(expected),
+				[]byte(r.Header.Get(HeaderSignature))) ||
+				r.Header.Get(HeaderEventType) != d.EventType ||
+				r.Header.Get(HeaderDeliveryId) != d.Id.String() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer srv.Close()
+	httpClient = srv.Client()
+	d.Url = srv.URL
+
+	code, err := post(d)
+	if err != nil || code != http.StatusNoContent {
+		t.Errorf("Expected delivery, got code %d: %v", code, err)
+	}
+}
+
+func TestPostFailsOnErrorResponse(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+	defer srv.Close()
+	httpClient = srv.Client()
+	d := newTestDelivery()
+	d.Url = srv.URL
+
+	code, err := post(d)
+	if err == nil || code != http.StatusServiceUnavailable {
+		t.Errorf("Expected failed delivery, got code %d: %v", code, err)
+	}
+	// receiver response is not recorded
+	if lastError := getLastError(err); lastError != ErrUnexpectedResponse {
+		t.Errorf("Expected %v, got %v", ErrUnexpectedResponse, lastError)
+	}
+}
+
+// test servers listen on loopback which webhooks must not reach
+func TestPostToLoopbackFails(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer srv.Close()
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Control: dialControl}).DialContext
+	httpClient = &http.Client{Transport: transport}
+	d := newTestDelivery()
+	d.Url = srv.URL
+
+	_, err := post(d)
+	if !errors.Is(err, ErrAddressNotAllowed) {
+		t.Errorf("Expected %v, got %v", ErrAddressNotAllowed, err)
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":              true,
+		"2606:4700:4700::1111": true,
+		"127.0.0.1":            false,
+		"10.0.0.1":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"255.255.255.255":      false,
+		"224.0.0.1":            false,
+	}
+	for ip, expected := range tests {
+		if IsPublicAddress(netip.MustParseAddr(ip)) != expected {
+			t.Errorf("%s: expected public=%t", ip, expected)
+		}
+	}
+}
+
+func TestSealedSecretIsBoundToTenant(t *testing.T) {
+	sealed, err := SealSecret("tenant", testSecret)
+	if err != nil {
+		t.Fatalf("Failed to seal secret: %v", err)
+	}
+	if strings.Contains(sealed, testSecret) {
+		t.Errorf("Expected sealed secret to not contain the secret")
+	}
+	if secret, err := openSecret("tenant", sealed); err != nil ||
+		secret != testSecret {
+		t.Errorf("Expected secret to open, got %q: %v", secret, err)
+	}
+	if _, err = openSecret("other", sealed); err != ErrInvalidSealedSecret {
+		t.Errorf("Expected %v, got %v", ErrInvalidSealedSecret, err)
+	}
+}
+
+const testSecret = "test webhook secret"
+
+func newTestDelivery() *structs.WebhookDelivery {
+	sealed, err := SealSecret("tenant", testSecret)
+	if err != nil {
+		panic(err)
+	}
+	return &structs.WebhookDelivery{
+		Id:        uuid.New(),
+		TenantId:  "tenant",
+		EventType: EventEnrollCompleted,
+		Payload:   `{"id":"` + uuid.NewString() + `"}`,
+		Secret:    sealed,
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/db"
+	"github.com/HPInc/krypton-es/es/service/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	EventEnrollCompleted   = "enroll.completed"
+	EventEnrollFailed      = "enroll.failed"
+	EventUnenrollCompleted = "unenroll.completed"
+	EventUnenrollFailed    = "unenroll.failed"
+)
+
+// json body posted to webhooks. id is the same across retries
+// so receivers can drop duplicate deliveries.
+type Event struct {
+	Id        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_time"`
+	Data      EventData `json:"data"`
+}
+
+type EventData struct {
+	// enroll or unenroll id
+	Id        uuid.UUID `json:"id"`
+	TenantId  string    `json:"tenant_id"`
+	DeviceId  string    `json:"device_id,omitempty"`
+	UserId    string    `json:"user_id,omitempty"`
+	ErrorCode int       `json:"error_code,omitempty"`
+	ErrorText string    `json:"error_text,omitempty"`
+}
+
+// enroll id was updated with a device certificate
+func EnrollCompleted(id uuid.UUID) {
+	if !isEnabled {
+		return
+	}
+	entry, err := db.GetEnrollStatus(id)
+	if err != nil {
+		logLookupError(EventEnrollCompleted, id, err)
+		return
+	}
+	data := EventData{
+		Id:       id,
+		TenantId: entry.TenantId,
+		UserId:   entry.UserId,
+	}
+	if entry.DeviceId != uuid.Nil {
+		data.DeviceId = entry.DeviceId.String()
+	}
+	queueEvent(EventEnrollCompleted, &data)
+}
+
+// unenroll id was completed by worker
+func UnenrollCompleted(id uuid.UUID) {
+	if !isEnabled {
+		return
+	}
+	entry, err := db.GetUnenrollStatus(id)
+	if err != nil {
+		logLookupError(EventUnenrollCompleted, id, err)
+		return
+	}
+	queueEvent(EventUnenrollCompleted, &EventData{
+		Id:       id,
+		TenantId: entry.TenantId,
+		DeviceId: entry.DeviceId,
+	})
+}
+
+// enroll was moved to enroll_error
+func EnrollFailed(ee *structs.EnrollError) {
+	if !isEnabled {
+		return
+	}
+	id, err := uuid.Parse(ee.EnrollId)
+	if err != nil {
+		logLookupError(EventEnrollFailed, id, err)
+		return
+	}
+	status, err := db.GetEnrollErrorStatus(id)
+	if err != nil {
+		logLookupError(EventEnrollFailed, id, err)
+		return
+	}
+	queueEvent(EventEnrollFailed, getFailedEventData(id, status))
+}
+
+// unenroll was moved to unenroll_error
+func UnenrollFailed(ee *structs.EnrollError) {
+	if !isEnabled {
+		return
+	}
+	id, err := uuid.Parse(ee.EnrollId)
+	if err != nil {
+		logLookupError(EventUnenrollFailed, id, err)
+		return
+	}
+	status, err := db.GetUnenrollErrorStatus(id)
+	if err != nil {
+		logLookupError(EventUnenrollFailed, id, err)
+		return
+	}
+	queueEvent(EventUnenrollFailed, getFailedEventData(id, status))
+}
+
+func getFailedEventData(id uuid.UUID,
+	status *structs.EnrollErrorStatus) *EventData {
+	data := EventData{
+		Id:        id,
+		TenantId:  status.TenantId,
+		UserId:    status.UserId,
+		ErrorCode: status.ErrorCode,
+		ErrorText: status.ErrorText,
+	}
+	if status.DeviceId != uuid.Nil {
+		data.DeviceId = status.DeviceId.String()
+	}
+	return &data
+}
+
+// record a delivery for every webhook of the tenant and wake up dispatcher
+func queueEvent(eventType string, data *EventData) {
+	ev := Event{
+		Id:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      *data,
+	}
+	payload, err := json.Marshal(&ev)
+	if err != nil {
+		esLogger.Error("Failed to marshal webhook event",
+			zap.String("type", eventType),
+			zap.Error(err))
+		return
+	}
+	count, err := db.CreateWebhookDeliveries(data.TenantId, eventType,
+		string(payload))
+	if err != nil {
+		esLogger.Error("Failed to queue webhook event",
+			zap.String("type", eventType),
+			zap.String("id", data.Id.String()),
+			zap.Error(err))
+		return
+	}
+	if count == 0 {
+		return
+	}
+	esLogger.Debug("Queued webhook event",
+		zap.String("type", eventType),
+		zap.String("id", data.Id.String()),
+		zap.Int64("deliveries", count))
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func logLookupError(eventType string, id uuid.UUID, err error) {
+	esLogger.Error("Could not look up record for webhook event",
+		zap.String("type", eventType),
+		zap.String("id", id.String()),
+		zap.Error(err))
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/HPInc/krypton-es/es/service/config"
+	"go.uber.org/zap"
+)
+
+var (
+	// Structured logging using Uber Zap.
+	esLogger *zap.Logger
+
+	// Global context for the package.
+	gCtx context.Context
+
+	// cancel func helps in propagating shutdown to dispatcher
+	gCancelFunc context.CancelFunc
+
+	// config settings
+	webhookSettings *config.Webhook
+
+	// events are queued only when enabled
+	isEnabled bool
+
+	// client for delivery attempts
+	httpClient *http.Client
+
+	// wakes up dispatcher when an event is queued on this instance
+	wakeup = make(chan struct{}, 1)
+
+	dispatcherDone sync.WaitGroup
+
+	ErrInvalidSettings     = errors.New("invalid webhook settings")
+	ErrInvalidSecretKey    = errors.New("webhook secret key must be base64 of 32 bytes")
+	ErrSecretKeyNotSet     = errors.New("webhook secret key is not set")
+	ErrInvalidSealedSecret = errors.New("stored webhook secret could not be decrypted")
+	ErrAddressNotAllowed   = errors.New("webhook address is not public")
+
+	// recorded as last error of a delivery. receiver responses and
+	// network details are only logged.
+	ErrUnexpectedResponse = errors.New("unexpected response status")
+	ErrRequestFailed      = errors.New("delivery request failed")
+)
+
+func Init(logger *zap.Logger, settings *config.Webhook) error {
+	esLogger = logger
+	webhookSettings = settings
+
+	// key is loaded when set so webhooks can be registered on
+	// instances that do not run the dispatcher
+	if settings.SecretKey != "" {
+		if err := initSecretKey(settings.SecretKey); err != nil {
+			esLogger.Error("Invalid webhook secret key", zap.Error(err))
+			return err
+		}
+	}
+
+	if !settings.Enabled {
+		esLogger.Info("Webhooks are disabled")
+		return nil
+	}
+	if settings.PollIntervalSeconds <= 0 || settings.BatchSize <= 0 ||
+		settings.TimeoutSeconds <= 0 || settings.MaxAttempts <= 0 ||
+		settings.InitialBackoffSeconds <= 0 ||
+		settings.MaxBackoffSeconds < settings.InitialBackoffSeconds {
+		esLogger.Error("Webhook settings must be positive",
+			zap.Any("settings", settings))
+		return ErrInvalidSettings
+	}
+	if secretAead == nil {
+		esLogger.Error("Webhook secret key is required to deliver events")
+		return ErrSecretKeyNotSet
+	}
+
+	timeout := time.Duration(settings.TimeoutSeconds) * time.Second
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the webhook host
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	httpClient = &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// a redirect could send the signed event elsewhere. do not follow.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	gCtx, gCancelFunc = context.WithCancel(context.Background())
+	isEnabled = true
+
+	dispatcherDone.Add(1)
+	go dispatch()
+
+	esLogger.Info("Webhook dispatcher started",
+		zap.Int("poll_interval_seconds", settings.PollIntervalSeconds),
+		zap.Int("max_attempts", settings.MaxAttempts))
+	return nil
+}
+
+func Shutdown() {
+	if gCancelFunc == nil {
+		return
+	}
+	esLogger.Info("Device Enrollment Service: Shutting down webhook dispatcher")
+	isEnabled = false
+	gCancelFunc()
+	dispatcherDone.Wait()
+}
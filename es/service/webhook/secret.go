@@ -0,0 +1,63 @@
+// Copyright 2025 HP Development Company, L.P.
+// SPDX-License-Identifier: MIT
+
+package webhook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// aes-256 key size
+const secretKeySize = 32
+
+// seals webhook secrets at rest. nil when no key is configured.
+var secretAead cipher.AEAD
+
+// key is base64 encoded 32 random bytes
+func initSecretKey(key string) error {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(b) != secretKeySize {
+		return ErrInvalidSecretKey
+	}
+	block, err := aes.NewCipher(b)
+	if err != nil {
+		return err
+	}
+	secretAead, err = cipher.NewGCM(block)
+	return err
+}
+
+// encrypt a webhook secret for storage. the tenant id is bound as
+// additional data so a sealed secret cannot be moved across tenants.
+func SealSecret(tenantId, secret string) (string, error) {
+	if secretAead == nil {
+		return "", ErrSecretKeyNotSet
+	}
+	nonce := make([]byte, secretAead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := secretAead.Seal(nonce, nonce, []byte(secret), []byte(tenantId))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt a stored webhook secret
+func openSecret(tenantId, sealed string) (string, error) {
+	if secretAead == nil {
+		return "", ErrSecretKeyNotSet
+	}
+	b, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(b) < secretAead.NonceSize() {
+		return "", ErrInvalidSealedSecret
+	}
+	nonceSize := secretAead.NonceSize()
+	secret, err := secretAead.Open(nil, b[:nonceSize], b[nonceSize:],
+		[]byte(tenantId))
+	if err != nil {
+		return "", ErrInvalidSealedSecret
+	}
+	return string(secret), nil
+}